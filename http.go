@@ -0,0 +1,316 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"io"
+	"log"
+	"net/http"
+	"net/url"
+	"os"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/gorilla/websocket"
+	"github.com/umk/llmservices/internal/auth"
+	"github.com/umk/llmservices/internal/config"
+	"github.com/umk/llmservices/internal/gateway"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/propagation"
+)
+
+const (
+	shutdownTimeout   = 30 * time.Second
+	readHeaderTimeout = 10 * time.Second
+	maxRequestSize    = 32 << 20
+)
+
+var upgrader = websocket.Upgrader{
+	CheckOrigin: checkOrigin,
+}
+
+// ServeHTTP serves JSON-RPC over HTTP POST and WebSocket at the address until
+// a signal is received. Every HTTP request has its own session, and cannot
+// receive callbacks. A WebSocket connection has a session for its lifetime,
+// and receives callbacks like the stdio and socket transports do.
+func ServeHTTP(ctx context.Context, addr string, shutdown <-chan os.Signal) error {
+	var conns wsConns
+
+	var h http.Handler = http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if !checkOrigin(r) {
+			http.Error(w, "origin not allowed", http.StatusForbidden)
+			return
+		}
+
+		if websocket.IsWebSocketUpgrade(r) {
+			serveWebSocket(ctx, w, r, &conns)
+		} else {
+			servePost(w, r)
+		}
+	})
+
+	if config.Cur.Gateway {
+		mux := http.NewServeMux()
+		mux.Handle("/v1/", gateway.Handler())
+		mux.Handle("/", h)
+		h = mux
+	}
+
+	s := &http.Server{
+		Addr:              addr,
+		Handler:           withTraceContext(h),
+		ReadHeaderTimeout: readHeaderTimeout,
+	}
+
+	done := make(chan error, 1)
+	go func() {
+		<-shutdown
+
+		ctx, cancel := context.WithTimeout(ctx, shutdownTimeout)
+		defer cancel()
+
+		// WebSocket connections are hijacked, so the server doesn't track
+		// them, and they must be closed separately.
+		err := errors.Join(s.Shutdown(ctx), conns.close(ctx))
+		done <- err
+	}()
+
+	if err := s.ListenAndServe(); !errors.Is(err, http.ErrServerClosed) {
+		return err
+	}
+
+	return <-done
+}
+
+// checkOrigin rejects the requests made by the pages of other sites, which
+// browsers send with the Origin header. Otherwise, any site opened in the
+// browser could call the service with the keys of its providers.
+func checkOrigin(r *http.Request) bool {
+	origin := r.Header.Get("Origin")
+	if origin == "" {
+		return true
+	}
+
+	u, err := url.Parse(origin)
+	if err != nil {
+		return false
+	}
+
+	return strings.EqualFold(u.Host, r.Host)
+}
+
+// withTraceContext continues the trace of the caller, if the request has the
+// traceparent header.
+func withTraceContext(h http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		ctx := otel.GetTextMapPropagator().Extract(r.Context(), propagation.HeaderCarrier(r.Header))
+		h.ServeHTTP(w, r.WithContext(ctx))
+	})
+}
+
+func servePost(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		w.Header().Set("Allow", http.MethodPost)
+		http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	p, ok := authenticate(w, r)
+	if !ok {
+		return
+	}
+
+	in := http.MaxBytesReader(w, r.Body, maxRequestSize)
+
+	var out bytes.Buffer
+	if err := (Runner{NoCallbacks: true, Principal: p}).Run(r.Context(), in, &out); err != nil {
+		var maxErr *http.MaxBytesError
+		if errors.As(err, &maxErr) {
+			http.Error(w, "request too large", http.StatusRequestEntityTooLarge)
+		} else {
+			http.Error(w, "bad request", http.StatusBadRequest)
+		}
+		return
+	}
+
+	// Notifications don't have a response.
+	if out.Len() == 0 {
+		w.WriteHeader(http.StatusNoContent)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.Write(out.Bytes())
+}
+
+func serveWebSocket(ctx context.Context, w http.ResponseWriter, r *http.Request, conns *wsConns) {
+	p, ok := authenticate(w, r)
+	if !ok {
+		return
+	}
+
+	conn, err := upgrader.Upgrade(w, r, nil)
+	if err != nil {
+		// The upgrader has already responded with an error.
+		return
+	}
+
+	if !conns.add(conn) {
+		conn.Close()
+		return
+	}
+	defer conns.remove(conn)
+
+	rw := &wsReadWriter{conn: conn, conns: conns}
+	if err := (Runner{Principal: p}).Run(ctx, rw, rw); err != nil {
+		log.Println("Error serving WebSocket:", err)
+	}
+
+	msg := websocket.FormatCloseMessage(websocket.CloseNormalClosure, "")
+	if conns.isClosed() {
+		msg = websocket.FormatCloseMessage(websocket.CloseGoingAway, "server is shutting down")
+	}
+	conn.WriteControl(websocket.CloseMessage, msg, time.Now().Add(time.Second))
+}
+
+// authenticate returns the principal identified by the bearer token of the
+// request. If there is no token, the session must authenticate by itself.
+func authenticate(w http.ResponseWriter, r *http.Request) (*auth.Principal, bool) {
+	token := auth.GetBearerToken(r.Header.Get("Authorization"))
+	if token == "" {
+		return nil, true
+	}
+
+	p, err := auth.Authenticate(token)
+	if err != nil {
+		w.Header().Set("WWW-Authenticate", "Bearer")
+		http.Error(w, err.Error(), http.StatusUnauthorized)
+		return nil, false
+	}
+
+	return p, true
+}
+
+// wsReadWriter exchanges JSON-RPC messages over a WebSocket connection, one
+// message per WebSocket message.
+type wsReadWriter struct {
+	conn  *websocket.Conn
+	conns *wsConns
+
+	r    io.Reader
+	wbuf []byte
+}
+
+// Read reads the WebSocket messages as lines of text.
+func (rw *wsReadWriter) Read(p []byte) (int, error) {
+	for {
+		if rw.r != nil {
+			n, err := rw.r.Read(p)
+			if err != io.EOF {
+				return n, err
+			}
+			rw.r = nil
+			p[0] = '\n'
+			return 1, nil
+		}
+
+		_, r, err := rw.conn.NextReader()
+		if err != nil {
+			// Reading is interrupted on shutdown, so that the requests in
+			// progress are completed before the connection is closed.
+			if rw.conns.isClosed() || websocket.IsCloseError(err, websocket.CloseNormalClosure, websocket.CloseGoingAway) {
+				return 0, io.EOF
+			}
+			return 0, err
+		}
+		rw.r = r
+	}
+}
+
+// Write sends every line of text written as a WebSocket message.
+func (rw *wsReadWriter) Write(p []byte) (int, error) {
+	rw.wbuf = append(rw.wbuf, p...)
+
+	for {
+		i := bytes.IndexByte(rw.wbuf, '\n')
+		if i < 0 {
+			return len(p), nil
+		}
+
+		if err := rw.conn.WriteMessage(websocket.TextMessage, rw.wbuf[:i]); err != nil {
+			return 0, err
+		}
+		rw.wbuf = rw.wbuf[i+1:]
+	}
+}
+
+// wsConns tracks the open WebSocket connections to close them on shutdown.
+type wsConns struct {
+	mu     sync.Mutex
+	conns  map[*websocket.Conn]struct{}
+	closed bool
+	wg     sync.WaitGroup
+}
+
+func (c *wsConns) add(conn *websocket.Conn) bool {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if c.closed {
+		return false
+	}
+	if c.conns == nil {
+		c.conns = make(map[*websocket.Conn]struct{})
+	}
+	c.conns[conn] = struct{}{}
+	c.wg.Add(1)
+
+	return true
+}
+
+func (c *wsConns) remove(conn *websocket.Conn) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	delete(c.conns, conn)
+	conn.Close()
+	c.wg.Done()
+}
+
+func (c *wsConns) isClosed() bool {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	return c.closed
+}
+
+// close stops reading the requests from the connections, and waits until
+// the requests in progress are completed, or the context is done.
+func (c *wsConns) close(ctx context.Context) error {
+	c.mu.Lock()
+	c.closed = true
+	for conn := range c.conns {
+		conn.SetReadDeadline(time.Now())
+	}
+	c.mu.Unlock()
+
+	done := make(chan struct{})
+	go func() {
+		c.wg.Wait()
+		close(done)
+	}()
+
+	select {
+	case <-done:
+		return nil
+	case <-ctx.Done():
+		c.mu.Lock()
+		for conn := range c.conns {
+			conn.Close()
+		}
+		c.mu.Unlock()
+		return ctx.Err()
+	}
+}
@@ -0,0 +1,88 @@
+// Package auth authenticates the sessions served over the network, and
+// authorizes their access to the global clients, the methods and the models.
+// Unless the tokens or the secret are configured, any session has full access.
+package auth
+
+import (
+	"crypto/sha256"
+	"errors"
+	"strings"
+)
+
+var (
+	ErrNotAuthenticated = errors.New("session is not authenticated")
+	ErrInvalidToken     = errors.New("token is invalid or expired")
+	ErrSigningDisabled  = errors.New("secret to sign tokens is not configured")
+)
+
+type Config struct {
+	// Static tokens to pass either as bearer tokens or to the authenticate
+	// method.
+	Tokens []Token `yaml:"tokens,omitempty" validate:"dive"`
+	// Key to sign and verify the session tokens with HMAC-SHA256. If not
+	// specified, the signed tokens are not accepted.
+	Secret string `yaml:"secret,omitempty" validate:"omitempty,min=32"`
+}
+
+type Token struct {
+	// Name of the token to tell its sessions apart.
+	Name   string `yaml:"name" validate:"required"`
+	Token  string `yaml:"token" validate:"required,min=16"`
+	Policy `yaml:",inline"`
+}
+
+var (
+	enabled bool
+	tokens  map[[sha256.Size]byte]*Principal
+	secret  []byte
+)
+
+// Init enables authentication of the sessions served over the network, if
+// the configuration has any tokens or a secret.
+func Init(config Config) {
+	tokens = make(map[[sha256.Size]byte]*Principal, len(config.Tokens))
+	for _, t := range config.Tokens {
+		tokens[sha256.Sum256([]byte(t.Token))] = &Principal{
+			Name:     t.Name,
+			Policies: []Policy{t.Policy},
+		}
+	}
+
+	if config.Secret != "" {
+		secret = []byte(config.Secret)
+	}
+
+	enabled = len(tokens) > 0 || secret != nil
+}
+
+// Enabled tells whether the sessions served over the network must be
+// authenticated.
+func Enabled() bool {
+	return enabled
+}
+
+// Authenticate returns the principal identified by either a static or
+// a signed token.
+func Authenticate(token string) (*Principal, error) {
+	// Tokens are looked up by their hashes, so that the time of lookup
+	// doesn't depend on how much of a token is guessed.
+	if p, ok := tokens[sha256.Sum256([]byte(token))]; ok {
+		return p, nil
+	}
+
+	if secret != nil && strings.Contains(token, ".") {
+		return verify(token)
+	}
+
+	return nil, ErrInvalidToken
+}
+
+// GetBearerToken returns the token of the Authorization header value, or
+// an empty string if the value isn't a bearer token.
+func GetBearerToken(header string) string {
+	scheme, token, ok := strings.Cut(header, " ")
+	if !ok || !strings.EqualFold(scheme, "Bearer") {
+		return ""
+	}
+	return strings.TrimSpace(token)
+}
@@ -0,0 +1,58 @@
+package auth
+
+import (
+	"context"
+	"sync"
+	"time"
+)
+
+type ContextKey string
+
+const (
+	CtxSession ContextKey = "session"
+)
+
+type session struct {
+	mu        sync.Mutex
+	principal *Principal
+}
+
+// Context returns the context of a session on behalf of the principal. If the
+// principal is nil, the session must authenticate before accessing anything.
+func Context(ctx context.Context, principal *Principal) context.Context {
+	return context.WithValue(ctx, CtxSession, &session{principal: principal})
+}
+
+// SetPrincipal changes the principal of the session.
+func SetPrincipal(ctx context.Context, principal *Principal) {
+	s := ctx.Value(CtxSession).(*session)
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.principal = principal
+}
+
+// GetPrincipal returns the principal of the session. If authentication is
+// not enabled, any session has full access.
+func GetPrincipal(ctx context.Context) (*Principal, error) {
+	if !enabled {
+		return Unrestricted, nil
+	}
+
+	s := ctx.Value(CtxSession).(*session)
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if s.principal == nil {
+		return nil, ErrNotAuthenticated
+	}
+
+	// The session outlives the token it's authenticated with.
+	if s.principal.ExpiresAt != 0 && s.principal.ExpiresAt <= time.Now().Unix() {
+		return nil, ErrInvalidToken
+	}
+
+	return s.principal, nil
+}
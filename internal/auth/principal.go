@@ -0,0 +1,129 @@
+package auth
+
+import (
+	"fmt"
+	"slices"
+	"strings"
+)
+
+// Policy permits access to the global clients, the methods, the models, the
+// global MCP servers and the global indexes that match any of its patterns. A pattern may contain * to match any
+// sequence of characters. If a list is omitted, anything is permitted.
+type Policy struct {
+	Clients []string `json:"clients" yaml:"clients,omitempty"`
+	Methods []string `json:"methods" yaml:"methods,omitempty"`
+	Models  []string `json:"models" yaml:"models,omitempty"`
+	// IDs of the global MCP servers, whose tools may be offered to the
+	// models.
+	MCPServers []string `json:"mcp_servers" yaml:"mcpservers,omitempty"`
+	// IDs of the global indexes, in which documents may be upserted or
+	// deleted. Any global index can be searched.
+	Indexes []string `json:"indexes" yaml:"indexes,omitempty"`
+}
+
+// Principal is the caller on behalf of which the session is served.
+type Principal struct {
+	Name string
+	// Access is permitted only if all of the policies permit it.
+	Policies []Policy
+	// Time in Unix seconds after which the principal is not accepted, or
+	// zero if it doesn't expire.
+	ExpiresAt int64
+}
+
+// Unrestricted is the principal of the sessions, which are trusted by the
+// transport, like the one served from stdio.
+var Unrestricted = &Principal{Name: "unrestricted"}
+
+// PermissionError is returned if the principal doesn't have access to
+// a resource.
+type PermissionError struct {
+	Kind string
+	Name string
+}
+
+func (e *PermissionError) Error() string {
+	return fmt.Sprintf("%s is not permitted: %s", e.Kind, e.Name)
+}
+
+func (p *Principal) CheckClient(clientID string) error {
+	if !p.allows(func(policy *Policy) []string { return policy.Clients }, clientID) {
+		return &PermissionError{Kind: "client", Name: clientID}
+	}
+	return nil
+}
+
+func (p *Principal) CheckMethod(method string) error {
+	if !p.allows(func(policy *Policy) []string { return policy.Methods }, method) {
+		return &PermissionError{Kind: "method", Name: method}
+	}
+	return nil
+}
+
+func (p *Principal) CheckMCPServer(serverID string) error {
+	if !p.allows(func(policy *Policy) []string { return policy.MCPServers }, serverID) {
+		return &PermissionError{Kind: "MCP server", Name: serverID}
+	}
+	return nil
+}
+
+func (p *Principal) CheckIndex(indexID string) error {
+	if !p.allows(func(policy *Policy) []string { return policy.Indexes }, indexID) {
+		return &PermissionError{Kind: "index", Name: indexID}
+	}
+	return nil
+}
+
+func (p *Principal) AllowsModel(model string) bool {
+	return p.allows(func(policy *Policy) []string { return policy.Models }, model)
+}
+
+// RestrictsModels tells whether any of the models is not permitted.
+func (p *Principal) RestrictsModels() bool {
+	return slices.ContainsFunc(p.Policies, func(policy Policy) bool {
+		return policy.Models != nil
+	})
+}
+
+func (p *Principal) allows(patterns func(policy *Policy) []string, name string) bool {
+	for i := range p.Policies {
+		if !matchAny(patterns(&p.Policies[i]), name) {
+			return false
+		}
+	}
+	return true
+}
+
+func matchAny(patterns []string, name string) bool {
+	if patterns == nil {
+		return true
+	}
+	return slices.ContainsFunc(patterns, func(pattern string) bool {
+		return match(pattern, name)
+	})
+}
+
+func match(pattern, name string) bool {
+	parts := strings.Split(pattern, "*")
+	if len(parts) == 1 {
+		return pattern == name
+	}
+
+	first, last := parts[0], parts[len(parts)-1]
+	if len(name) < len(first)+len(last) || !strings.HasPrefix(name, first) || !strings.HasSuffix(name, last) {
+		return false
+	}
+
+	// The parts in the middle are matched in order between the first
+	// and the last ones.
+	name = name[len(first) : len(name)-len(last)]
+	for _, part := range parts[1 : len(parts)-1] {
+		i := strings.Index(name, part)
+		if i < 0 {
+			return false
+		}
+		name = name[i+len(part):]
+	}
+
+	return true
+}
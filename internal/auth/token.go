@@ -0,0 +1,71 @@
+package auth
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/json"
+	"strings"
+	"time"
+)
+
+// Claims are the contents of a signed session token.
+type Claims struct {
+	Subject string `json:"sub"`
+	// Time in Unix seconds after which the token is not accepted.
+	ExpiresAt int64 `json:"exp"`
+	// Access is permitted only if all of the policies permit it.
+	Policies []Policy `json:"acl"`
+}
+
+// Sign returns a session token with the claims, which is accepted until it
+// expires, or the secret is changed.
+func Sign(claims Claims) (string, error) {
+	if secret == nil {
+		return "", ErrSigningDisabled
+	}
+
+	b, err := json.Marshal(claims)
+	if err != nil {
+		return "", err
+	}
+
+	payload := base64.RawURLEncoding.EncodeToString(b)
+
+	return payload + "." + base64.RawURLEncoding.EncodeToString(getSignature(payload)), nil
+}
+
+func verify(token string) (*Principal, error) {
+	payload, sig, _ := strings.Cut(token, ".")
+
+	s, err := base64.RawURLEncoding.DecodeString(sig)
+	if err != nil || !hmac.Equal(s, getSignature(payload)) {
+		return nil, ErrInvalidToken
+	}
+
+	b, err := base64.RawURLEncoding.DecodeString(payload)
+	if err != nil {
+		return nil, ErrInvalidToken
+	}
+
+	var claims Claims
+	if err := json.Unmarshal(b, &claims); err != nil {
+		return nil, ErrInvalidToken
+	}
+
+	if claims.ExpiresAt <= time.Now().Unix() {
+		return nil, ErrInvalidToken
+	}
+
+	return &Principal{
+		Name:      claims.Subject,
+		Policies:  claims.Policies,
+		ExpiresAt: claims.ExpiresAt,
+	}, nil
+}
+
+func getSignature(payload string) []byte {
+	h := hmac.New(sha256.New, secret)
+	h.Write([]byte(payload))
+	return h.Sum(nil)
+}
@@ -1,12 +1,27 @@
 package config
 
 import (
+	"context"
+	"errors"
 	"flag"
 	"fmt"
+	"log"
+	"maps"
 	"os"
+	"slices"
+	"strings"
+	"time"
 
+	"github.com/umk/llmservices/internal/auth"
+	"github.com/umk/llmservices/internal/logging"
+	"github.com/umk/llmservices/internal/metrics"
+	"github.com/umk/llmservices/internal/service/callbacks"
 	"github.com/umk/llmservices/internal/service/handlers"
+	"github.com/umk/llmservices/internal/service/handlers/documents"
 	"github.com/umk/llmservices/pkg/client"
+	"github.com/umk/llmservices/pkg/mcp"
+	"github.com/umk/llmservices/pkg/tools"
+	"github.com/umk/llmservices/pkg/vector"
 )
 
 type Config struct {
@@ -16,12 +31,49 @@ type Config struct {
 	File string
 	// Name of the default client to override the config file's Default.
 	Default string
+	// TCP address to serve from over HTTP and WebSocket instead of stdio.
+	Listen string
+	// Whether to serve the OpenAI-compatible API along with JSON-RPC.
+	Gateway bool
+	// Whether to serve over the network with full access for any session,
+	// if authentication is not configured.
+	Insecure bool
+	// Whether to speak the Model Context Protocol over stdio instead of the
+	// methods of the service.
+	MCP bool
+	// TCP address to serve the Prometheus metrics from at /metrics.
+	Metrics string
+	// OTLP/HTTP endpoint to export the traces to.
+	OTLP string
+	// Minimum level of the records to log, which overrides the one of the
+	// config file.
+	LogLevel string
+	// Whether to log the messages and the completions.
+	LogContent bool
 }
 
+// Budgets keeps the spend of the global clients, which must be saved
+// before the process exits.
+var Budgets *client.Budgets
+
+var cache client.Cache
+
+// Time to wait for a global MCP server to start and initialize.
+const mcpConnectTimeout = 30 * time.Second
+
 var Cur = Config{
-	Socket:  "",
-	File:    "",
-	Default: "",
+	Socket:   "",
+	File:     "",
+	Default:  "",
+	Listen:   "",
+	Gateway:  false,
+	Insecure: false,
+	MCP:      false,
+	Metrics:  "",
+	OTLP:     "",
+
+	LogLevel:   "",
+	LogContent: false,
 }
 
 func Init() error {
@@ -36,6 +88,14 @@ func Init() error {
 	flag.StringVar(&Cur.Socket, "socket", Cur.Socket, "unix domain socket path to serve from instead of stdio")
 	flag.StringVar(&Cur.File, "config", Cur.File, "path to a configuration file")
 	flag.StringVar(&Cur.Default, "default", Cur.Default, "ID of default client")
+	flag.StringVar(&Cur.Listen, "listen", Cur.Listen, "TCP address like :8080 to serve from over HTTP and WebSocket instead of stdio")
+	flag.BoolVar(&Cur.Gateway, "gateway", Cur.Gateway, "serve the OpenAI-compatible API under /v1/ at the -listen address")
+	flag.BoolVar(&Cur.Insecure, "insecure", Cur.Insecure, "serve at the -listen address with full access for anyone if no tokens or secret are configured")
+	flag.BoolVar(&Cur.MCP, "mcp", Cur.MCP, "speak the Model Context Protocol over stdio, exposing the methods as tools and the clients as resources")
+	flag.StringVar(&Cur.Metrics, "metrics", Cur.Metrics, "TCP address like :9090 to serve the Prometheus metrics from at /metrics")
+	flag.StringVar(&Cur.OTLP, "otlp", Cur.OTLP, "OTLP/HTTP endpoint like http://localhost:4318 to export the traces to")
+	flag.StringVar(&Cur.LogLevel, "log-level", Cur.LogLevel, "minimum level of the records to log: debug, info, warn or error")
+	flag.BoolVar(&Cur.LogContent, "log-content", Cur.LogContent, "log the messages and the completions, with the sensitive values masked")
 
 	// Parse the flags
 	flag.Parse()
@@ -45,15 +105,57 @@ func Init() error {
 		os.Exit(2)
 	}
 
+	if Cur.Gateway && Cur.Listen == "" {
+		return errors.New("-gateway requires -listen")
+	}
+
+	if Cur.MCP && (Cur.Listen != "" || Cur.Socket != "") {
+		return errors.New("-mcp cannot be combined with -listen or -socket")
+	}
+
 	f, err := readConfigFiles()
 	if err != nil {
 		return fmt.Errorf("failed to read config file: %w", err)
 	}
 
+	if err := initLogging(f); err != nil {
+		return fmt.Errorf("failed to initialize logging: %w", err)
+	}
+
+	auth.Init(f.Auth)
+
+	// Unlike stdio and the socket, the address may be reachable by anyone.
+	if Cur.Listen != "" && !auth.Enabled() {
+		if !Cur.Insecure {
+			return errors.New("-listen requires tokens or a secret in the auth section of the config file, or -insecure")
+		}
+		log.Println("Warning: serving at", Cur.Listen, "with full access for anyone, since authentication is not configured")
+	}
+
+	if err := initBudgets(f); err != nil {
+		return fmt.Errorf("failed to initialize budgets: %w", err)
+	}
+
+	if err := initCache(f); err != nil {
+		return fmt.Errorf("failed to initialize cache: %w", err)
+	}
+
 	if err := initClients(f); err != nil {
 		return fmt.Errorf("failed to initialize clients: %w", err)
 	}
 
+	if err := initIndexes(f); err != nil {
+		return fmt.Errorf("failed to initialize indexes: %w", err)
+	}
+
+	if err := initTools(f); err != nil {
+		return fmt.Errorf("failed to initialize tools: %w", err)
+	}
+
+	if err := initMCPServers(f); err != nil {
+		return fmt.Errorf("failed to initialize MCP servers: %w", err)
+	}
+
 	return nil
 }
 
@@ -64,12 +166,44 @@ func initClients(config ConfigFile) error {
 
 	clients := make(map[string]*client.Client)
 
-	for id, conf := range config.Clients {
-		c, err := client.New(&conf)
-		if err != nil {
-			return err
+	getClient := func(clientID string) (*client.Client, error) {
+		if c, ok := clients[clientID]; ok {
+			return c, nil
+		}
+		return nil, fmt.Errorf("client %q not found", clientID)
+	}
+
+	// Clients that fall back to other clients are created after all of
+	// the clients they refer to.
+	pending := maps.Clone(config.Clients)
+
+	for len(pending) > 0 {
+		n := len(pending)
+
+		for id, conf := range pending {
+			if !slices.ContainsFunc(conf.Fallback, func(t client.FallbackTarget) bool {
+				_, ok := clients[t.ClientID]
+				return !ok
+			}) {
+				c, err := client.New(&conf,
+					client.WithName(id),
+					client.WithClients(getClient),
+					client.WithBudgets(Budgets),
+					client.WithCache(cache),
+					client.WithObserver(metrics.NewObserver(id, &conf)),
+					client.WithLogger(logging.Logger, logging.Content))
+				if err != nil {
+					return fmt.Errorf("failed to create client %q: %w", id, err)
+				}
+				clients[id] = c
+				delete(pending, id)
+			}
+		}
+
+		if len(pending) == n {
+			ids := slices.Sorted(maps.Keys(pending))
+			return fmt.Errorf("clients refer to missing clients or each other: %s", strings.Join(ids, ", "))
 		}
-		clients[id] = c
 	}
 
 	for id, c := range clients {
@@ -97,3 +231,107 @@ func initClients(config ConfigFile) error {
 
 	return nil
 }
+
+func initLogging(config ConfigFile) error {
+	if Cur.LogLevel != "" {
+		config.Log.Level = Cur.LogLevel
+	}
+	if Cur.LogContent {
+		config.Log.Content = true
+	}
+
+	return logging.Init(config.Log)
+}
+
+func initBudgets(config ConfigFile) error {
+	// Unless any of the clients has a budget, there's no spend to keep
+	// across restarts. If the home directory is unknown, it's kept in
+	// memory only.
+	path := config.Budget.Path
+	if path == "" && slices.ContainsFunc(slices.Collect(maps.Values(config.Clients)), func(c client.Config) bool {
+		return c.Budget != nil
+	}) {
+		if p, err := defaultBudgetsPath(); err == nil {
+			path = p
+		}
+	}
+
+	b, err := client.OpenBudgets(path)
+	if err != nil {
+		return err
+	}
+
+	Budgets = b
+	handlers.SetSessionTokens(config.Budget.SessionTokens)
+
+	return nil
+}
+
+func initCache(config ConfigFile) error {
+	if config.Cache.Path == "" {
+		cache = client.NewMemoryCache(config.Cache.Size)
+	} else {
+		c, err := client.OpenDiskCache(config.Cache.Path, config.Cache.Size)
+		if err != nil {
+			return err
+		}
+		cache = c
+	}
+
+	handlers.SetCache(cache)
+
+	return nil
+}
+
+func initIndexes(config ConfigFile) error {
+	for id, conf := range config.Indexes {
+		s, err := vector.Open(conf)
+		if err != nil {
+			return fmt.Errorf("failed to open index %q: %w", id, err)
+		}
+		documents.SetGlobalIndex(id, s)
+	}
+
+	return nil
+}
+
+func initTools(config ConfigFile) error {
+	r := tools.NewRegistry()
+
+	for name, conf := range config.Tools {
+		var t tools.Tool
+		switch conf.Type {
+		case "calculator":
+			t = tools.NewCalculator(name, conf.Description)
+		case "time":
+			t = tools.NewTime(name, conf.Description)
+		case "jsonpath":
+			t = tools.NewJSONPath(name, conf.Description)
+		case "search":
+			t = documents.NewSearchTool(name, conf.Description, *conf.Search)
+		default:
+			return fmt.Errorf("unknown type of tool %q: %s", name, conf.Type)
+		}
+		if err := r.Register(t); err != nil {
+			return err
+		}
+	}
+
+	callbacks.SetTools(r)
+
+	return nil
+}
+
+func initMCPServers(config ConfigFile) error {
+	for id, conf := range config.MCPServers {
+		ctx, cancel := context.WithTimeout(context.Background(), mcpConnectTimeout)
+		s, err := mcp.Connect(ctx, &conf)
+		cancel()
+		if err != nil {
+			return fmt.Errorf("failed to connect to MCP server %q: %w", id, err)
+		}
+		handlers.SetGlobalMCPServer(id, s)
+	}
+
+	return nil
+}
@@ -5,7 +5,12 @@ import (
 	"path/filepath"
 
 	"github.com/go-playground/validator/v10"
+	"github.com/umk/llmservices/internal/auth"
+	"github.com/umk/llmservices/internal/logging"
+	"github.com/umk/llmservices/internal/service/handlers/documents"
 	"github.com/umk/llmservices/pkg/client"
+	"github.com/umk/llmservices/pkg/mcp"
+	"github.com/umk/llmservices/pkg/vector"
 	"gopkg.in/yaml.v3"
 )
 
@@ -14,6 +19,48 @@ type ConfigFile struct {
 	Clients map[string]client.Config `yaml:"clients,omitempty" validate:"dive"`
 	// A name of the client that specified as default in a global clients list.
 	Default string `yaml:"default,omitempty"`
+	// A map of global indexes of documents available for any session.
+	Indexes map[string]vector.Config `yaml:"indexes,omitempty" validate:"dive"`
+	// Tokens to authenticate the sessions served over the network with.
+	Auth auth.Config `yaml:"auth,omitempty"`
+	// Budgets shared by the global clients and the sessions.
+	Budget BudgetConfig `yaml:"budget,omitempty"`
+	// Cache of the responses of the clients that enable caching.
+	Cache CacheConfig `yaml:"cache,omitempty"`
+	// Logging of the method calls and the requests made by the clients.
+	Log logging.Config `yaml:"log,omitempty"`
+	// A map of tools executed by the service, which the requests may offer
+	// to the model by their names.
+	Tools map[string]ToolConfig `yaml:"tools,omitempty" validate:"dive"`
+	// A map of global MCP servers, whose tools the requests may offer to
+	// the model by the IDs of the servers.
+	MCPServers map[string]mcp.Config `yaml:"mcpservers,omitempty" validate:"dive"`
+}
+
+type BudgetConfig struct {
+	// Path to the file to keep the spend of the clients in across restarts.
+	// If not specified, a default file is used.
+	Path string `yaml:"path,omitempty"`
+	// Tokens a session may use with any of the clients.
+	SessionTokens int64 `yaml:"sessiontokens,omitempty" validate:"min=0"`
+}
+
+type CacheConfig struct {
+	// Directory to keep the responses in across restarts. If not specified,
+	// the responses are kept in memory.
+	Path string `yaml:"path,omitempty"`
+	// Number of the most recently used responses to keep.
+	Size int `yaml:"size,omitempty" validate:"min=0"`
+}
+
+type ToolConfig struct {
+	// Type of the built-in tool: calculator, time, jsonpath or search.
+	Type string `yaml:"type" validate:"required,oneof=calculator time jsonpath search"`
+	// Description of the tool for the model. If not specified, a default
+	// description of the type is used.
+	Description string `yaml:"description,omitempty"`
+	// Parameters of the search made by a tool of the search type.
+	Search *documents.SearchParams `yaml:"search,omitempty" validate:"required_if=Type search"`
 }
 
 func readConfigFiles() (ConfigFile, error) {
@@ -60,3 +107,11 @@ func defaultConfigPath() (string, error) {
 	}
 	return filepath.Join(home, "llmservices.yaml"), nil
 }
+
+func defaultBudgetsPath() (string, error) {
+	home, err := os.UserHomeDir()
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(home, "llmservices.budgets.json"), nil
+}
@@ -0,0 +1,446 @@
+package gateway
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/umk/jsonrpc2"
+	"github.com/umk/llmservices/pkg/adapter"
+)
+
+func chatCompletionsHandler(w http.ResponseWriter, r *http.Request) {
+	var req chatCompletionRequest
+	if !readRequest(w, r, &req) {
+		return
+	}
+
+	cl, model, err := getClient(r.Context(), req.Model, "getCompletion")
+	if err != nil {
+		writeGetClientError(w, err)
+		return
+	}
+
+	messages, params, err := getCompletionParams(&req)
+	if err != nil {
+		writeError(w, http.StatusBadRequest, "invalid_request_error", err.Error())
+		return
+	}
+
+	params.Model = model
+
+	if err := jsonrpc2.Val.Struct(completionRequest{Messages: messages, Params: params}); err != nil {
+		writeError(w, http.StatusBadRequest, "invalid_request_error", err.Error())
+		return
+	}
+
+	id := newCompletionID()
+	created := time.Now().Unix()
+
+	if !req.Stream {
+		resp, err := cl.Completion(r.Context(), messages, params)
+		if err != nil {
+			writeClientError(w, err)
+			return
+		}
+
+		writeJSON(w, http.StatusOK, getChatCompletion(&resp, id, created, req.Model))
+		return
+	}
+
+	s := chunkStream{
+		w: w,
+		chunk: chatCompletionChunk{
+			ID:      id,
+			Object:  "chat.completion.chunk",
+			Created: created,
+			Model:   req.Model,
+		},
+	}
+
+	resp, err := cl.CompletionStream(r.Context(), messages, params, s.delta)
+	if err != nil {
+		if s.started {
+			s.error(err)
+		} else {
+			writeClientError(w, err)
+		}
+		return
+	}
+
+	s.finish(&resp, req.StreamOptions != nil && req.StreamOptions.IncludeUsage)
+}
+
+// completionRequest is validated the same way as the completion requests
+// made over JSON-RPC.
+type completionRequest struct {
+	Messages []adapter.Message        `validate:"required,min=1,dive"`
+	Params   adapter.CompletionParams `validate:"required"`
+}
+
+func newCompletionID() string {
+	b := make([]byte, 12)
+	rand.Read(b)
+	return "chatcmpl-" + hex.EncodeToString(b)
+}
+
+func getCompletionParams(req *chatCompletionRequest) ([]adapter.Message, adapter.CompletionParams, error) {
+	if req.N != nil && *req.N != 1 {
+		return nil, adapter.CompletionParams{}, errors.New("only one choice is supported")
+	}
+
+	messages := make([]adapter.Message, len(req.Messages))
+	for i := range req.Messages {
+		m, err := getMessage(&req.Messages[i])
+		if err != nil {
+			return nil, adapter.CompletionParams{}, fmt.Errorf("messages[%d]: %w", i, err)
+		}
+		messages[i] = m
+	}
+
+	params := adapter.CompletionParams{
+		FrequencyPenalty: req.FrequencyPenalty,
+		MaxTokens:        req.MaxCompletionTokens,
+		PresencePenalty:  req.PresencePenalty,
+		Temperature:      req.Temperature,
+		TopP:             req.TopP,
+	}
+
+	if params.MaxTokens == nil {
+		params.MaxTokens = req.MaxTokens
+	}
+
+	if len(req.Stop) > 0 {
+		stop, err := getStringOrList(req.Stop)
+		if err != nil {
+			return nil, adapter.CompletionParams{}, fmt.Errorf("stop: %w", err)
+		}
+		params.Stop = stop
+	}
+
+	if f := req.ResponseFormat; f != nil {
+		switch f.Type {
+		case "text":
+			params.ResponseFormat = &adapter.ResponseFormat{
+				OfResponseFormatText: &adapter.ResponseFormatText{},
+			}
+		case "json_schema":
+			if f.JSONSchema == nil {
+				return nil, adapter.CompletionParams{}, errors.New("response_format: json_schema must be specified")
+			}
+			params.ResponseFormat = &adapter.ResponseFormat{
+				OfResponseFormatJSONSchema: &adapter.ResponseFormatJSONSchema{
+					JSONSchema: adapter.JSONSchema{
+						Name:        f.JSONSchema.Name,
+						Description: f.JSONSchema.Description,
+						Schema:      f.JSONSchema.Schema,
+						Strict:      f.JSONSchema.Strict,
+					},
+				},
+			}
+		default:
+			return nil, adapter.CompletionParams{}, fmt.Errorf("response_format: type is not supported: %s", f.Type)
+		}
+	}
+
+	for _, t := range req.Tools {
+		if t.Type != "function" {
+			return nil, adapter.CompletionParams{}, fmt.Errorf("tools: type is not supported: %s", t.Type)
+		}
+
+		parameters := t.Function.Parameters
+		if parameters == nil {
+			parameters = map[string]any{"type": "object", "properties": map[string]any{}}
+		}
+
+		params.Tools = append(params.Tools, adapter.Tool{
+			Function: adapter.ToolFunction{
+				Name:        t.Function.Name,
+				Description: t.Function.Description,
+				Parameters:  parameters,
+				Strict:      t.Function.Strict,
+			},
+		})
+	}
+
+	return messages, params, nil
+}
+
+func getMessage(m *chatMessage) (adapter.Message, error) {
+	switch m.Role {
+	case "system", "developer":
+		text, err := getContentText(m.Content)
+		if err != nil {
+			return adapter.Message{}, err
+		}
+		return adapter.CreateSystemMessage(text), nil
+
+	case "user":
+		parts, err := getContentParts(m.Content)
+		if err != nil {
+			return adapter.Message{}, err
+		}
+		return adapter.Message{
+			OfUserMessage: &adapter.UserMessage{Parts: parts},
+		}, nil
+
+	case "assistant":
+		a := &adapter.AssistantMessage{
+			Refusal: m.Refusal,
+		}
+		if len(m.Content) > 0 && string(m.Content) != "null" {
+			text, err := getContentText(m.Content)
+			if err != nil {
+				return adapter.Message{}, err
+			}
+			a.Content = &text
+		}
+		for _, c := range m.ToolCalls {
+			a.ToolCalls = append(a.ToolCalls, adapter.ToolCall{
+				ID: c.ID,
+				Function: adapter.ToolCallFunction{
+					Name:      c.Function.Name,
+					Arguments: c.Function.Arguments,
+				},
+			})
+		}
+		if a.Content == nil && a.Refusal == nil && len(a.ToolCalls) == 0 {
+			a.Content = new(string)
+		}
+		return adapter.Message{OfAssistantMessage: a}, nil
+
+	case "tool":
+		text, err := getContentText(m.Content)
+		if err != nil {
+			return adapter.Message{}, err
+		}
+		return adapter.CreateToolMessage(m.ToolCallID, text), nil
+
+	default:
+		return adapter.Message{}, fmt.Errorf("role is not supported: %s", m.Role)
+	}
+}
+
+// getContentParts converts the content, which is either a string or a list
+// of content parts.
+func getContentParts(content json.RawMessage) ([]adapter.ContentPart, error) {
+	var text string
+	if err := json.Unmarshal(content, &text); err == nil {
+		return []adapter.ContentPart{{
+			OfContentPartText: &adapter.ContentPartText{Text: text},
+		}}, nil
+	}
+
+	var parts []contentPart
+	if err := json.Unmarshal(content, &parts); err != nil {
+		return nil, errors.New("content must be either a string or a list of content parts")
+	}
+
+	r := make([]adapter.ContentPart, len(parts))
+	for i, p := range parts {
+		switch p.Type {
+		case "text":
+			r[i].OfContentPartText = &adapter.ContentPartText{Text: p.Text}
+		case "image_url":
+			if p.ImageURL == nil {
+				return nil, errors.New("image_url must be specified")
+			}
+			r[i].OfContentPartImageUrl = &adapter.ContentPartImage{ImageUrl: p.ImageURL.URL}
+		default:
+			return nil, fmt.Errorf("content part type is not supported: %s", p.Type)
+		}
+	}
+
+	return r, nil
+}
+
+// getContentText returns the text of the content, which is either a string
+// or a list of text content parts.
+func getContentText(content json.RawMessage) (string, error) {
+	parts, err := getContentParts(content)
+	if err != nil {
+		return "", err
+	}
+
+	var sb strings.Builder
+	for _, p := range parts {
+		if p.OfContentPartText == nil {
+			return "", errors.New("content must contain text only")
+		}
+		sb.WriteString(p.OfContentPartText.Text)
+	}
+
+	return sb.String(), nil
+}
+
+func getStringOrList(v json.RawMessage) ([]string, error) {
+	var s string
+	if err := json.Unmarshal(v, &s); err == nil {
+		return []string{s}, nil
+	}
+
+	var l []string
+	if err := json.Unmarshal(v, &l); err != nil {
+		return nil, errors.New("must be either a string or a list of strings")
+	}
+
+	return l, nil
+}
+
+func getChatCompletion(resp *adapter.Completion, id string, created int64, model string) chatCompletion {
+	m := responseMessage{
+		Role:    "assistant",
+		Content: resp.Message.Content,
+		Refusal: resp.Message.Refusal,
+	}
+
+	for _, c := range resp.Message.ToolCalls {
+		m.ToolCalls = append(m.ToolCalls, toolCall{
+			ID:   c.ID,
+			Type: "function",
+			Function: toolCallFunction{
+				Name:      c.Function.Name,
+				Arguments: c.Function.Arguments,
+			},
+		})
+	}
+
+	return chatCompletion{
+		ID:      id,
+		Object:  "chat.completion",
+		Created: created,
+		Model:   model,
+		Choices: []chatChoice{{
+			Index:        0,
+			Message:      m,
+			FinishReason: getFinishReason(resp),
+		}},
+		Usage: getUsage(resp.Usage),
+	}
+}
+
+func getFinishReason(resp *adapter.Completion) string {
+	if len(resp.Message.ToolCalls) > 0 {
+		return "tool_calls"
+	}
+	return "stop"
+}
+
+func getUsage(u *adapter.CompletionUsage) *usage {
+	if u == nil {
+		return nil
+	}
+	return &usage{
+		PromptTokens:     u.PromptTokens,
+		CompletionTokens: u.CompletionTokens,
+		TotalTokens:      u.PromptTokens + u.CompletionTokens,
+	}
+}
+
+// chunkStream sends the chunks of completion as server-sent events.
+type chunkStream struct {
+	w       http.ResponseWriter
+	chunk   chatCompletionChunk
+	started bool
+}
+
+func (s *chunkStream) delta(ctx context.Context, delta adapter.CompletionDelta) error {
+	d := responseDelta{
+		Content: delta.Content,
+		Refusal: delta.Refusal,
+	}
+
+	for _, c := range delta.ToolCalls {
+		t := toolCall{
+			Index: &c.Index,
+			ID:    c.ID,
+			Function: toolCallFunction{
+				Name:      c.Function.Name,
+				Arguments: c.Function.Arguments,
+			},
+		}
+		if c.ID != "" {
+			t.Type = "function"
+		}
+		d.ToolCalls = append(d.ToolCalls, t)
+	}
+
+	if d.Content == nil && d.Refusal == nil && len(d.ToolCalls) == 0 {
+		return nil
+	}
+
+	return s.send(d, nil)
+}
+
+func (s *chunkStream) finish(resp *adapter.Completion, includeUsage bool) {
+	reason := getFinishReason(resp)
+	if err := s.send(responseDelta{}, &reason); err != nil {
+		return
+	}
+
+	if includeUsage && resp.Usage != nil {
+		s.chunk.Choices = []chatChunkChoice{}
+		s.chunk.Usage = getUsage(resp.Usage)
+		if err := s.write(s.chunk); err != nil {
+			return
+		}
+	}
+
+	s.writeData([]byte("[DONE]"))
+}
+
+func (s *chunkStream) send(delta responseDelta, finishReason *string) error {
+	if !s.started {
+		delta.Role = "assistant"
+	}
+
+	s.chunk.Choices = []chatChunkChoice{{
+		Index:        0,
+		Delta:        delta,
+		FinishReason: finishReason,
+	}}
+
+	return s.write(s.chunk)
+}
+
+// error reports the error after the stream has started, when the status of
+// the response cannot be changed anymore.
+func (s *chunkStream) error(err error) {
+	s.write(errorResponse{
+		Error: errorObject{
+			Message: err.Error(),
+			Type:    "api_error",
+		},
+	})
+}
+
+func (s *chunkStream) write(v any) error {
+	b, err := json.Marshal(v)
+	if err != nil {
+		return err
+	}
+
+	return s.writeData(b)
+}
+
+func (s *chunkStream) writeData(b []byte) error {
+	if !s.started {
+		h := s.w.Header()
+		h.Set("Content-Type", "text/event-stream")
+		h.Set("Cache-Control", "no-cache")
+		s.w.WriteHeader(http.StatusOK)
+		s.started = true
+	}
+
+	if _, err := fmt.Fprintf(s.w, "data: %s\n\n", b); err != nil {
+		return err
+	}
+
+	return http.NewResponseController(s.w).Flush()
+}
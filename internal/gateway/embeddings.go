@@ -0,0 +1,95 @@
+package gateway
+
+import (
+	"encoding/base64"
+	"encoding/binary"
+	"fmt"
+	"math"
+	"net/http"
+
+	"github.com/umk/jsonrpc2"
+	"github.com/umk/llmservices/pkg/adapter"
+)
+
+func embeddingsHandler(w http.ResponseWriter, r *http.Request) {
+	var req embeddingsRequest
+	if !readRequest(w, r, &req) {
+		return
+	}
+
+	cl, model, err := getClient(r.Context(), req.Model, "getEmbeddings")
+	if err != nil {
+		writeGetClientError(w, err)
+		return
+	}
+
+	input, err := getStringOrList(req.Input)
+	if err != nil {
+		writeError(w, http.StatusBadRequest, "invalid_request_error", fmt.Sprintf("input: %s", err))
+		return
+	}
+
+	if req.EncodingFormat != "" && req.EncodingFormat != "float" && req.EncodingFormat != "base64" {
+		writeError(w, http.StatusBadRequest, "invalid_request_error", fmt.Sprintf("encoding_format is not supported: %s", req.EncodingFormat))
+		return
+	}
+
+	params := adapter.EmbeddingsParams{
+		Model:      model,
+		Dimensions: req.Dimensions,
+	}
+
+	if err := jsonrpc2.Val.Struct(embeddingsRequestParams{Input: input, Params: params}); err != nil {
+		writeError(w, http.StatusBadRequest, "invalid_request_error", err.Error())
+		return
+	}
+
+	resp, err := cl.Embeddings(r.Context(), input, params)
+	if err != nil {
+		writeClientError(w, err)
+		return
+	}
+
+	result := embeddingsResponse{
+		Object: "list",
+		Data:   make([]embedding, len(resp.Data)),
+		Model:  req.Model,
+	}
+
+	for i, v := range resp.Data {
+		result.Data[i] = embedding{
+			Object:    "embedding",
+			Index:     i,
+			Embedding: v,
+		}
+		if req.EncodingFormat == "base64" {
+			result.Data[i].Embedding = getBase64Embedding(v)
+		}
+	}
+
+	if resp.Usage != nil {
+		result.Usage = embeddingsUsage{
+			PromptTokens: resp.Usage.PromptTokens,
+			TotalTokens:  resp.Usage.PromptTokens,
+		}
+	}
+
+	writeJSON(w, http.StatusOK, result)
+}
+
+// embeddingsRequestParams is validated the same way as the embeddings
+// requests made over JSON-RPC.
+type embeddingsRequestParams struct {
+	Input  []string                 `validate:"required,min=1,dive,required"`
+	Params adapter.EmbeddingsParams `validate:"required"`
+}
+
+// getBase64Embedding encodes the embedding as little-endian 32-bit floats,
+// like the OpenAI API does.
+func getBase64Embedding(v []float64) string {
+	b := make([]byte, len(v)*4)
+	for i, f := range v {
+		binary.LittleEndian.PutUint32(b[i*4:], math.Float32bits(float32(f)))
+	}
+	return base64.StdEncoding.EncodeToString(b)
+}
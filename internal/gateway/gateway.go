@@ -0,0 +1,173 @@
+// Package gateway exposes the clients through the OpenAI-compatible REST
+// API. The clients are addressed by the model field like clientID/model.
+package gateway
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"strings"
+
+	"github.com/umk/llmservices/internal/auth"
+	"github.com/umk/llmservices/internal/service/handlers"
+	"github.com/umk/llmservices/pkg/adapter"
+	"github.com/umk/llmservices/pkg/client"
+)
+
+const maxRequestSize = 32 << 20
+
+// Handler returns the handler of the API, which is expected to be mounted
+// at the root of the server. If authentication is enabled, the requests
+// must have a bearer token, and the endpoints are permitted as the methods
+// getCompletion and getEmbeddings. The requests with the header
+// Cache-Control: no-cache bypass the cache of the clients.
+func Handler() http.Handler {
+	mux := http.NewServeMux()
+
+	mux.HandleFunc("POST /v1/chat/completions", chatCompletionsHandler)
+	mux.HandleFunc("POST /v1/embeddings", embeddingsHandler)
+	mux.HandleFunc("GET /v1/models", modelsHandler)
+
+	mux.HandleFunc("/v1/", func(w http.ResponseWriter, r *http.Request) {
+		writeError(w, http.StatusNotFound, "not_found_error", fmt.Sprintf("unknown endpoint: %s %s", r.Method, r.URL.Path))
+	})
+
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		p := auth.Unrestricted
+		if auth.Enabled() {
+			var err error
+			if p, err = auth.Authenticate(auth.GetBearerToken(r.Header.Get("Authorization"))); err != nil {
+				w.Header().Set("WWW-Authenticate", "Bearer")
+				writeError(w, http.StatusUnauthorized, "authentication_error", err.Error())
+				return
+			}
+		}
+
+		ctx := auth.Context(r.Context(), p)
+		if strings.Contains(r.Header.Get("Cache-Control"), "no-cache") {
+			ctx = client.ContextWithoutCache(ctx)
+		}
+
+		mux.ServeHTTP(w, r.WithContext(ctx))
+	})
+}
+
+// getClient resolves the model of the request like clientID/model into the
+// client and the model to use with it, and checks whether the principal of
+// the request is permitted to use them with the method. If the model doesn't
+// start with an ID of a client, either the client with the ID equal to the
+// model and its default model, or the default client with the model are used.
+func getClient(ctx context.Context, model string, method string) (*client.Client, string, error) {
+	if model == "" {
+		return nil, "", errors.New("model must be specified")
+	}
+
+	id, c, m, err := findClient(model)
+	if err != nil {
+		return nil, "", err
+	}
+
+	p, err := auth.GetPrincipal(ctx)
+	if err != nil {
+		return nil, "", err
+	}
+
+	if err := p.CheckMethod(method); err != nil {
+		return nil, "", err
+	}
+
+	if err := p.CheckClient(id); err != nil {
+		return nil, "", err
+	}
+
+	if m != "" && !p.AllowsModel(m) {
+		return nil, "", &auth.PermissionError{Kind: "model", Name: m}
+	}
+
+	return c, m, nil
+}
+
+func findClient(model string) (string, *client.Client, string, error) {
+	if id, m, ok := strings.Cut(model, "/"); ok {
+		if c, err := handlers.GetGlobalClient(id); err == nil {
+			return id, c, m, nil
+		}
+	}
+
+	if c, err := handlers.GetGlobalClient(model); err == nil {
+		return model, c, c.Model(), nil
+	}
+
+	if c, err := handlers.GetGlobalClient("default"); err == nil {
+		return "default", c, model, nil
+	}
+
+	return "", nil, "", fmt.Errorf("client not found for model: %s", model)
+}
+
+// writeGetClientError responds with the error of resolving the model.
+func writeGetClientError(w http.ResponseWriter, err error) {
+	var permErr *auth.PermissionError
+	if errors.As(err, &permErr) {
+		writeError(w, http.StatusForbidden, "permission_error", err.Error())
+	} else {
+		writeError(w, http.StatusNotFound, "invalid_request_error", err.Error())
+	}
+}
+
+func readRequest(w http.ResponseWriter, r *http.Request, req any) bool {
+	d := json.NewDecoder(http.MaxBytesReader(w, r.Body, maxRequestSize))
+	if err := d.Decode(req); err != nil {
+		writeError(w, http.StatusBadRequest, "invalid_request_error", fmt.Sprintf("invalid request: %s", err))
+		return false
+	}
+
+	return true
+}
+
+func writeJSON(w http.ResponseWriter, status int, v any) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	json.NewEncoder(w).Encode(v)
+}
+
+func writeError(w http.ResponseWriter, status int, typ string, message string) {
+	writeJSON(w, status, errorResponse{
+		Error: errorObject{
+			Message: message,
+			Type:    typ,
+		},
+	})
+}
+
+// writeClientError responds with the error of the client, keeping the status
+// of the provider's response if there is one.
+func writeClientError(w http.ResponseWriter, err error) {
+	status := http.StatusBadGateway
+	typ := "api_error"
+
+	var statusErr *adapter.StatusError
+	switch {
+	case errors.As(err, &statusErr):
+		// The credentials of the client are not the caller's concern.
+		if statusErr.StatusCode == http.StatusUnauthorized || statusErr.StatusCode == http.StatusForbidden {
+			break
+		}
+		status = statusErr.StatusCode
+		if status == http.StatusTooManyRequests {
+			typ = "rate_limit_error"
+		} else if status < 500 {
+			typ = "invalid_request_error"
+		}
+	case errors.Is(err, client.ErrBudgetExceeded):
+		status = http.StatusTooManyRequests
+		typ = "insufficient_quota"
+	case errors.Is(err, adapter.ErrNotSupported):
+		status = http.StatusBadRequest
+		typ = "invalid_request_error"
+	}
+
+	writeError(w, status, typ, err.Error())
+}
@@ -0,0 +1,50 @@
+package gateway
+
+import (
+	"net/http"
+	"slices"
+	"strings"
+
+	"github.com/umk/llmservices/internal/auth"
+	"github.com/umk/llmservices/internal/service/handlers"
+	"github.com/umk/llmservices/pkg/client"
+)
+
+func modelsHandler(w http.ResponseWriter, r *http.Request) {
+	resp := modelList{
+		Object: "list",
+		Data:   []model{},
+	}
+
+	p, err := auth.GetPrincipal(r.Context())
+	if err != nil {
+		writeError(w, http.StatusUnauthorized, "authentication_error", err.Error())
+		return
+	}
+
+	// Only the clients and the models permitted to the principal are listed.
+	handlers.RangeGlobalClients(func(clientID string, c *client.Client) bool {
+		if p.CheckClient(clientID) != nil {
+			return true
+		}
+		id := clientID
+		if m := c.Model(); m != "" {
+			if !p.AllowsModel(m) {
+				return true
+			}
+			id += "/" + m
+		}
+		resp.Data = append(resp.Data, model{
+			ID:      id,
+			Object:  "model",
+			OwnedBy: clientID,
+		})
+		return true
+	})
+
+	slices.SortFunc(resp.Data, func(a, b model) int {
+		return strings.Compare(a.ID, b.ID)
+	})
+
+	writeJSON(w, http.StatusOK, resp)
+}
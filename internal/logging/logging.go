@@ -0,0 +1,126 @@
+// Package logging records the calls of the methods and the requests made by
+// the clients. The values of the sensitive attributes are redacted, and the
+// patterns like e-mail addresses are masked in the rest of the values before
+// the records are written.
+package logging
+
+import (
+	"fmt"
+	"io"
+	"log/slog"
+	"os"
+	"regexp"
+	"slices"
+)
+
+type Config struct {
+	// Minimum level of the records: debug, info, warn or error. If not
+	// specified, nothing is logged.
+	Level string `yaml:"level,omitempty" validate:"omitempty,oneof=debug info warn error"`
+	// Format of the records, which is text unless specified.
+	Format string `yaml:"format,omitempty" validate:"omitempty,oneof=text json"`
+	// Path to the file to append the records to. If not specified, the
+	// records are written to stderr.
+	Path string `yaml:"path,omitempty"`
+	// Whether to record the messages and the completions.
+	Content bool `yaml:"content,omitempty"`
+	// Names of the attributes to redact in addition to the default ones, like
+	// "key" or "token".
+	Redact []string `yaml:"redact,omitempty"`
+	// Patterns to mask in addition to the default ones, like the API keys and
+	// the e-mail addresses.
+	Mask []Mask `yaml:"mask,omitempty" validate:"dive"`
+}
+
+type Mask struct {
+	// Regular expression to find the values to mask.
+	Pattern string `yaml:"pattern" validate:"required"`
+	// Text to replace the values with, which may refer to the groups of
+	// the pattern like $1. Unless specified, it's [MASKED].
+	Replacement string `yaml:"replacement,omitempty"`
+}
+
+var defaultRedact = []string{
+	"key", "api_key", "apikey", "authorization", "password", "secret", "token",
+}
+
+var defaultMasks = []Mask{
+	{Pattern: `\bsk-[A-Za-z0-9_-]{16,}`, Replacement: "[API KEY]"},
+	{Pattern: `\bAIza[A-Za-z0-9_-]{35}\b`, Replacement: "[API KEY]"},
+	{Pattern: `(?i)\bbearer\s+[A-Za-z0-9._~+/=-]+`, Replacement: "Bearer [REDACTED]"},
+	{Pattern: `[A-Za-z0-9._%+-]+@[A-Za-z0-9.-]+\.[A-Za-z]{2,}`, Replacement: "[EMAIL]"},
+}
+
+var (
+	// Logger records the calls of the methods and the requests made by the
+	// clients. It discards the records until Init is called.
+	Logger = slog.New(slog.DiscardHandler)
+	// Content tells whether the messages and the completions are recorded.
+	Content bool
+)
+
+// Init sets the logger according to the configuration. The file the records
+// are written to, if any, stays open until the process exits.
+func Init(config Config) error {
+	if config.Level == "" {
+		return nil
+	}
+
+	var level slog.Level
+	if err := level.UnmarshalText([]byte(config.Level)); err != nil {
+		return err
+	}
+
+	masks, err := compileMasks(slices.Concat(defaultMasks, config.Mask))
+	if err != nil {
+		return err
+	}
+
+	var w io.Writer = os.Stderr
+	if config.Path != "" {
+		f, err := os.OpenFile(config.Path, os.O_CREATE|os.O_WRONLY|os.O_APPEND, 0o600)
+		if err != nil {
+			return err
+		}
+		w = f
+	}
+
+	opts := &slog.HandlerOptions{Level: level}
+
+	var h slog.Handler
+	if config.Format == "json" {
+		h = slog.NewJSONHandler(w, opts)
+	} else {
+		h = slog.NewTextHandler(w, opts)
+	}
+
+	h = newRedactHandler(h, slices.Concat(defaultRedact, config.Redact), masks)
+
+	Logger = slog.New(h)
+	Content = config.Content
+
+	return nil
+}
+
+type mask struct {
+	rx          *regexp.Regexp
+	replacement string
+}
+
+func compileMasks(src []Mask) ([]mask, error) {
+	masks := make([]mask, 0, len(src))
+	for _, m := range src {
+		rx, err := regexp.Compile(m.Pattern)
+		if err != nil {
+			return nil, fmt.Errorf("invalid mask pattern %q: %w", m.Pattern, err)
+		}
+
+		r := m.Replacement
+		if r == "" {
+			r = "[MASKED]"
+		}
+
+		masks = append(masks, mask{rx: rx, replacement: r})
+	}
+	return masks, nil
+}
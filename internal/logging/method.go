@@ -0,0 +1,51 @@
+package logging
+
+import (
+	"context"
+	"errors"
+	"log/slog"
+	"time"
+
+	"github.com/umk/jsonrpc2"
+)
+
+// Method wraps the handler of the method to record its calls.
+func Method(method string, fn jsonrpc2.HandlerFunc) jsonrpc2.HandlerFunc {
+	return func(ctx context.Context, c jsonrpc2.RPCContext) (any, error) {
+		start := time.Now()
+
+		resp, err := fn(ctx, c)
+
+		attrs := []slog.Attr{
+			slog.String("method", method),
+			slog.Duration("latency", time.Since(start)),
+		}
+
+		level := slog.LevelInfo
+		if err != nil {
+			level = slog.LevelError
+			if code, ok := getErrorCode(err); ok {
+				attrs = append(attrs, slog.Int("code", code))
+			}
+			attrs = append(attrs, slog.String("error", err.Error()))
+		}
+
+		Logger.LogAttrs(ctx, level, "rpc", attrs...)
+
+		return resp, err
+	}
+}
+
+func getErrorCode(err error) (int, bool) {
+	var rpcErr jsonrpc2.Error
+	if errors.As(err, &rpcErr) {
+		return rpcErr.Code, true
+	}
+
+	var rpcErrer interface{ RPCError() jsonrpc2.Error }
+	if errors.As(err, &rpcErrer) {
+		return rpcErrer.RPCError().Code, true
+	}
+
+	return 0, false
+}
@@ -0,0 +1,95 @@
+package logging
+
+import (
+	"context"
+	"log/slog"
+	"strings"
+)
+
+const redacted = "[REDACTED]"
+
+// redactHandler redacts the values of the attributes by their names, and
+// masks the patterns in the string values of the rest of the attributes
+// and in the messages of the records.
+type redactHandler struct {
+	next  slog.Handler
+	keys  map[string]struct{}
+	masks []mask
+}
+
+func newRedactHandler(next slog.Handler, keys []string, masks []mask) *redactHandler {
+	h := &redactHandler{
+		next:  next,
+		keys:  make(map[string]struct{}, len(keys)),
+		masks: masks,
+	}
+
+	for _, k := range keys {
+		h.keys[strings.ToLower(k)] = struct{}{}
+	}
+
+	return h
+}
+
+func (h *redactHandler) Enabled(ctx context.Context, level slog.Level) bool {
+	return h.next.Enabled(ctx, level)
+}
+
+func (h *redactHandler) Handle(ctx context.Context, r slog.Record) error {
+	nr := slog.NewRecord(r.Time, r.Level, h.mask(r.Message), r.PC)
+
+	r.Attrs(func(a slog.Attr) bool {
+		nr.AddAttrs(h.redact(a))
+		return true
+	})
+
+	return h.next.Handle(ctx, nr)
+}
+
+func (h *redactHandler) WithAttrs(attrs []slog.Attr) slog.Handler {
+	r := make([]slog.Attr, len(attrs))
+	for i, a := range attrs {
+		r[i] = h.redact(a)
+	}
+
+	return &redactHandler{next: h.next.WithAttrs(r), keys: h.keys, masks: h.masks}
+}
+
+func (h *redactHandler) WithGroup(name string) slog.Handler {
+	return &redactHandler{next: h.next.WithGroup(name), keys: h.keys, masks: h.masks}
+}
+
+func (h *redactHandler) redact(a slog.Attr) slog.Attr {
+	if _, ok := h.keys[strings.ToLower(a.Key)]; ok {
+		return slog.String(a.Key, redacted)
+	}
+
+	v := a.Value.Resolve()
+
+	switch v.Kind() {
+	case slog.KindString:
+		return slog.String(a.Key, h.mask(v.String()))
+	case slog.KindGroup:
+		g := v.Group()
+		r := make([]slog.Attr, len(g))
+		for i, a := range g {
+			r[i] = h.redact(a)
+		}
+		return slog.Attr{Key: a.Key, Value: slog.GroupValue(r...)}
+	case slog.KindAny:
+		// The values like errors are written as text, so they're masked as
+		// text as well.
+		if err, ok := v.Any().(error); ok {
+			return slog.String(a.Key, h.mask(err.Error()))
+		}
+	}
+
+	return slog.Attr{Key: a.Key, Value: v}
+}
+
+func (h *redactHandler) mask(s string) string {
+	for _, m := range h.masks {
+		s = m.rx.ReplaceAllString(s, m.replacement)
+	}
+	return s
+}
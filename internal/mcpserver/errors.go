@@ -0,0 +1,22 @@
+package mcpserver
+
+import (
+	"fmt"
+
+	"github.com/umk/jsonrpc2"
+)
+
+func newUnknownToolError(name string) error {
+	return jsonrpc2.Error{
+		Code:    -32602,
+		Message: fmt.Sprintf("Unknown tool: %s", name),
+	}
+}
+
+func newResourceNotFoundError(uri string) error {
+	return jsonrpc2.Error{
+		Code:    -32002,
+		Message: "Resource not found",
+		Data:    map[string]any{"uri": uri},
+	}
+}
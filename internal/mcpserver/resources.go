@@ -0,0 +1,95 @@
+package mcpserver
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"slices"
+	"strings"
+
+	"github.com/umk/jsonrpc2"
+	"github.com/umk/llmservices/internal/auth"
+	"github.com/umk/llmservices/internal/service/handlers"
+	"github.com/umk/llmservices/pkg/client"
+	"github.com/umk/llmservices/pkg/mcp"
+)
+
+const clientURIPrefix = "llmservices://clients/"
+
+// clientResource describes the global client to the host.
+type clientResource struct {
+	ClientID string `json:"client_id"`
+	// Model used for the requests that don't specify one.
+	Model string `json:"model,omitempty"`
+	// Usage of the client by model since it was created.
+	Usage []client.ModelUsage `json:"usage"`
+}
+
+// listResources returns all of the resources at once, so the cursor of the
+// request, which may have no parameters at all, is ignored.
+func (s *server) listResources(ctx context.Context, c jsonrpc2.RPCContext) (any, error) {
+	p, err := auth.GetPrincipal(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	resp := mcp.ListResourcesResponse{Resources: []mcp.Resource{}}
+
+	// Only the clients permitted to the principal are listed.
+	handlers.RangeGlobalClients(func(clientID string, cl *client.Client) bool {
+		if p.CheckClient(clientID) != nil {
+			return true
+		}
+		description := "Client of the models"
+		if m := cl.Model(); m != "" {
+			description += fmt.Sprintf(" with the default model %s", m)
+		}
+		resp.Resources = append(resp.Resources, mcp.Resource{
+			URI:         clientURIPrefix + clientID,
+			Name:        clientID,
+			Description: description + ".",
+			MimeType:    "application/json",
+		})
+		return true
+	})
+
+	slices.SortFunc(resp.Resources, func(a, b mcp.Resource) int {
+		return strings.Compare(a.Name, b.Name)
+	})
+
+	return c.Response(resp)
+}
+
+func (s *server) readResource(ctx context.Context, c jsonrpc2.RPCContext) (any, error) {
+	var req mcp.ReadResourceRequest
+	if err := c.Request(&req); err != nil {
+		return nil, err
+	}
+
+	clientID, ok := strings.CutPrefix(req.URI, clientURIPrefix)
+	if !ok {
+		return nil, newResourceNotFoundError(req.URI)
+	}
+
+	cl, err := handlers.GetClient(ctx, clientID)
+	if err != nil {
+		return nil, newResourceNotFoundError(req.URI)
+	}
+
+	b, err := json.Marshal(clientResource{
+		ClientID: clientID,
+		Model:    cl.Model(),
+		Usage:    cl.Stats.Get(cl.Name()),
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return c.Response(mcp.ReadResourceResponse{
+		Contents: []mcp.ResourceContents{{
+			URI:      req.URI,
+			MimeType: "application/json",
+			Text:     string(b),
+		}},
+	})
+}
@@ -0,0 +1,74 @@
+// Package mcpserver serves the session to the hosts of the Model Context
+// Protocol, like editors and agents. Some of the methods of the service are
+// exposed as tools, and the global clients are exposed as resources.
+package mcpserver
+
+import (
+	"context"
+	"io"
+	"slices"
+
+	"github.com/umk/jsonrpc2"
+	"github.com/umk/llmservices/pkg/mcp"
+)
+
+// Revisions of the protocol the server can speak, from the latest one.
+var protocolVersions = []string{mcp.ProtocolVersion, "2025-03-26", "2024-11-05"}
+
+const instructions = "The tools call the language models and the embedding models configured in the service. " +
+	"The clients to call the models with are listed as resources, and are referenced by the client_id argument of the tools."
+
+type server struct {
+	methods map[string]jsonrpc2.HandlerFunc
+}
+
+// Run serves the session over the input and the output until the input is
+// closed. The tools call the handlers of the methods of the service with the
+// same names.
+func Run(ctx context.Context, in io.Reader, out io.Writer, methods map[string]jsonrpc2.HandlerFunc) error {
+	s := server{methods: methods}
+
+	h := jsonrpc2.NewHandler(map[string]jsonrpc2.HandlerFunc{
+		"initialize":                s.initialize,
+		"notifications/initialized": s.notified,
+		"ping":                      s.ping,
+		"tools/list":                s.listTools,
+		"tools/call":                s.callTool,
+		"resources/list":            s.listResources,
+		"resources/read":            s.readResource,
+	})
+
+	return jsonrpc2.NewHost(in, out, jsonrpc2.WithServer(h)).Run(ctx)
+}
+
+func (s *server) initialize(ctx context.Context, c jsonrpc2.RPCContext) (any, error) {
+	var req mcp.InitializeRequest
+	if err := c.Request(&req); err != nil {
+		return nil, err
+	}
+
+	// The revision requested by the host is used if supported. Otherwise,
+	// the host decides whether it can use the latest one.
+	version := mcp.ProtocolVersion
+	if slices.Contains(protocolVersions, req.ProtocolVersion) {
+		version = req.ProtocolVersion
+	}
+
+	return c.Response(mcp.InitializeResponse{
+		ProtocolVersion: version,
+		Capabilities: map[string]any{
+			"tools":     map[string]any{},
+			"resources": map[string]any{},
+		},
+		ServerInfo:   mcp.GetImplementation(),
+		Instructions: instructions,
+	})
+}
+
+func (s *server) notified(ctx context.Context, c jsonrpc2.RPCContext) (any, error) {
+	return nil, nil
+}
+
+func (s *server) ping(ctx context.Context, c jsonrpc2.RPCContext) (any, error) {
+	return c.Response(mcp.PingResponse{})
+}
@@ -0,0 +1,249 @@
+package mcpserver
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"slices"
+
+	"github.com/umk/jsonrpc2"
+	"github.com/umk/llmservices/pkg/mcp"
+)
+
+// tool exposes the method of the service with the same name, which gets the
+// arguments of the tool as its parameters.
+type tool struct {
+	name        string
+	description string
+	schema      map[string]any
+}
+
+var messageSchema = map[string]any{
+	"type": "object",
+	"description": `Message like {"system":{"content":"..."}}, {"user":{"parts":[{"text":{"text":"..."}}]}} ` +
+		`or {"assistant":{"content":"..."}}.`,
+}
+
+var threadSchema = map[string]any{
+	"type":        "object",
+	"description": "Thread of the messages grouped in frames.",
+	"properties": map[string]any{
+		"frames": map[string]any{
+			"type": "array",
+			"items": map[string]any{
+				"type": "object",
+				"properties": map[string]any{
+					"messages": map[string]any{"type": "array", "items": messageSchema},
+				},
+				"required": []string{"messages"},
+			},
+		},
+	},
+	"required": []string{"frames"},
+}
+
+var clientIDSchema = map[string]any{
+	"type":        "string",
+	"description": "ID of the client listed as a resource.",
+}
+
+var noCacheSchema = map[string]any{
+	"type":        "boolean",
+	"description": "Whether to bypass the cache of the client.",
+}
+
+var tools = []tool{
+	{
+		name:        "getCompletion",
+		description: "Gets the completion of the messages from a language model.",
+		schema: map[string]any{
+			"type": "object",
+			"properties": map[string]any{
+				"client_id": clientIDSchema,
+				"messages":  map[string]any{"type": "array", "items": messageSchema, "minItems": 1},
+				"params": map[string]any{
+					"type":        "object",
+					"description": "Parameters of the completion like model, temperature and max_tokens.",
+					"properties": map[string]any{
+						"model":       map[string]any{"type": "string"},
+						"temperature": map[string]any{"type": "number"},
+						"max_tokens":  map[string]any{"type": "integer"},
+					},
+					"required": []string{"model"},
+				},
+				"no_cache": noCacheSchema,
+			},
+			"required": []string{"client_id", "messages", "params"},
+		},
+	},
+	{
+		name:        "getEmbeddings",
+		description: "Gets the embeddings of the inputs from an embedding model.",
+		schema: map[string]any{
+			"type": "object",
+			"properties": map[string]any{
+				"client_id": clientIDSchema,
+				"input": map[string]any{
+					"description": "Text to embed, or a list of texts to embed at once.",
+					"anyOf": []any{
+						map[string]any{"type": "string"},
+						map[string]any{"type": "array", "items": map[string]any{"type": "string"}, "minItems": 1},
+					},
+				},
+				"params": map[string]any{
+					"type": "object",
+					"properties": map[string]any{
+						"model":      map[string]any{"type": "string"},
+						"dimensions": map[string]any{"type": "integer"},
+					},
+					"required": []string{"model"},
+				},
+				"no_cache": noCacheSchema,
+			},
+			"required": []string{"client_id", "input", "params"},
+		},
+	},
+	{
+		name:        "getThreadSummary",
+		description: "Summarizes the older messages of the thread to fit it in the limits of the context.",
+		schema: map[string]any{
+			"type": "object",
+			"properties": map[string]any{
+				"client_id": clientIDSchema,
+				"gen_client_id": map[string]any{
+					"type":        "string",
+					"description": "ID of the client that generated the thread, if not the same as the summarizer.",
+				},
+				"thread":       threadSchema,
+				"fraction":     map[string]any{"type": "number", "description": "Fraction of the thread to summarize."},
+				"max_messages": map[string]any{"type": "integer"},
+				"max_tokens":   map[string]any{"type": "integer"},
+			},
+			"required": []string{"client_id", "thread"},
+		},
+	},
+	{
+		name: "getAgentResponse",
+		description: "Gets the response of an agent to the thread, which may call the tools executed by the service " +
+			"and the tools of the MCP servers configured in the service.",
+		schema: map[string]any{
+			"type": "object",
+			"properties": map[string]any{
+				"client_id": clientIDSchema,
+				"thread":    threadSchema,
+				"params": map[string]any{
+					"type":        "object",
+					"description": "Parameters of the response like model, description of the agent and iterations.",
+					"properties": map[string]any{
+						"model":       map[string]any{"type": "string"},
+						"description": map[string]any{"type": "string"},
+						"iterations":  map[string]any{"type": "integer", "minimum": 1},
+						"strategy":    map[string]any{"type": "string", "enum": []string{"react", "tools", "auto"}},
+					},
+					"required": []string{"model", "iterations"},
+				},
+				"server_tools": map[string]any{"type": "array", "items": map[string]any{"type": "string"}},
+				"mcp_servers":  map[string]any{"type": "array", "items": map[string]any{"type": "string"}},
+				"no_cache":     noCacheSchema,
+			},
+			"required": []string{"client_id", "thread", "params"},
+		},
+	},
+}
+
+// listTools returns all of the tools at once, so the cursor of the request,
+// which may have no parameters at all, is ignored.
+func (s *server) listTools(ctx context.Context, c jsonrpc2.RPCContext) (any, error) {
+	resp := mcp.ListToolsResponse{Tools: make([]mcp.Tool, 0, len(tools))}
+	for _, t := range tools {
+		if _, ok := s.methods[t.name]; !ok {
+			continue
+		}
+		resp.Tools = append(resp.Tools, mcp.Tool{
+			Name:        t.name,
+			Description: t.description,
+			InputSchema: t.schema,
+		})
+	}
+
+	return c.Response(resp)
+}
+
+func (s *server) callTool(ctx context.Context, c jsonrpc2.RPCContext) (any, error) {
+	var req mcp.CallToolRequest
+	if err := c.Request(&req); err != nil {
+		return nil, err
+	}
+
+	fn, ok := s.methods[req.Name]
+	if !ok || !slices.ContainsFunc(tools, func(t tool) bool { return t.name == req.Name }) {
+		return nil, newUnknownToolError(req.Name)
+	}
+
+	arguments := req.Arguments
+	if arguments == nil {
+		arguments = json.RawMessage("{}")
+	}
+
+	// The failure of the method is reported to the model rather than as
+	// the error of the protocol.
+	r, err := fn(ctx, &toolContext{arguments: arguments})
+	if err != nil {
+		return c.Response(mcp.CallToolResponse{
+			Content: []mcp.Content{{Type: "text", Text: getErrorText(err)}},
+			IsError: true,
+		})
+	}
+
+	b, err := json.Marshal(r)
+	if err != nil {
+		return nil, err
+	}
+
+	return c.Response(mcp.CallToolResponse{
+		Content:           []mcp.Content{{Type: "text", Text: string(b)}},
+		StructuredContent: json.RawMessage(b),
+	})
+}
+
+func getErrorText(err error) string {
+	var rpcErr jsonrpc2.Error
+	if !errors.As(err, &rpcErr) {
+		return err.Error()
+	}
+
+	if data, ok := rpcErr.Data.(map[string]any); ok {
+		if e, ok := data["error"]; ok {
+			return fmt.Sprintf("%s: %v", rpcErr.Message, e)
+		}
+	}
+
+	return rpcErr.Message
+}
+
+// toolContext passes the arguments of the tool to the method as if they
+// were the parameters of the request.
+type toolContext struct {
+	arguments json.RawMessage
+}
+
+func (c *toolContext) ID(v any) error {
+	return nil
+}
+
+func (c *toolContext) Request(v any) error {
+	if err := json.Unmarshal(c.arguments, v); err != nil {
+		return fmt.Errorf("invalid arguments: %w", err)
+	}
+
+	if err := jsonrpc2.Val.Struct(v); err != nil {
+		return fmt.Errorf("invalid arguments: %w", err)
+	}
+
+	return nil
+}
+
+func (c *toolContext) Response(v any) (any, error) {
+	return v, nil
+}
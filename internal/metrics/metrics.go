@@ -0,0 +1,134 @@
+// Package metrics collects the metrics of the service and the clients, and
+// exposes them in the Prometheus format.
+package metrics
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/collectors"
+	"github.com/prometheus/client_golang/prometheus/promhttp"
+)
+
+const namespace = "llmservices"
+
+var registry = prometheus.NewRegistry()
+
+var (
+	rpcCalls = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Namespace: namespace,
+		Name:      "rpc_calls_total",
+		Help:      "Number of JSON-RPC method calls by method and error code, which is 0 on success.",
+	}, []string{"method", "code"})
+
+	rpcDuration = prometheus.NewHistogramVec(prometheus.HistogramOpts{
+		Namespace: namespace,
+		Name:      "rpc_duration_seconds",
+		Help:      "Time taken by JSON-RPC method calls.",
+		Buckets:   latencyBuckets,
+	}, []string{"method"})
+
+	activeSessions = prometheus.NewGauge(prometheus.GaugeOpts{
+		Namespace: namespace,
+		Name:      "active_sessions",
+		Help:      "Number of sessions being served.",
+	})
+
+	adapterDuration = prometheus.NewHistogramVec(prometheus.HistogramOpts{
+		Namespace: namespace,
+		Name:      "adapter_request_duration_seconds",
+		Help:      "Time taken by the requests to the providers, with every attempt counted separately.",
+		Buckets:   latencyBuckets,
+	}, []string{"client", "model", "operation", "status"})
+
+	clientRequests = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Namespace: namespace,
+		Name:      "client_requests_total",
+		Help:      "Number of requests made by the clients with all of their attempts, by status.",
+	}, []string{"client", "model", "status"})
+
+	clientRetries = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Namespace: namespace,
+		Name:      "client_retries_total",
+		Help:      "Number of retries of the requests made by the clients.",
+	}, []string{"client", "model"})
+
+	clientTokens = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Namespace: namespace,
+		Name:      "client_tokens_total",
+		Help:      "Number of tokens used by the clients, by type: prompt, completion or embedding.",
+	}, []string{"client", "model", "type"})
+
+	clientCacheHits = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Namespace: namespace,
+		Name:      "client_cache_hits_total",
+		Help:      "Number of requests of the clients responded from the cache.",
+	}, []string{"client", "model"})
+
+	clientCost = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Namespace: namespace,
+		Name:      "client_cost_dollars_total",
+		Help:      "Spend of the clients according to their pricing.",
+	}, []string{"client", "model"})
+
+	clientWait = prometheus.NewHistogramVec(prometheus.HistogramOpts{
+		Namespace: namespace,
+		Name:      "client_wait_duration_seconds",
+		Help:      "Time the requests wait for a slot among the concurrent requests of the clients.",
+		Buckets:   []float64{0.001, 0.01, 0.05, 0.1, 0.5, 1, 5, 10, 30, 60},
+	}, []string{"client"})
+)
+
+var latencyBuckets = []float64{0.1, 0.25, 0.5, 1, 2.5, 5, 10, 30, 60, 120}
+
+func init() {
+	registry.MustRegister(
+		collectors.NewGoCollector(),
+		collectors.NewProcessCollector(collectors.ProcessCollectorOpts{}),
+		rpcCalls,
+		rpcDuration,
+		activeSessions,
+		adapterDuration,
+		clientRequests,
+		clientRetries,
+		clientTokens,
+		clientCacheHits,
+		clientCost,
+		clientWait,
+	)
+}
+
+// Handler returns the handler that responds with the metrics.
+func Handler() http.Handler {
+	return promhttp.HandlerFor(registry, promhttp.HandlerOpts{})
+}
+
+// ListenAndServe serves the metrics at /metrics of the address until the
+// context is done.
+func ListenAndServe(ctx context.Context, addr string) error {
+	mux := http.NewServeMux()
+	mux.Handle("GET /metrics", Handler())
+
+	s := &http.Server{
+		Addr:    addr,
+		Handler: mux,
+	}
+
+	go func() {
+		<-ctx.Done()
+
+		ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+		defer cancel()
+
+		s.Shutdown(ctx)
+	}()
+
+	if err := s.ListenAndServe(); !errors.Is(err, http.ErrServerClosed) {
+		return err
+	}
+
+	return nil
+}
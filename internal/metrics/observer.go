@@ -0,0 +1,119 @@
+package metrics
+
+import (
+	"context"
+	"errors"
+	"strconv"
+	"time"
+
+	"github.com/umk/jsonrpc2"
+	"github.com/umk/llmservices/pkg/client"
+)
+
+// Method wraps the handler of the method to count its calls.
+func Method(method string, fn jsonrpc2.HandlerFunc) jsonrpc2.HandlerFunc {
+	return func(ctx context.Context, c jsonrpc2.RPCContext) (any, error) {
+		start := time.Now()
+
+		resp, err := fn(ctx, c)
+
+		rpcDuration.WithLabelValues(method).Observe(time.Since(start).Seconds())
+		rpcCalls.WithLabelValues(method, getErrorCode(err)).Inc()
+
+		return resp, err
+	}
+}
+
+func getErrorCode(err error) string {
+	if err == nil {
+		return "0"
+	}
+
+	var rpcErr jsonrpc2.Error
+	if errors.As(err, &rpcErr) {
+		return strconv.Itoa(rpcErr.Code)
+	}
+
+	var rpcErrer interface{ RPCError() jsonrpc2.Error }
+	if errors.As(err, &rpcErrer) {
+		return strconv.Itoa(rpcErrer.RPCError().Code)
+	}
+
+	return "-32603"
+}
+
+func getStatus(failed bool) string {
+	if failed {
+		return "error"
+	}
+	return "ok"
+}
+
+// StartSession counts the session as active until the returned function is
+// called.
+func StartSession() func() {
+	activeSessions.Inc()
+	return activeSessions.Dec
+}
+
+// Observer collects the metrics of the clients. To keep the number of the
+// series bounded, the labels come from the configuration of the service
+// rather than from the requests.
+type Observer struct {
+	client string
+	// Models reported by their names. Other models are reported as "other".
+	models map[string]bool
+}
+
+var _ client.Observer = Observer{}
+
+// NewObserver creates the observer of the global client, which reports the
+// default model of the client and the models priced by the client by their
+// names.
+func NewObserver(clientID string, conf *client.Config) Observer {
+	models := make(map[string]bool)
+	if conf.Model != "" {
+		models[conf.Model] = true
+	}
+	for model := range conf.Pricing {
+		if model != "*" {
+			models[model] = true
+		}
+	}
+
+	return Observer{client: clientID, models: models}
+}
+
+// SessionObserver is the observer of the clients set by the sessions. Their
+// IDs and models are chosen by the peers, so they're reported as "session"
+// and "other".
+var SessionObserver = Observer{client: "session"}
+
+func (o Observer) getModel(model string) string {
+	if o.models[model] {
+		return model
+	}
+	return "other"
+}
+
+func (o Observer) ObserveWait(clientID string, wait time.Duration) {
+	clientWait.WithLabelValues(o.client).Observe(wait.Seconds())
+}
+
+func (o Observer) ObserveAttempt(clientID, model, operation string, latency time.Duration, err error) {
+	adapterDuration.WithLabelValues(o.client, o.getModel(model), operation, getStatus(err != nil)).Observe(latency.Seconds())
+}
+
+func (o Observer) ObserveUsage(clientID, model string, usage *client.Usage) {
+	clientID, model = o.client, o.getModel(model)
+
+	clientRequests.WithLabelValues(clientID, model, getStatus(usage.Errors > 0)).Add(float64(usage.Requests))
+	clientRetries.WithLabelValues(clientID, model).Add(float64(usage.Retries))
+	clientCacheHits.WithLabelValues(clientID, model).Add(float64(usage.CacheHits))
+
+	clientTokens.WithLabelValues(clientID, model, "prompt").Add(float64(usage.PromptTokens))
+	clientTokens.WithLabelValues(clientID, model, "completion").Add(float64(usage.CompletionTokens))
+	clientTokens.WithLabelValues(clientID, model, "embedding").Add(float64(usage.EmbeddingTokens))
+
+	clientCost.WithLabelValues(clientID, model).Add(usage.Cost)
+}
@@ -0,0 +1,25 @@
+package msg
+
+import (
+	_ "embed"
+	"strings"
+	"text/template"
+)
+
+//go:embed templates/agent_tools_error_message.tmpl
+var agentToolsErrorMessage string
+
+var agentToolsErrorMessageTmpl = template.Must(template.New("agent_tools_error_message").Parse(agentToolsErrorMessage))
+
+type AgentToolsErrorMessageParams struct {
+	FinalAnswerTool string
+}
+
+func RenderAgentToolsErrorMessage(params AgentToolsErrorMessageParams) (string, error) {
+	var sb strings.Builder
+	if err := agentToolsErrorMessageTmpl.Execute(&sb, params); err != nil {
+		return "", err
+	}
+
+	return strings.TrimSpace(sb.String()), nil
+}
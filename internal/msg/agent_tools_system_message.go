@@ -0,0 +1,26 @@
+package msg
+
+import (
+	_ "embed"
+	"strings"
+	"text/template"
+)
+
+//go:embed templates/agent_tools_system_message.tmpl
+var agentToolsSystemMessage string
+
+var agentToolsSystemMessageTmpl = template.Must(template.New("agent_tools_system_message").Parse(agentToolsSystemMessage))
+
+type AgentToolsSystemMessageParams struct {
+	Description     string
+	FinalAnswerTool string
+}
+
+func RenderAgentToolsSystemMessage(params AgentToolsSystemMessageParams) (string, error) {
+	var sb strings.Builder
+	if err := agentToolsSystemMessageTmpl.Execute(&sb, params); err != nil {
+		return "", err
+	}
+
+	return strings.TrimSpace(sb.String()), nil
+}
@@ -0,0 +1,33 @@
+package msg
+
+import (
+	_ "embed"
+	"strings"
+	"text/template"
+)
+
+//go:embed templates/retrieval_context_message.tmpl
+var retrievalContextMessage string
+
+var retrievalContextMessageTmpl = template.Must(template.New("retrieval_context_message").Parse(retrievalContextMessage))
+
+type RetrievalContextMessageParams struct {
+	// The documents retrieved for the last user message
+	Documents []RetrievalContextDocument
+}
+
+type RetrievalContextDocument struct {
+	// ID of the document to refer to
+	ID string
+	// Content of the document
+	Text string
+}
+
+func RenderRetrievalContextMessage(p RetrievalContextMessageParams) (string, error) {
+	var sb strings.Builder
+	if err := retrievalContextMessageTmpl.Execute(&sb, p); err != nil {
+		return "", err
+	}
+
+	return strings.TrimSpace(sb.String()), nil
+}
@@ -0,0 +1,89 @@
+package rest
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+
+	"github.com/umk/llmservices/pkg/adapter"
+)
+
+// Maximum size of an error response body to be kept in the error.
+const maxErrorBody = 4 * 1024
+
+// Post sends the request as a JSON document and decodes the JSON response.
+// If the server responds with an unsuccessful status, the error is
+// an *adapter.StatusError.
+func Post(ctx context.Context, client *http.Client, url string, header http.Header, req any, resp any) error {
+	r, err := Send(ctx, client, url, header, req)
+	if err != nil {
+		return err
+	}
+	defer r.Body.Close()
+
+	if resp == nil {
+		return nil
+	}
+
+	if err := json.NewDecoder(r.Body).Decode(resp); err != nil {
+		return fmt.Errorf("failed to decode response: %w", err)
+	}
+
+	return nil
+}
+
+// Send sends the request as a JSON document and returns the response, which
+// body must be closed by the caller. If the server responds with an
+// unsuccessful status, the error is an *adapter.StatusError.
+func Send(ctx context.Context, client *http.Client, url string, header http.Header, req any) (*http.Response, error) {
+	b, err := json.Marshal(req)
+	if err != nil {
+		return nil, err
+	}
+
+	r, err := http.NewRequestWithContext(ctx, http.MethodPost, url, bytes.NewReader(b))
+	if err != nil {
+		return nil, err
+	}
+
+	for k, v := range header {
+		r.Header[k] = v
+	}
+	r.Header.Set("Content-Type", "application/json")
+
+	return Do(client, r)
+}
+
+// Do sends the request and checks the status of response.
+func Do(client *http.Client, req *http.Request) (*http.Response, error) {
+	if client == nil {
+		client = http.DefaultClient
+	}
+
+	r, err := client.Do(req)
+	if err != nil {
+		return nil, err
+	}
+
+	if r.StatusCode < 200 || r.StatusCode > 299 {
+		defer r.Body.Close()
+
+		b, _ := io.ReadAll(io.LimitReader(r.Body, maxErrorBody))
+		return nil, &adapter.StatusError{
+			StatusCode: r.StatusCode,
+			Header:     r.Header,
+			Body:       strings.TrimSpace(string(b)),
+		}
+	}
+
+	return r, nil
+}
+
+// URL joins the base URL and the path.
+func URL(base string, path string) string {
+	return strings.TrimSuffix(base, "/") + "/" + strings.TrimPrefix(path, "/")
+}
@@ -2,15 +2,90 @@ package callbacks
 
 import (
 	"context"
+	"encoding/json"
+	"fmt"
+	"slices"
 
+	"github.com/umk/jsonrpc2"
 	"github.com/umk/llmservices/pkg/adapter"
+	"github.com/umk/llmservices/pkg/tools"
 )
 
+var registry *tools.Registry
+
+// SetTools specifies the tools executed by the service, which the requests
+// may offer to the model by their names.
+func SetTools(r *tools.Registry) {
+	registry = r
+}
+
+// Tools returns the tools executed by the service with the names.
+func Tools(names ...string) (*tools.Registry, error) {
+	r := tools.NewRegistry()
+
+	for _, name := range names {
+		t, ok := registry.Get(name)
+		if !ok {
+			return nil, fmt.Errorf("tool not found: %s", name)
+		}
+		if err := r.Register(t); err != nil {
+			return nil, err
+		}
+	}
+
+	return r, nil
+}
+
 // Callback is a unified callback interface that aggregates callbacks of
 // individual packages like threads and agents.
-type Callback struct{}
+type Callback struct {
+	// ID of the request, on behalf of which the callbacks are made.
+	RequestID json.RawMessage
+	// Tools executed by the service for the request, like those of the
+	// MCP servers. Other tools are called by the peer.
+	Tools *tools.Registry
+}
+
+// New creates a callback bound to the request of the RPC context.
+func New(c jsonrpc2.RPCContext) (Callback, error) {
+	var id json.RawMessage
+	if err := c.ID(&id); err != nil {
+		return Callback{}, err
+	}
+
+	return Callback{RequestID: id}, nil
+}
+
+// AddTools makes the callback execute the tools of the registry, and returns
+// the tools offered to the model along with them. The names of the tools must
+// not be taken by the tools of the peer or the ones added before, so that
+// the calls meant for one tool never reach another.
+func (c *Callback) AddTools(offered []adapter.Tool, r *tools.Registry) ([]adapter.Tool, error) {
+	if c.Tools == nil {
+		c.Tools = tools.NewRegistry()
+	}
+
+	for _, name := range r.Names() {
+		if slices.ContainsFunc(offered, func(t adapter.Tool) bool { return t.Function.Name == name }) {
+			return nil, fmt.Errorf("tool is already offered: %s", name)
+		}
+
+		t, _ := r.Get(name)
+		if err := c.Tools.Register(t); err != nil {
+			return nil, err
+		}
+
+		offered = append(offered, adapter.Tool{Function: t.Function()})
+	}
+
+	return offered, nil
+}
 
 func (c Callback) Call(ctx context.Context, fn adapter.ToolCallFunction) (string, error) {
+	if t, ok := c.Tools.Get(fn.Name); ok {
+		return callTool(ctx, t, fn)
+	}
+
 	var res GetFunctionCallResponse
 	if err := GetFunctionCallRPC(ctx, GetFunctionCallRequest{
 		ToolCallFunction: fn,
@@ -26,3 +101,10 @@ func (c Callback) Thought(ctx context.Context, content string) error {
 		Content: content,
 	})
 }
+
+func (c Callback) Delta(ctx context.Context, delta adapter.CompletionDelta) error {
+	return PushCompletionDeltaRPC(ctx, PushCompletionDeltaRequest{
+		RequestID:       c.RequestID,
+		CompletionDelta: delta,
+	})
+}
@@ -2,12 +2,59 @@ package callbacks
 
 import (
 	"context"
+
+	"github.com/umk/llmservices/pkg/adapter"
+	"github.com/umk/llmservices/pkg/tools"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	semconv "go.opentelemetry.io/otel/semconv/v1.30.0"
+	"go.opentelemetry.io/otel/trace"
 )
 
+var tracer = otel.Tracer("github.com/umk/llmservices/internal/service/callbacks")
+
 func GetFunctionCallRPC(ctx context.Context, req GetFunctionCallRequest, resp *GetFunctionCallResponse) error {
-	return (*Client(ctx)).Call(ctx, "getFunctionCall", req, resp)
+	ctx, span := tracer.Start(ctx, "getFunctionCall",
+		trace.WithSpanKind(trace.SpanKindClient),
+		trace.WithAttributes(
+			semconv.RPCSystemKey.String("jsonrpc"),
+			semconv.RPCMethod("getFunctionCall"),
+			semconv.RPCJsonrpcVersion("2.0"),
+			attribute.String("llmservices.function.name", req.Name),
+		))
+	defer span.End()
+
+	if err := (*Client(ctx)).Call(ctx, "getFunctionCall", req, resp); err != nil {
+		span.RecordError(err)
+		span.SetStatus(codes.Error, err.Error())
+		return err
+	}
+
+	return nil
+}
+
+// callTool executes the tool of the service in place of the function of the
+// peer.
+func callTool(ctx context.Context, t tools.Tool, fn adapter.ToolCallFunction) (string, error) {
+	ctx, span := tracer.Start(ctx, "tool "+fn.Name,
+		trace.WithAttributes(attribute.String("llmservices.function.name", fn.Name)))
+	defer span.End()
+
+	resp, err := t.Call(ctx, fn.Arguments)
+	if err != nil {
+		span.RecordError(err)
+		span.SetStatus(codes.Error, err.Error())
+		return "", err
+	}
+
+	return resp, nil
 }
 
 func PushThoughtRPC(ctx context.Context, req PushThoughtRequest) error {
 	return (*Client(ctx)).Notify(ctx, "pushThought", req)
 }
+
+func PushCompletionDeltaRPC(ctx context.Context, req PushCompletionDeltaRequest) error {
+	return (*Client(ctx)).Notify(ctx, "pushCompletionDelta", req)
+}
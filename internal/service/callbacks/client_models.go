@@ -1,6 +1,10 @@
 package callbacks
 
-import "github.com/umk/llmservices/pkg/adapter"
+import (
+	"encoding/json"
+
+	"github.com/umk/llmservices/pkg/adapter"
+)
 
 /*** Get function call ***/
 
@@ -17,3 +21,11 @@ type GetFunctionCallResponse struct {
 type PushThoughtRequest struct {
 	Content string `json:"content"`
 }
+
+/*** Push completion delta ***/
+
+type PushCompletionDeltaRequest struct {
+	// ID of the request that produces the completion.
+	RequestID json.RawMessage `json:"request_id,omitempty"`
+	adapter.CompletionDelta
+}
@@ -0,0 +1,20 @@
+package callbacks
+
+import (
+	"context"
+	"errors"
+)
+
+var ErrCallbacksDisabled = errors.New("transport doesn't support callbacks")
+
+// Disabled is a client for the transports, which cannot send requests back
+// to the caller. Calls fail, and notifications are dropped.
+type Disabled struct{}
+
+func (Disabled) Call(ctx context.Context, method string, req any, resp any) error {
+	return ErrCallbacksDisabled
+}
+
+func (Disabled) Notify(ctx context.Context, method string, req any) error {
+	return nil
+}
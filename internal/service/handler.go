@@ -2,22 +2,54 @@ package service
 
 import (
 	"github.com/umk/jsonrpc2"
+	"github.com/umk/llmservices/internal/logging"
+	"github.com/umk/llmservices/internal/metrics"
 	"github.com/umk/llmservices/internal/service/handlers"
 	"github.com/umk/llmservices/internal/service/handlers/agent"
+	"github.com/umk/llmservices/internal/service/handlers/documents"
 	"github.com/umk/llmservices/internal/service/handlers/thread"
+	"github.com/umk/llmservices/internal/tracing"
 )
 
 func Handler() *jsonrpc2.Handler {
-	return jsonrpc2.NewHandler(map[string]jsonrpc2.HandlerFunc{
+	return jsonrpc2.NewHandler(Methods())
+}
+
+// Methods returns the handlers of the methods, which are authorized, traced,
+// measured and logged.
+func Methods() map[string]jsonrpc2.HandlerFunc {
+	funcs := map[string]jsonrpc2.HandlerFunc{
+		"createToken": handlers.CreateTokenRPC,
+
 		"setClient":     handlers.SetClientRPC,
 		"getCompletion": handlers.GetCompletionRPC,
 		"getEmbeddings": handlers.GetEmbeddingsRPC,
 		"getStatistics": handlers.GetStatisticsRPC,
+		"splitText":     handlers.SplitTextRPC,
 
 		"getThreadCompletion": thread.GetCompletionRPC,
 		"getThreadSummary":    thread.GetSummaryRPC,
 		"getThreadResponse":   thread.GetResponseRPC,
 
 		"getAgentResponse": agent.GetResponseRPC,
-	})
+
+		"setIndex":        documents.SetIndexRPC,
+		"upsertDocuments": documents.UpsertDocumentsRPC,
+		"deleteDocuments": documents.DeleteDocumentsRPC,
+		"searchDocuments": documents.SearchDocumentsRPC,
+	}
+
+	for method, fn := range funcs {
+		funcs[method] = handlers.Authorized(method, fn)
+	}
+
+	// A session must be able to authenticate before it's permitted to call
+	// anything else.
+	funcs["authenticate"] = handlers.AuthenticateRPC
+
+	for method, fn := range funcs {
+		funcs[method] = tracing.Method(method, metrics.Method(method, logging.Method(method, fn)))
+	}
+
+	return funcs
 }
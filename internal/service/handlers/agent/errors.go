@@ -1,11 +1,29 @@
 package agent
 
-import "github.com/umk/jsonrpc2"
+import (
+	"errors"
+
+	"github.com/umk/jsonrpc2"
+	"github.com/umk/llmservices/internal/service/handlers"
+	"github.com/umk/llmservices/pkg/client"
+)
 
 func newResponseError(err error) error {
+	if errors.Is(err, client.ErrBudgetExceeded) {
+		return handlers.NewBudgetError(err)
+	}
+
 	return jsonrpc2.Error{
 		Code:    -32000,
 		Message: "Response error",
 		Data:    map[string]any{"error": err.Error()},
 	}
 }
+
+func newToolsError(err error) error {
+	return jsonrpc2.Error{
+		Code:    -32000,
+		Message: "Tools error",
+		Data:    map[string]any{"error": err.Error()},
+	}
+}
@@ -5,6 +5,8 @@ import (
 
 	"github.com/umk/jsonrpc2"
 	"github.com/umk/llmservices/internal/service/callbacks"
+	"github.com/umk/llmservices/internal/service/handlers"
+	"github.com/umk/llmservices/pkg/client"
 )
 
 func GetResponseRPC(ctx context.Context, c jsonrpc2.RPCContext) (any, error) {
@@ -18,7 +20,36 @@ func GetResponseRPC(ctx context.Context, c jsonrpc2.RPCContext) (any, error) {
 		return nil, err
 	}
 
-	req.Params.Handler = callbacks.Callback{}
+	if req.NoCache {
+		ctx = client.ContextWithoutCache(ctx)
+	}
+
+	cb, err := callbacks.New(c)
+	if err != nil {
+		return nil, err
+	}
+
+	if len(req.ServerTools) > 0 {
+		r, err := callbacks.Tools(req.ServerTools...)
+		if err != nil {
+			return nil, newToolsError(err)
+		}
+		if req.Params.Tools, err = cb.AddTools(req.Params.Tools, r); err != nil {
+			return nil, newToolsError(err)
+		}
+	}
+
+	if len(req.MCPServers) > 0 {
+		r, err := handlers.GetMCPTools(ctx, req.MCPServers)
+		if err != nil {
+			return nil, err
+		}
+		if req.Params.Tools, err = cb.AddTools(req.Params.Tools, r); err != nil {
+			return nil, newToolsError(err)
+		}
+	}
+
+	req.Params.Handler = cb
 
 	resp, err := cl.Response(ctx, req.Thread, req.Params)
 	if err != nil {
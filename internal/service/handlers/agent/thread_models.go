@@ -11,6 +11,15 @@ type GetResponseRequest struct {
 	ClientID string               `json:"client_id" validate:"required"`
 	Thread   thread.Thread        `json:"thread"`
 	Params   agent.ResponseParams `json:"params"`
+	// Whether to bypass the cache of the client, if it caches responses.
+	NoCache bool `json:"no_cache"`
+	// Names of the tools executed by the service to offer to the model in
+	// addition to the tools of the request.
+	ServerTools []string `json:"server_tools,omitempty"`
+	// IDs of the MCP servers, whose tools are offered to the model in
+	// addition to the tools of the request. The names of all of the tools
+	// offered must be different.
+	MCPServers []string `json:"mcp_servers,omitempty"`
 }
 
 type GetResponseResponse struct {
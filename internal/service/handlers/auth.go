@@ -0,0 +1,79 @@
+package handlers
+
+import (
+	"context"
+	"time"
+
+	"github.com/umk/jsonrpc2"
+	"github.com/umk/llmservices/internal/auth"
+)
+
+// Authorized wraps the handler of the method to call it only if the session
+// is permitted to.
+func Authorized(method string, fn jsonrpc2.HandlerFunc) jsonrpc2.HandlerFunc {
+	return func(ctx context.Context, c jsonrpc2.RPCContext) (any, error) {
+		p, err := auth.GetPrincipal(ctx)
+		if err != nil {
+			return nil, NewAuthError(err)
+		}
+
+		if err := p.CheckMethod(method); err != nil {
+			return nil, NewAuthError(err)
+		}
+
+		return fn(ctx, c)
+	}
+}
+
+func AuthenticateRPC(ctx context.Context, c jsonrpc2.RPCContext) (any, error) {
+	var req AuthenticateRequest
+	if err := c.Request(&req); err != nil {
+		return nil, err
+	}
+
+	if !auth.Enabled() {
+		return c.Response(AuthenticateResponse{Name: auth.Unrestricted.Name})
+	}
+
+	p, err := auth.Authenticate(req.Token)
+	if err != nil {
+		return nil, NewAuthError(err)
+	}
+
+	auth.SetPrincipal(ctx, p)
+
+	return c.Response(AuthenticateResponse{Name: p.Name})
+}
+
+func CreateTokenRPC(ctx context.Context, c jsonrpc2.RPCContext) (any, error) {
+	var req CreateTokenRequest
+	if err := c.Request(&req); err != nil {
+		return nil, err
+	}
+
+	p, err := auth.GetPrincipal(ctx)
+	if err != nil {
+		return nil, NewAuthError(err)
+	}
+
+	// The token cannot permit more than the session that created it, nor be
+	// accepted for longer.
+	claims := auth.Claims{
+		Subject:   p.Name,
+		ExpiresAt: time.Now().Add(time.Duration(req.TTL)).Unix(),
+		Policies:  append(p.Policies[:len(p.Policies):len(p.Policies)], req.Policy),
+	}
+	if p.ExpiresAt != 0 {
+		claims.ExpiresAt = min(claims.ExpiresAt, p.ExpiresAt)
+	}
+
+	token, err := auth.Sign(claims)
+	if err != nil {
+		return nil, newConfigError(err)
+	}
+
+	return c.Response(CreateTokenResponse{
+		Token:     token,
+		ExpiresAt: claims.ExpiresAt,
+	})
+}
@@ -0,0 +1,34 @@
+package handlers
+
+import (
+	"github.com/umk/llmservices/internal/auth"
+	"github.com/umk/llmservices/pkg/client"
+)
+
+/*** Authenticate ***/
+
+type AuthenticateRequest struct {
+	// Either a static token from the configuration, or a signed one.
+	Token string `json:"token" validate:"required"`
+}
+
+type AuthenticateResponse struct {
+	Name string `json:"name"`
+}
+
+/*** Create token ***/
+
+type CreateTokenRequest struct {
+	// Access permitted by the token in addition to the restrictions of the
+	// session that creates it.
+	Policy auth.Policy `json:"policy"`
+	// Time the token is accepted for, like "1h". The token expires no later
+	// than the one of the session.
+	TTL client.Duration `json:"ttl" validate:"gt=0"`
+}
+
+type CreateTokenResponse struct {
+	Token string `json:"token"`
+	// Time in Unix seconds after which the token is not accepted.
+	ExpiresAt int64 `json:"expires_at"`
+}
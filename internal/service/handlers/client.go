@@ -5,6 +5,9 @@ import (
 	"sync"
 
 	"github.com/umk/jsonrpc2"
+	"github.com/umk/llmservices/internal/auth"
+	"github.com/umk/llmservices/internal/logging"
+	"github.com/umk/llmservices/internal/metrics"
 	"github.com/umk/llmservices/pkg/client"
 )
 
@@ -16,7 +19,14 @@ func SetClientRPC(ctx context.Context, c jsonrpc2.RPCContext) (any, error) {
 		return nil, err
 	}
 
-	cl, err := client.New(&req.Config)
+	cl, err := client.New(&req.Config,
+		client.WithName(req.ClientID),
+		client.WithClients(func(clientID string) (*client.Client, error) {
+			return GetClient(ctx, clientID)
+		}),
+		client.WithCache(cache),
+		client.WithObserver(metrics.SessionObserver),
+		client.WithLogger(logging.Logger, logging.Content))
 	if err != nil {
 		return nil, newConfigError(err)
 	}
@@ -33,8 +43,23 @@ func GetClient(ctx context.Context, clientID string) (*client.Client, error) {
 		return v.(*client.Client), nil
 	}
 
+	// The global clients are shared by the sessions, so access to them is
+	// restricted according to the principal of the session.
+	p, err := auth.GetPrincipal(ctx)
+	if err != nil {
+		return nil, NewAuthError(err)
+	}
+
+	if err := p.CheckClient(clientID); err != nil {
+		return nil, NewAuthError(err)
+	}
+
 	if v, ok := globalClients.Load(clientID); ok {
-		return v.(*client.Client), nil
+		cl := v.(*client.Client)
+		if p.RestrictsModels() {
+			cl = cl.WithModels(p.AllowsModel)
+		}
+		return cl, nil
 	}
 
 	return nil, errClientNotFound
@@ -47,3 +72,19 @@ func SetClient(ctx context.Context, clientID string, client *client.Client) {
 func SetGlobalClient(clientID string, client *client.Client) {
 	globalClients.Store(clientID, client)
 }
+
+func GetGlobalClient(clientID string) (*client.Client, error) {
+	if v, ok := globalClients.Load(clientID); ok {
+		return v.(*client.Client), nil
+	}
+
+	return nil, errClientNotFound
+}
+
+// RangeGlobalClients calls the function for each of the global clients,
+// until the function returns false.
+func RangeGlobalClients(fn func(clientID string, client *client.Client) bool) {
+	globalClients.Range(func(k, v any) bool {
+		return fn(k.(string), v.(*client.Client))
+	})
+}
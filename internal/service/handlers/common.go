@@ -2,8 +2,13 @@ package handlers
 
 import (
 	"context"
+	"errors"
 
 	"github.com/umk/jsonrpc2"
+	"github.com/umk/llmservices/internal/service/callbacks"
+	"github.com/umk/llmservices/pkg/adapter"
+	"github.com/umk/llmservices/pkg/chunker"
+	"github.com/umk/llmservices/pkg/client"
 )
 
 func GetCompletionRPC(ctx context.Context, c jsonrpc2.RPCContext) (any, error) {
@@ -17,7 +22,20 @@ func GetCompletionRPC(ctx context.Context, c jsonrpc2.RPCContext) (any, error) {
 		return nil, err
 	}
 
-	resp, err := cl.Completion(ctx, req.Messages, req.Params)
+	if req.NoCache {
+		ctx = client.ContextWithoutCache(ctx)
+	}
+
+	var delta adapter.DeltaHandler
+	if req.Stream {
+		cb, err := callbacks.New(c)
+		if err != nil {
+			return nil, err
+		}
+		delta = cb.Delta
+	}
+
+	resp, err := cl.CompletionStream(ctx, req.Messages, req.Params, delta)
 	if err != nil {
 		return nil, newCompletionError(err)
 	}
@@ -38,13 +56,70 @@ func GetEmbeddingsRPC(ctx context.Context, c jsonrpc2.RPCContext) (any, error) {
 		return nil, err
 	}
 
-	resp, err := cl.Embeddings(ctx, req.Input, req.Params)
+	if req.NoCache {
+		ctx = client.ContextWithoutCache(ctx)
+	}
+
+	input := req.Input.Values
+
+	var chunks []EmbeddingsChunk
+	if req.Split != nil {
+		b := cl.Samples.BytesPerTok()
+
+		input = nil
+		for i, v := range req.Input.Values {
+			cs, err := chunker.Split(v, *req.Split, b)
+			if err != nil {
+				return nil, newSplitError(err)
+			}
+			for _, c := range cs {
+				input = append(input, c.Text)
+				chunks = append(chunks, EmbeddingsChunk{Input: i, Chunk: c})
+			}
+		}
+		if len(input) == 0 {
+			return nil, newSplitError(errors.New("inputs contain no text"))
+		}
+	}
+
+	resp, err := cl.Embeddings(ctx, input, req.Params)
 	if err != nil {
 		return nil, newEmbeddingsError(err)
 	}
 
+	var data any = resp.Data
+	if req.Input.Single && req.Split == nil {
+		data = resp.Data[0]
+	}
+
 	return c.Response(GetEmbeddingsResponse{
-		Embeddings: resp,
+		Data:     data,
+		Chunks:   chunks,
+		Usage:    resp.Usage,
+		Attempts: resp.Attempts,
+		Backend:  resp.Backend,
+		Cached:   resp.Cached,
+	})
+}
+
+func SplitTextRPC(ctx context.Context, c jsonrpc2.RPCContext) (any, error) {
+	var req SplitTextRequest
+	if err := c.Request(&req); err != nil {
+		return nil, err
+	}
+
+	cl, err := GetClient(ctx, req.ClientID)
+	if err != nil {
+		return nil, err
+	}
+
+	chunks, err := chunker.Split(req.Text, req.Config, cl.Samples.BytesPerTok())
+	if err != nil {
+		return nil, newSplitError(err)
+	}
+
+	return c.Response(SplitTextResponse{
+		Chunks: chunks,
 	})
 }
 
@@ -59,8 +134,16 @@ func GetStatisticsRPC(ctx context.Context, c jsonrpc2.RPCContext) (any, error) {
 		return nil, err
 	}
 
+	s := client.ContextStatistics(ctx)
+
 	resp := GetStatisticsResponse{
-		BytesPerTok: cl.Samples.BytesPerTok(),
+		BytesPerTok:  cl.Samples.BytesPerTok(),
+		Usage:        cl.Stats.Get(cl.Name()),
+		SessionUsage: s.Get(cl.Name()),
+	}
+
+	if req.Reset {
+		s.Reset(cl.Name())
 	}
 
 	return c.Response(resp)
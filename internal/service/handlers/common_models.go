@@ -1,7 +1,11 @@
 package handlers
 
 import (
+	"encoding/json"
+
 	"github.com/umk/llmservices/pkg/adapter"
+	"github.com/umk/llmservices/pkg/chunker"
+	"github.com/umk/llmservices/pkg/client"
 )
 
 /*** Get completion ***/
@@ -10,6 +14,10 @@ type GetCompletionRequest struct {
 	ClientID string                   `json:"client_id" validate:"required"`
 	Messages []adapter.Message        `json:"messages" validate:"required,min=1"`
 	Params   adapter.CompletionParams `json:"params"`
+	// Whether to push the chunks of completion as notifications.
+	Stream bool `json:"stream"`
+	// Whether to bypass the cache of the client, if it caches responses.
+	NoCache bool `json:"no_cache"`
 }
 
 type GetCompletionResponse struct {
@@ -19,21 +27,82 @@ type GetCompletionResponse struct {
 /*** Get embeddings ***/
 
 type GetEmbeddingsRequest struct {
-	Input    string                   `json:"input" validate:"required"`
+	// Either a single string, or a list of strings.
+	Input    EmbeddingsInput          `json:"input"`
 	Params   adapter.EmbeddingsParams `json:"params"`
 	ClientID string                   `json:"client_id" validate:"required"`
+	// If specified, the inputs are split into chunks, and the embeddings
+	// are returned for each of the chunks.
+	Split *chunker.Config `json:"split,omitempty"`
+	// Whether to bypass the cache of the client, if it caches responses.
+	NoCache bool `json:"no_cache"`
 }
 
 type GetEmbeddingsResponse struct {
-	adapter.Embeddings
+	// Embedding of the input if it's a single string, which is not split.
+	// Otherwise, the embeddings in the same order as the inputs or the
+	// chunks.
+	Data any `json:"data"`
+	// Chunks of the inputs in the same order as the embeddings, if the
+	// inputs were split.
+	Chunks   []EmbeddingsChunk        `json:"chunks,omitempty"`
+	Usage    *adapter.EmbeddingsUsage `json:"usage,omitempty"`
+	Attempts int                      `json:"attempts,omitempty"`
+	Backend  string                   `json:"backend,omitempty"`
+	Cached   bool                     `json:"cached,omitempty"`
+}
+
+type EmbeddingsInput struct {
+	Values []string `validate:"required,min=1,dive,required"`
+	// Whether a single string is given instead of a list.
+	Single bool
+}
+
+func (i *EmbeddingsInput) UnmarshalJSON(b []byte) error {
+	var s string
+	if err := json.Unmarshal(b, &s); err == nil {
+		*i = EmbeddingsInput{Values: []string{s}, Single: true}
+		return nil
+	}
+
+	*i = EmbeddingsInput{}
+
+	return json.Unmarshal(b, &i.Values)
+}
+
+type EmbeddingsChunk struct {
+	// Index of the input the chunk belongs to.
+	Input int `json:"input"`
+	chunker.Chunk
+}
+
+/*** Split text ***/
+
+type SplitTextRequest struct {
+	// ID of a client, which estimates the number of tokens in the text.
+	ClientID string         `json:"client_id" validate:"required"`
+	Text     string         `json:"text" validate:"required"`
+	Config   chunker.Config `json:"config"`
+}
+
+type SplitTextResponse struct {
+	Chunks []chunker.Chunk `json:"chunks"`
 }
 
 /*** Get statistics ***/
 
 type GetStatisticsRequest struct {
 	ClientID string `json:"client_id" validate:"required"`
+	// Whether to reset the usage of the client in the session once it's
+	// returned.
+	Reset bool `json:"reset"`
 }
 
 type GetStatisticsResponse struct {
 	BytesPerTok float32 `json:"bytes_per_tok"`
+	// Usage of the client by model since it was created.
+	Usage []client.ModelUsage `json:"usage"`
+	// Usage of the client by model in the session since it started or the
+	// usage was reset.
+	SessionUsage []client.ModelUsage `json:"session_usage"`
 }
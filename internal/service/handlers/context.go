@@ -3,6 +3,8 @@ package handlers
 import (
 	"context"
 	"sync"
+
+	"github.com/umk/llmservices/pkg/client"
 )
 
 type ContextKey string
@@ -11,8 +13,31 @@ const (
 	CtxClients ContextKey = "clients"
 )
 
+var (
+	sessionTokens int64
+	cache         client.Cache
+)
+
 func Context(ctx context.Context) context.Context {
-	return context.WithValue(ctx, CtxClients, new(sync.Map))
+	ctx = context.WithValue(ctx, CtxClients, new(sync.Map))
+
+	// Usage of the clients is also accounted for the session.
+	s := client.NewStatistics()
+	s.MaxTokens = sessionTokens
+
+	return client.ContextWithStatistics(ctx, s)
+}
+
+// SetSessionTokens limits the tokens a session may use with any of the
+// clients. If zero, the tokens are not limited.
+func SetSessionTokens(tokens int64) {
+	sessionTokens = tokens
+}
+
+// SetCache specifies the cache shared by the clients of the sessions, which
+// keep their responses in it if caching is enabled by their configuration.
+func SetCache(c client.Cache) {
+	cache = c
 }
 
 func Clients(ctx context.Context) *sync.Map {
@@ -0,0 +1,105 @@
+package documents
+
+import (
+	"context"
+
+	"github.com/umk/jsonrpc2"
+	"github.com/umk/llmservices/internal/service/handlers"
+	"github.com/umk/llmservices/pkg/vector"
+)
+
+func UpsertDocumentsRPC(ctx context.Context, c jsonrpc2.RPCContext) (any, error) {
+	var req UpsertDocumentsRequest
+	if err := c.Request(&req); err != nil {
+		return nil, err
+	}
+
+	s, err := getIndexForUpdate(ctx, req.IndexID)
+	if err != nil {
+		return nil, err
+	}
+
+	cl, err := handlers.GetClient(ctx, req.ClientID)
+	if err != nil {
+		return nil, err
+	}
+
+	input := make([]string, len(req.Documents))
+	for i, d := range req.Documents {
+		input[i] = d.Text
+	}
+
+	e, err := cl.Embeddings(ctx, input, req.Params)
+	if err != nil {
+		return nil, newEmbeddingsError(err)
+	}
+
+	docs := make([]vector.Document, len(req.Documents))
+	for i, d := range req.Documents {
+		docs[i] = vector.Document{
+			ID:       d.ID,
+			Vector:   e.Data[i],
+			Text:     d.Text,
+			Metadata: d.Metadata,
+		}
+	}
+
+	if err := s.Upsert(docs...); err != nil {
+		return nil, newIndexError(err)
+	}
+
+	return c.Response(UpsertDocumentsResponse{
+		Usage: e.Usage,
+	})
+}
+
+func DeleteDocumentsRPC(ctx context.Context, c jsonrpc2.RPCContext) (any, error) {
+	var req DeleteDocumentsRequest
+	if err := c.Request(&req); err != nil {
+		return nil, err
+	}
+
+	s, err := getIndexForUpdate(ctx, req.IndexID)
+	if err != nil {
+		return nil, err
+	}
+
+	n, err := s.Delete(req.IDs...)
+	if err != nil {
+		return nil, newIndexError(err)
+	}
+
+	return c.Response(DeleteDocumentsResponse{
+		Deleted: n,
+	})
+}
+
+func SearchDocumentsRPC(ctx context.Context, c jsonrpc2.RPCContext) (any, error) {
+	var req SearchDocumentsRequest
+	if err := c.Request(&req); err != nil {
+		return nil, err
+	}
+
+	hits, usage, err := search(ctx, &req.SearchParams, req.Query)
+	if err != nil {
+		return nil, err
+	}
+
+	resp := SearchDocumentsResponse{
+		Hits:  make([]Hit, len(hits)),
+		Usage: usage,
+	}
+
+	for i, h := range hits {
+		resp.Hits[i] = Hit{
+			Document: Document{
+				ID:       h.ID,
+				Text:     h.Text,
+				Metadata: h.Metadata,
+			},
+			Score: h.Score,
+		}
+	}
+
+	return c.Response(resp)
+}
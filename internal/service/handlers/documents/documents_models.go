@@ -0,0 +1,81 @@
+package documents
+
+import (
+	"github.com/umk/llmservices/pkg/adapter"
+	"github.com/umk/llmservices/pkg/vector"
+)
+
+type Document struct {
+	ID       string         `json:"id" validate:"required"`
+	Text     string         `json:"text" validate:"required"`
+	Metadata map[string]any `json:"metadata,omitempty"`
+}
+
+type Hit struct {
+	Document
+	Score float64 `json:"score"`
+}
+
+/*** Set index ***/
+
+type SetIndexRequest struct {
+	IndexID string `json:"index_id" validate:"required"`
+	// Configuration of the index, which is kept in memory only, so the path
+	// must not be specified.
+	Config vector.Config `json:"config"`
+}
+
+type SetIndexResponse struct{}
+
+/*** Upsert documents ***/
+
+type UpsertDocumentsRequest struct {
+	IndexID string `json:"index_id" validate:"required"`
+	// ID of a client that gets the embeddings of the documents.
+	ClientID  string                   `json:"client_id" validate:"required"`
+	Documents []Document               `json:"documents" validate:"required,min=1,dive"`
+	Params    adapter.EmbeddingsParams `json:"params"`
+}
+
+type UpsertDocumentsResponse struct {
+	Usage *adapter.EmbeddingsUsage `json:"usage,omitempty"`
+}
+
+/*** Delete documents ***/
+
+type DeleteDocumentsRequest struct {
+	IndexID string   `json:"index_id" validate:"required"`
+	IDs     []string `json:"ids" validate:"required,min=1"`
+}
+
+type DeleteDocumentsResponse struct {
+	// Number of documents deleted from the index.
+	Deleted int `json:"deleted"`
+}
+
+/*** Search documents ***/
+
+type SearchDocumentsRequest struct {
+	SearchParams
+	Query string `json:"query" validate:"required"`
+}
+
+type SearchDocumentsResponse struct {
+	Hits  []Hit                    `json:"hits"`
+	Usage *adapter.EmbeddingsUsage `json:"usage,omitempty"`
+}
+
+type SearchParams struct {
+	IndexID string `json:"index_id" validate:"required"`
+	// ID of a client that gets the embeddings of the query. Must use the
+	// same model as the documents were added with.
+	ClientID string                   `json:"client_id" validate:"required"`
+	Params   adapter.EmbeddingsParams `json:"params"`
+	// Maximum number of documents to return. Defaults to 10.
+	Limit int `json:"limit" validate:"omitempty,min=1"`
+	// Minimum similarity of documents to the query, from -1 to 1.
+	MinScore *float64 `json:"min_score,omitempty" validate:"omitempty,gte=-1,lte=1"`
+	// Metadata the documents must have. If the value is a list, the
+	// metadata value must be equal to any of its items.
+	Filter vector.Filter `json:"filter,omitempty"`
+}
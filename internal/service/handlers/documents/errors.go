@@ -0,0 +1,39 @@
+package documents
+
+import (
+	"errors"
+
+	"github.com/umk/jsonrpc2"
+	"github.com/umk/llmservices/internal/service/handlers"
+	"github.com/umk/llmservices/pkg/client"
+)
+
+var errIndexNotFound = jsonrpc2.Error{
+	Code:    -32000,
+	Message: "Index not found",
+}
+
+var errIndexPath = jsonrpc2.Error{
+	Code:    -32000,
+	Message: "Index of a session cannot have a path",
+}
+
+func newIndexError(err error) error {
+	return jsonrpc2.Error{
+		Code:    -32000,
+		Message: "Index error",
+		Data:    map[string]any{"error": err.Error()},
+	}
+}
+
+func newEmbeddingsError(err error) error {
+	if errors.Is(err, client.ErrBudgetExceeded) {
+		return handlers.NewBudgetError(err)
+	}
+
+	return jsonrpc2.Error{
+		Code:    -32000,
+		Message: "Embeddings error",
+		Data:    map[string]any{"error": err.Error()},
+	}
+}
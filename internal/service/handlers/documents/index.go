@@ -0,0 +1,91 @@
+package documents
+
+import (
+	"context"
+	"sync"
+
+	"github.com/umk/jsonrpc2"
+	"github.com/umk/llmservices/internal/auth"
+	"github.com/umk/llmservices/internal/service/handlers"
+	"github.com/umk/llmservices/pkg/vector"
+)
+
+type ContextKey string
+
+const (
+	CtxIndexes ContextKey = "indexes"
+)
+
+var globalIndexes sync.Map
+
+func Context(ctx context.Context) context.Context {
+	return context.WithValue(ctx, CtxIndexes, new(sync.Map))
+}
+
+func Indexes(ctx context.Context) *sync.Map {
+	return ctx.Value(CtxIndexes).(*sync.Map)
+}
+
+func SetIndexRPC(ctx context.Context, c jsonrpc2.RPCContext) (any, error) {
+	var req SetIndexRequest
+	if err := c.Request(&req); err != nil {
+		return nil, err
+	}
+
+	// The index of a session is kept in memory only, so that the session
+	// cannot read or overwrite the files of the host.
+	if req.Config.Path != "" {
+		return nil, errIndexPath
+	}
+
+	s, err := vector.Open(req.Config)
+	if err != nil {
+		return nil, newIndexError(err)
+	}
+
+	Indexes(ctx).Store(req.IndexID, s)
+
+	var resp SetIndexResponse
+
+	return c.Response(resp)
+}
+
+func GetIndex(ctx context.Context, indexID string) (*vector.Store, error) {
+	if v, ok := Indexes(ctx).Load(indexID); ok {
+		return v.(*vector.Store), nil
+	}
+
+	if v, ok := globalIndexes.Load(indexID); ok {
+		return v.(*vector.Store), nil
+	}
+
+	return nil, errIndexNotFound
+}
+
+// getIndexForUpdate returns the index, in which documents are going to be
+// upserted or deleted. The global indexes are shared by the sessions, so
+// changes to them are restricted according to the principal of the session.
+func getIndexForUpdate(ctx context.Context, indexID string) (*vector.Store, error) {
+	if v, ok := Indexes(ctx).Load(indexID); ok {
+		return v.(*vector.Store), nil
+	}
+
+	p, err := auth.GetPrincipal(ctx)
+	if err != nil {
+		return nil, handlers.NewAuthError(err)
+	}
+
+	if err := p.CheckIndex(indexID); err != nil {
+		return nil, handlers.NewAuthError(err)
+	}
+
+	if v, ok := globalIndexes.Load(indexID); ok {
+		return v.(*vector.Store), nil
+	}
+
+	return nil, errIndexNotFound
+}
+
+func SetGlobalIndex(indexID string, index *vector.Store) {
+	globalIndexes.Store(indexID, index)
+}
@@ -0,0 +1,79 @@
+package documents
+
+import (
+	"context"
+
+	"github.com/umk/llmservices/internal/service/handlers"
+	"github.com/umk/llmservices/pkg/adapter"
+	"github.com/umk/llmservices/pkg/client/thread"
+	"github.com/umk/llmservices/pkg/vector"
+)
+
+const defaultSearchLimit = 10
+
+func search(ctx context.Context, params *SearchParams, query string) ([]vector.Hit, *adapter.EmbeddingsUsage, error) {
+	s, err := GetIndex(ctx, params.IndexID)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	cl, err := handlers.GetClient(ctx, params.ClientID)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	e, err := cl.Embeddings(ctx, []string{query}, params.Params)
+	if err != nil {
+		return nil, nil, newEmbeddingsError(err)
+	}
+
+	limit := params.Limit
+	if limit == 0 {
+		limit = defaultSearchLimit
+	}
+
+	hits, err := s.Search(e.Data[0], limit, params.Filter)
+	if err != nil {
+		return nil, nil, newIndexError(err)
+	}
+
+	if params.MinScore != nil {
+		for i, h := range hits {
+			if h.Score < *params.MinScore {
+				hits = hits[:i]
+				break
+			}
+		}
+	}
+
+	return hits, e.Usage, nil
+}
+
+// Retriever looks up the documents relevant to the last user message of
+// a thread in the index.
+type Retriever struct {
+	params SearchParams
+}
+
+func NewRetriever(params SearchParams) *Retriever {
+	return &Retriever{params: params}
+}
+
+func (r *Retriever) Retrieve(ctx context.Context, query string) ([]thread.Citation, error) {
+	hits, _, err := search(ctx, &r.params, query)
+	if err != nil {
+		return nil, err
+	}
+
+	citations := make([]thread.Citation, len(hits))
+	for i, h := range hits {
+		citations[i] = thread.Citation{
+			ID:       h.ID,
+			Text:     h.Text,
+			Score:    h.Score,
+			Metadata: h.Metadata,
+		}
+	}
+
+	return citations, nil
+}
@@ -0,0 +1,83 @@
+package documents
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+
+	"github.com/umk/llmservices/pkg/adapter"
+	"github.com/umk/llmservices/pkg/tools"
+)
+
+type searchTool struct {
+	function adapter.ToolFunction
+	params   SearchParams
+}
+
+// NewSearchTool creates the tool that searches the index for the documents
+// relevant to a query. The index and the client are resolved with the
+// context of the call, so they're subject to the restrictions of the
+// session.
+func NewSearchTool(name, description string, params SearchParams) tools.Tool {
+	if description == "" {
+		description = "Searches the documents relevant to a query, ordered from the most relevant."
+	}
+
+	return &searchTool{
+		function: adapter.ToolFunction{
+			Name:        name,
+			Description: &description,
+			Parameters: map[string]any{
+				"type": "object",
+				"properties": map[string]any{
+					"query": map[string]any{
+						"type":        "string",
+						"description": "Text to find the relevant documents for.",
+					},
+				},
+				"required": []string{"query"},
+			},
+		},
+		params: params,
+	}
+}
+
+func (t *searchTool) Function() adapter.ToolFunction {
+	return t.function
+}
+
+func (t *searchTool) Call(ctx context.Context, arguments string) (string, error) {
+	var args struct {
+		Query string `json:"query"`
+	}
+	if err := json.Unmarshal([]byte(arguments), &args); err != nil {
+		return "", err
+	}
+	if args.Query == "" {
+		return "", errors.New("query is required")
+	}
+
+	hits, _, err := search(ctx, &t.params, args.Query)
+	if err != nil {
+		return "", err
+	}
+
+	r := make([]Hit, len(hits))
+	for i, h := range hits {
+		r[i] = Hit{
+			Document: Document{
+				ID:       h.ID,
+				Text:     h.Text,
+				Metadata: h.Metadata,
+			},
+			Score: h.Score,
+		}
+	}
+
+	b, err := json.Marshal(r)
+	if err != nil {
+		return "", err
+	}
+
+	return string(b), nil
+}
@@ -1,12 +1,50 @@
 package handlers
 
-import "github.com/umk/jsonrpc2"
+import (
+	"errors"
+
+	"github.com/umk/jsonrpc2"
+	"github.com/umk/llmservices/internal/auth"
+	"github.com/umk/llmservices/pkg/client"
+)
 
 var errClientNotFound = jsonrpc2.Error{
 	Code:    -32000,
 	Message: "Client not found",
 }
 
+var errMCPServerNotFound = jsonrpc2.Error{
+	Code:    -32000,
+	Message: "MCP server not found",
+}
+
+// NewAuthError reports that the session is not authenticated or that its
+// principal isn't permitted to access a resource.
+func NewAuthError(err error) error {
+	message := "Not authenticated"
+
+	var permErr *auth.PermissionError
+	if errors.As(err, &permErr) {
+		message = "Permission denied"
+	}
+
+	return jsonrpc2.Error{
+		Code:    -32000,
+		Message: message,
+		Data:    map[string]any{"error": err.Error()},
+	}
+}
+
+// NewBudgetError reports that a budget is exhausted with a code of its own,
+// so that the caller can tell it from the other errors.
+func NewBudgetError(err error) error {
+	return jsonrpc2.Error{
+		Code:    -32001,
+		Message: "Budget exceeded",
+		Data:    map[string]any{"error": err.Error()},
+	}
+}
+
 func newConfigError(err error) error {
 	return jsonrpc2.Error{
 		Code:    -32000,
@@ -16,6 +54,10 @@ func newConfigError(err error) error {
 }
 
 func newCompletionError(err error) error {
+	if errors.Is(err, client.ErrBudgetExceeded) {
+		return NewBudgetError(err)
+	}
+
 	return jsonrpc2.Error{
 		Code:    -32000,
 		Message: "Completion error",
@@ -24,6 +66,10 @@ func newCompletionError(err error) error {
 }
 
 func newEmbeddingsError(err error) error {
+	if errors.Is(err, client.ErrBudgetExceeded) {
+		return NewBudgetError(err)
+	}
+
 	return jsonrpc2.Error{
 		Code:    -32000,
 		Message: "Embeddings error",
@@ -38,3 +84,19 @@ func newSpeechError(err error) error {
 		Data:    map[string]any{"error": err.Error()},
 	}
 }
+
+func newSplitError(err error) error {
+	return jsonrpc2.Error{
+		Code:    -32000,
+		Message: "Split error",
+		Data:    map[string]any{"error": err.Error()},
+	}
+}
+
+func newMCPServerError(err error) error {
+	return jsonrpc2.Error{
+		Code:    -32000,
+		Message: "MCP server error",
+		Data:    map[string]any{"error": err.Error()},
+	}
+}
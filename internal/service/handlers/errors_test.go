@@ -0,0 +1,39 @@
+package handlers
+
+import (
+	"errors"
+	"fmt"
+	"testing"
+
+	"github.com/umk/jsonrpc2"
+	"github.com/umk/llmservices/pkg/client"
+)
+
+func TestBudgetError(t *testing.T) {
+	budgetErr := &client.BudgetError{Budget: "daily tokens", Limit: 100, Used: 120}
+
+	tests := []struct {
+		name string
+		err  error
+		want int
+	}{
+		{"budget", budgetErr, -32001},
+		{"wrapped budget", fmt.Errorf("all clients failed: %w", budgetErr), -32001},
+		{"other", errors.New("something went wrong"), -32000},
+	}
+
+	for _, tt := range tests {
+		for name, newError := range map[string]func(error) error{
+			"completion": newCompletionError,
+			"embeddings": newEmbeddingsError,
+		} {
+			var rpcErr jsonrpc2.Error
+			if !errors.As(newError(tt.err), &rpcErr) {
+				t.Fatalf("%s/%s: got no RPC error", tt.name, name)
+			}
+			if rpcErr.Code != tt.want {
+				t.Errorf("%s/%s: got code %d, want %d", tt.name, name, rpcErr.Code, tt.want)
+			}
+		}
+	}
+}
@@ -0,0 +1,63 @@
+package handlers
+
+import (
+	"context"
+	"sync"
+
+	"github.com/umk/llmservices/internal/auth"
+	"github.com/umk/llmservices/pkg/mcp"
+	"github.com/umk/llmservices/pkg/tools"
+)
+
+var globalMCPServers sync.Map
+
+func GetMCPServer(ctx context.Context, serverID string) (*mcp.Client, error) {
+	// Only the servers of the config file are available, since a session
+	// mustn't start processes on the host. The servers are shared by the
+	// sessions, so access to them is restricted according to the principal
+	// of the session.
+	p, err := auth.GetPrincipal(ctx)
+	if err != nil {
+		return nil, NewAuthError(err)
+	}
+
+	if err := p.CheckMCPServer(serverID); err != nil {
+		return nil, NewAuthError(err)
+	}
+
+	if v, ok := globalMCPServers.Load(serverID); ok {
+		return v.(*mcp.Client), nil
+	}
+
+	return nil, errMCPServerNotFound
+}
+
+// GetMCPTools returns the tools of the MCP servers to be executed by the
+// service for a single request.
+func GetMCPTools(ctx context.Context, serverIDs []string) (*tools.Registry, error) {
+	r := tools.NewRegistry()
+
+	for _, id := range serverIDs {
+		s, err := GetMCPServer(ctx, id)
+		if err != nil {
+			return nil, err
+		}
+
+		t, err := s.Tools(ctx)
+		if err != nil {
+			return nil, newMCPServerError(err)
+		}
+
+		for _, t := range t {
+			if err := r.Register(t); err != nil {
+				return nil, newMCPServerError(err)
+			}
+		}
+	}
+
+	return r, nil
+}
+
+func SetGlobalMCPServer(serverID string, server *mcp.Client) {
+	globalMCPServers.Store(serverID, server)
+}
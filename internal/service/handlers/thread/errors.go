@@ -1,6 +1,12 @@
 package thread
 
-import "github.com/umk/jsonrpc2"
+import (
+	"errors"
+
+	"github.com/umk/jsonrpc2"
+	"github.com/umk/llmservices/internal/service/handlers"
+	"github.com/umk/llmservices/pkg/client"
+)
 
 var errSummarizerParams = jsonrpc2.Error{
 	Code:    -32000,
@@ -8,6 +14,10 @@ var errSummarizerParams = jsonrpc2.Error{
 }
 
 func newResponseError(err error) error {
+	if errors.Is(err, client.ErrBudgetExceeded) {
+		return handlers.NewBudgetError(err)
+	}
+
 	return jsonrpc2.Error{
 		Code:    -32000,
 		Message: "Response error",
@@ -16,6 +26,10 @@ func newResponseError(err error) error {
 }
 
 func newCompletionError(err error) error {
+	if errors.Is(err, client.ErrBudgetExceeded) {
+		return handlers.NewBudgetError(err)
+	}
+
 	return jsonrpc2.Error{
 		Code:    -32000,
 		Message: "Completion error",
@@ -24,9 +38,21 @@ func newCompletionError(err error) error {
 }
 
 func newSummarizerError(err error) error {
+	if errors.Is(err, client.ErrBudgetExceeded) {
+		return handlers.NewBudgetError(err)
+	}
+
 	return jsonrpc2.Error{
 		Code:    -32000,
 		Message: "Summarizer error",
 		Data:    map[string]any{"error": err.Error()},
 	}
 }
+
+func newToolsError(err error) error {
+	return jsonrpc2.Error{
+		Code:    -32000,
+		Message: "Tools error",
+		Data:    map[string]any{"error": err.Error()},
+	}
+}
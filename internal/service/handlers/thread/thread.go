@@ -5,6 +5,10 @@ import (
 
 	"github.com/umk/jsonrpc2"
 	"github.com/umk/llmservices/internal/service/callbacks"
+	"github.com/umk/llmservices/internal/service/handlers"
+	"github.com/umk/llmservices/internal/service/handlers/documents"
+	"github.com/umk/llmservices/pkg/adapter"
+	"github.com/umk/llmservices/pkg/client"
 	"github.com/umk/llmservices/pkg/client/thread"
 )
 
@@ -19,7 +23,39 @@ func GetResponseRPC(ctx context.Context, c jsonrpc2.RPCContext) (any, error) {
 		return nil, err
 	}
 
-	req.Params.Handler = callbacks.Callback{}
+	if req.NoCache {
+		ctx = client.ContextWithoutCache(ctx)
+	}
+
+	cb, err := callbacks.New(c)
+	if err != nil {
+		return nil, err
+	}
+
+	if len(req.ServerTools) > 0 {
+		r, err := callbacks.Tools(req.ServerTools...)
+		if err != nil {
+			return nil, newToolsError(err)
+		}
+		if req.Params.Tools, err = cb.AddTools(req.Params.Tools, r); err != nil {
+			return nil, newToolsError(err)
+		}
+	}
+
+	if len(req.MCPServers) > 0 {
+		r, err := handlers.GetMCPTools(ctx, req.MCPServers)
+		if err != nil {
+			return nil, err
+		}
+		if req.Params.Tools, err = cb.AddTools(req.Params.Tools, r); err != nil {
+			return nil, newToolsError(err)
+		}
+	}
+
+	req.Params.Handler = cb
+	if req.Retrieval != nil {
+		req.Params.Retriever = documents.NewRetriever(*req.Retrieval)
+	}
 
 	resp, err := cl.Response(ctx, req.Thread, req.Params)
 	if err != nil {
@@ -42,7 +78,20 @@ func GetCompletionRPC(ctx context.Context, c jsonrpc2.RPCContext) (any, error) {
 		return nil, err
 	}
 
-	resp, err := cl.Completion(ctx, req.Thread, req.Params)
+	if req.NoCache {
+		ctx = client.ContextWithoutCache(ctx)
+	}
+
+	var delta adapter.DeltaHandler
+	if req.Stream {
+		cb, err := callbacks.New(c)
+		if err != nil {
+			return nil, err
+		}
+		delta = cb.Delta
+	}
+
+	resp, err := cl.CompletionStream(ctx, req.Thread, req.Params, delta)
 	if err != nil {
 		return nil, newCompletionError(err)
 	}
@@ -1,6 +1,7 @@
 package thread
 
 import (
+	"github.com/umk/llmservices/internal/service/handlers/documents"
 	"github.com/umk/llmservices/pkg/adapter"
 	"github.com/umk/llmservices/pkg/client/thread"
 )
@@ -11,6 +12,18 @@ type GetResponseRequest struct {
 	ClientID string                `json:"client_id" validate:"required"`
 	Thread   thread.Thread         `json:"thread"`
 	Params   thread.ResponseParams `json:"params"`
+	// If specified, the documents relevant to the last user message are
+	// retrieved from the index and added to the thread.
+	Retrieval *documents.SearchParams `json:"retrieval,omitempty"`
+	// Whether to bypass the cache of the client, if it caches responses.
+	NoCache bool `json:"no_cache"`
+	// Names of the tools executed by the service to offer to the model in
+	// addition to the tools of the request.
+	ServerTools []string `json:"server_tools,omitempty"`
+	// IDs of the MCP servers, whose tools are offered to the model in
+	// addition to the tools of the request. The names of all of the tools
+	// offered must be different.
+	MCPServers []string `json:"mcp_servers,omitempty"`
 }
 
 type GetResponseResponse struct {
@@ -23,6 +36,10 @@ type GetCompletionRequest struct {
 	ClientID string                   `json:"client_id" validate:"required"`
 	Thread   thread.Thread            `json:"thread"`
 	Params   adapter.CompletionParams `json:"params"`
+	// Whether to push the chunks of completion as notifications.
+	Stream bool `json:"stream"`
+	// Whether to bypass the cache of the client, if it caches responses.
+	NoCache bool `json:"no_cache"`
 }
 
 type GetCompletionResponse struct {
@@ -0,0 +1,86 @@
+// Package tracing exports the spans of the service, which are made by the
+// handlers and the clients with the global tracer provider.
+package tracing
+
+import (
+	"context"
+	"errors"
+
+	"github.com/umk/jsonrpc2"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/exporters/otlp/otlptrace/otlptracehttp"
+	"go.opentelemetry.io/otel/propagation"
+	"go.opentelemetry.io/otel/sdk/resource"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	semconv "go.opentelemetry.io/otel/semconv/v1.30.0"
+	"go.opentelemetry.io/otel/trace"
+)
+
+var tracer = otel.Tracer("github.com/umk/llmservices/internal/tracing")
+
+// Start sets the global tracer provider to pass the spans to the processor.
+// In tests, the processor can be sdktrace.NewSimpleSpanProcessor of
+// tracetest.InMemoryExporter, so that the spans are exported as soon as
+// they end. The returned function exports the remaining spans and stops the
+// provider.
+func Start(sp sdktrace.SpanProcessor) func(ctx context.Context) error {
+	tp := sdktrace.NewTracerProvider(
+		sdktrace.WithSpanProcessor(sp),
+		sdktrace.WithResource(resource.NewSchemaless(semconv.ServiceName("llmservices"))),
+	)
+
+	otel.SetTracerProvider(tp)
+	otel.SetTextMapPropagator(propagation.TraceContext{})
+
+	return tp.Shutdown
+}
+
+// StartOTLP starts exporting the spans to the OTLP/HTTP endpoint like
+// http://localhost:4318.
+func StartOTLP(ctx context.Context, endpoint string) (func(ctx context.Context) error, error) {
+	exporter, err := otlptracehttp.New(ctx, otlptracehttp.WithEndpointURL(endpoint))
+	if err != nil {
+		return nil, err
+	}
+
+	return Start(sdktrace.NewBatchSpanProcessor(exporter)), nil
+}
+
+// Method wraps the handler of the method to make a span for each call.
+func Method(method string, fn jsonrpc2.HandlerFunc) jsonrpc2.HandlerFunc {
+	return func(ctx context.Context, c jsonrpc2.RPCContext) (any, error) {
+		ctx, span := tracer.Start(ctx, method,
+			trace.WithSpanKind(trace.SpanKindServer),
+			trace.WithAttributes(
+				semconv.RPCSystemKey.String("jsonrpc"),
+				semconv.RPCMethod(method),
+				semconv.RPCJsonrpcVersion("2.0"),
+			))
+		defer span.End()
+
+		resp, err := fn(ctx, c)
+		if err != nil {
+			if code, ok := getErrorCode(err); ok {
+				span.SetAttributes(semconv.RPCJsonrpcErrorCode(code))
+			}
+			span.SetStatus(codes.Error, err.Error())
+		}
+
+		return resp, err
+	}
+}
+
+func getErrorCode(err error) (int, bool) {
+	var rpcErr jsonrpc2.Error
+	if errors.As(err, &rpcErr) {
+		return rpcErr.Code, true
+	}
+
+	var rpcErrer interface{ RPCError() jsonrpc2.Error }
+	if errors.As(err, &rpcErrer) {
+		return rpcErrer.RPCError().Code, true
+	}
+
+	return 0, false
+}
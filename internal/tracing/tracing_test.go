@@ -0,0 +1,129 @@
+package tracing
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/umk/jsonrpc2"
+	"github.com/umk/llmservices/pkg/adapter"
+	"github.com/umk/llmservices/pkg/client"
+	"github.com/umk/llmservices/pkg/client/thread"
+	"go.opentelemetry.io/otel/attribute"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/sdk/trace/tracetest"
+	semconv "go.opentelemetry.io/otel/semconv/v1.30.0"
+	"go.opentelemetry.io/otel/trace"
+)
+
+// rpcContext is the context of a call without the parameters.
+type rpcContext struct{}
+
+func (rpcContext) ID(v any) error              { return nil }
+func (rpcContext) Request(v any) error         { return nil }
+func (rpcContext) Response(v any) (any, error) { return v, nil }
+
+// newCompletionServer serves the chat completions of the OpenAI API, which
+// always answer with the same text.
+func newCompletionServer(t *testing.T) *httptest.Server {
+	t.Helper()
+
+	s := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		json.NewEncoder(w).Encode(map[string]any{
+			"id":      "chatcmpl-1",
+			"object":  "chat.completion",
+			"created": 0,
+			"model":   "test-model",
+			"choices": []any{map[string]any{
+				"index":         0,
+				"message":       map[string]any{"role": "assistant", "content": "Hello!"},
+				"finish_reason": "stop",
+			}},
+			"usage": map[string]any{"prompt_tokens": 10, "completion_tokens": 3, "total_tokens": 13},
+		})
+	}))
+	t.Cleanup(s.Close)
+
+	return s
+}
+
+func getAttribute(s sdktrace.ReadOnlySpan, key attribute.Key) (attribute.Value, bool) {
+	for _, a := range s.Attributes() {
+		if a.Key == key {
+			return a.Value, true
+		}
+	}
+	return attribute.Value{}, false
+}
+
+func TestSpans(t *testing.T) {
+	exporter := tracetest.NewInMemoryExporter()
+	stop := Start(sdktrace.NewSimpleSpanProcessor(exporter))
+	t.Cleanup(func() { stop(context.Background()) })
+
+	s := newCompletionServer(t)
+
+	c, err := client.New(&client.Config{BaseURL: s.URL, Key: "key", Model: "test-model"}, client.WithName("test"))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	fn := Method("getResponse", func(ctx context.Context, _ jsonrpc2.RPCContext) (any, error) {
+		return (*thread.Client)(c).Response(ctx, thread.Thread{
+			Frames: []thread.MessagesFrame{{Messages: []adapter.Message{adapter.CreateUserMessage("Hi!")}}},
+		}, thread.ResponseParams{Iterations: 1})
+	})
+
+	if _, err := fn(context.Background(), rpcContext{}); err != nil {
+		t.Fatal(err)
+	}
+
+	spans := make(map[string]sdktrace.ReadOnlySpan)
+	for _, s := range exporter.GetSpans().Snapshots() {
+		spans[s.Name()] = s
+	}
+
+	rpc, ok := spans["getResponse"]
+	if !ok {
+		t.Fatalf("no span of the method in %v", spans)
+	}
+	iteration, ok := spans["thread iteration"]
+	if !ok {
+		t.Fatalf("no span of the iteration in %v", spans)
+	}
+	chat, ok := spans["chat test-model"]
+	if !ok {
+		t.Fatalf("no span of the completion in %v", spans)
+	}
+
+	if rpc.SpanKind() != trace.SpanKindServer || rpc.Parent().IsValid() {
+		t.Errorf("span of the method has kind %v and parent %v, want a root server span", rpc.SpanKind(), rpc.Parent())
+	}
+	if iteration.Parent().SpanID() != rpc.SpanContext().SpanID() {
+		t.Error("span of the iteration isn't a child of the span of the method")
+	}
+	if chat.Parent().SpanID() != iteration.SpanContext().SpanID() {
+		t.Error("span of the completion isn't a child of the span of the iteration")
+	}
+	if chat.SpanKind() != trace.SpanKindClient {
+		t.Errorf("span of the completion has kind %v, want client", chat.SpanKind())
+	}
+
+	want := []attribute.KeyValue{
+		semconv.GenAIOperationNameChat,
+		semconv.GenAIRequestModel("test-model"),
+		semconv.GenAISystemKey.String(string(client.OpenAI)),
+		semconv.GenAIUsageInputTokens(10),
+		semconv.GenAIUsageOutputTokens(3),
+		semconv.GenAIResponseFinishReasons("stop"),
+	}
+	for _, a := range want {
+		v, ok := getAttribute(chat, a.Key)
+		if !ok || v.Emit() != a.Value.Emit() {
+			t.Errorf("attribute %s = %s, want %s", a.Key, v.Emit(), a.Value.Emit())
+		}
+	}
+}
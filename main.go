@@ -3,12 +3,19 @@ package main
 import (
 	"context"
 	"log"
+	"time"
 
 	"github.com/umk/jsonrpc2"
 	"github.com/umk/llmservices/internal/config"
+	"github.com/umk/llmservices/internal/tracing"
 	"github.com/umk/llmservices/pkg/adapter"
 )
 
+const (
+	budgetsSaveInterval = 10 * time.Second
+	tracingStopTimeout  = 5 * time.Second
+)
+
 func main() {
 	adapter.InitValidator(jsonrpc2.Val)
 
@@ -16,7 +23,37 @@ func main() {
 		log.Fatalln("Init error:", err)
 	}
 
-	if err := Serve(context.Background()); err != nil {
+	ctx, cancel := context.WithCancel(context.Background())
+
+	stopTracing := func(context.Context) error { return nil }
+	if config.Cur.OTLP != "" {
+		var err error
+		if stopTracing, err = tracing.StartOTLP(ctx, config.Cur.OTLP); err != nil {
+			log.Fatalln("Error starting tracing:", err)
+		}
+	}
+
+	saved := make(chan error, 1)
+	go func() {
+		saved <- config.Budgets.Run(ctx, budgetsSaveInterval)
+	}()
+
+	err := Serve(ctx)
+
+	cancel()
+	if err := <-saved; err != nil {
+		log.Println("Error saving budgets:", err)
+	}
+
+	// Export the spans that haven't been exported yet.
+	stopCtx, stopCancel := context.WithTimeout(context.Background(), tracingStopTimeout)
+	defer stopCancel()
+
+	if err := stopTracing(stopCtx); err != nil {
+		log.Println("Error exporting traces:", err)
+	}
+
+	if err != nil {
 		log.Fatalln("Error running server:", err)
 	}
 }
@@ -4,5 +4,11 @@ import "context"
 
 type Adapter interface {
 	Completion(ctx context.Context, messages []Message, params CompletionParams) (Completion, error)
-	Embeddings(ctx context.Context, input string, params EmbeddingsParams) (Embeddings, error)
+	Embeddings(ctx context.Context, input []string, params EmbeddingsParams) (Embeddings, error)
+}
+
+// CompletionStreamer is implemented by adapters that can deliver a completion
+// incrementally while it is being generated.
+type CompletionStreamer interface {
+	CompletionStream(ctx context.Context, messages []Message, params CompletionParams, handler DeltaHandler) (Completion, error)
 }
@@ -0,0 +1,27 @@
+package anthropic
+
+import "net/http"
+
+// Version of the Anthropic API the adapter is implemented against.
+const apiVersion = "2023-06-01"
+
+// Maximum number of tokens to generate, if not specified in parameters.
+// The Messages API requires the limit to be always set.
+const defaultMaxTokens = 4096
+
+type Adapter struct {
+	// Base URL of the API, like https://api.anthropic.com/v1/
+	BaseURL string
+	Key     string
+	// HTTP client to send requests with. If nil, the default client is used.
+	Client *http.Client
+}
+
+func (c *Adapter) header() http.Header {
+	h := make(http.Header)
+	h.Set("anthropic-version", apiVersion)
+	if c.Key != "" {
+		h.Set("x-api-key", c.Key)
+	}
+	return h
+}
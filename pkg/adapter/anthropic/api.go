@@ -0,0 +1,95 @@
+package anthropic
+
+import "encoding/json"
+
+type messagesRequest struct {
+	Model         string         `json:"model"`
+	MaxTokens     int64          `json:"max_tokens"`
+	System        string         `json:"system,omitempty"`
+	Messages      []messageParam `json:"messages"`
+	Tools         []toolParam    `json:"tools,omitempty"`
+	StopSequences []string       `json:"stop_sequences,omitempty"`
+	Temperature   *float64       `json:"temperature,omitempty"`
+	TopP          *float64       `json:"top_p,omitempty"`
+	Stream        bool           `json:"stream,omitempty"`
+}
+
+type messagesResponse struct {
+	Content    []contentBlock `json:"content"`
+	StopReason string         `json:"stop_reason"`
+	Usage      usage          `json:"usage"`
+}
+
+type messageParam struct {
+	Role    string         `json:"role"`
+	Content []contentBlock `json:"content"`
+}
+
+type contentBlock struct {
+	Type string `json:"type"`
+
+	// Text block
+	Text string `json:"text,omitempty"`
+
+	// Image block
+	Source *imageSource `json:"source,omitempty"`
+
+	// Tool use block
+	ID    string          `json:"id,omitempty"`
+	Name  string          `json:"name,omitempty"`
+	Input json.RawMessage `json:"input,omitempty"`
+
+	// Tool result block
+	ToolUseID string         `json:"tool_use_id,omitempty"`
+	Content   []contentBlock `json:"content,omitempty"`
+}
+
+type imageSource struct {
+	Type      string `json:"type"`
+	MediaType string `json:"media_type,omitempty"`
+	Data      string `json:"data,omitempty"`
+	URL       string `json:"url,omitempty"`
+}
+
+type toolParam struct {
+	Name        string         `json:"name"`
+	Description string         `json:"description,omitempty"`
+	InputSchema map[string]any `json:"input_schema"`
+}
+
+type usage struct {
+	InputTokens  int64 `json:"input_tokens"`
+	OutputTokens int64 `json:"output_tokens"`
+}
+
+// streamEvent is an event of the streamed response, which fields are set
+// depending on its type.
+type streamEvent struct {
+	Type string `json:"type"`
+
+	// Message start event
+	Message *messagesResponse `json:"message,omitempty"`
+
+	// Content block events
+	Index        int           `json:"index"`
+	ContentBlock *contentBlock `json:"content_block,omitempty"`
+
+	// Content block delta and message delta events
+	Delta *streamDelta `json:"delta,omitempty"`
+	Usage *usage       `json:"usage,omitempty"`
+
+	// Error event
+	Error *apiError `json:"error,omitempty"`
+}
+
+type streamDelta struct {
+	Type        string `json:"type"`
+	Text        string `json:"text,omitempty"`
+	PartialJSON string `json:"partial_json,omitempty"`
+	StopReason  string `json:"stop_reason,omitempty"`
+}
+
+type apiError struct {
+	Type    string `json:"type"`
+	Message string `json:"message"`
+}
@@ -0,0 +1,278 @@
+package anthropic
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strings"
+
+	"github.com/umk/llmservices/internal/rest"
+	"github.com/umk/llmservices/pkg/adapter"
+)
+
+func (c *Adapter) Completion(ctx context.Context, messages []adapter.Message, params adapter.CompletionParams) (adapter.Completion, error) {
+	p, err := getCompletionParams(messages, params)
+	if err != nil {
+		return adapter.Completion{}, err
+	}
+
+	var resp messagesResponse
+	if err := rest.Post(ctx, c.Client, rest.URL(c.BaseURL, "messages"), c.header(), p, &resp); err != nil {
+		return adapter.Completion{}, err
+	}
+
+	return getCompletionResponse(&resp)
+}
+
+func (c *Adapter) CompletionStream(ctx context.Context, messages []adapter.Message, params adapter.CompletionParams, handler adapter.DeltaHandler) (adapter.Completion, error) {
+	p, err := getCompletionParams(messages, params)
+	if err != nil {
+		return adapter.Completion{}, err
+	}
+	p.Stream = true
+
+	r, err := rest.Send(ctx, c.Client, rest.URL(c.BaseURL, "messages"), c.header(), p)
+	if err != nil {
+		return adapter.Completion{}, err
+	}
+	defer r.Body.Close()
+
+	// Response is a sequence of server-sent events, which build the content
+	// blocks of the message one by one.
+	s := streamState{calls: make(map[int]int)}
+
+	sc := bufio.NewScanner(r.Body)
+	sc.Buffer(nil, 1024*1024)
+	for sc.Scan() {
+		data, ok := strings.CutPrefix(sc.Text(), "data:")
+		if !ok {
+			continue
+		}
+
+		var e streamEvent
+		if err := json.Unmarshal([]byte(strings.TrimSpace(data)), &e); err != nil {
+			return adapter.Completion{}, fmt.Errorf("failed to decode response: %w", err)
+		}
+
+		delta, err := s.add(&e)
+		if err != nil {
+			return adapter.Completion{}, err
+		}
+		if delta == nil {
+			continue
+		}
+
+		if err := handler(ctx, *delta); err != nil {
+			return adapter.Completion{}, err
+		}
+	}
+	if err := sc.Err(); err != nil {
+		return adapter.Completion{}, err
+	}
+	if !s.done {
+		return adapter.Completion{}, errors.New("response ended unexpectedly")
+	}
+
+	return getCompletionResponse(&s.resp)
+}
+
+// streamState accumulates the events of the streamed response.
+type streamState struct {
+	resp messagesResponse
+	// Indexes of the tool calls by the indexes of their content blocks.
+	calls map[int]int
+	done  bool
+}
+
+// add applies the event to the response, and returns the delta to pass to
+// the handler, if any.
+func (s *streamState) add(e *streamEvent) (*adapter.CompletionDelta, error) {
+	switch e.Type {
+	case "message_start":
+		if e.Message != nil {
+			s.resp.Usage.InputTokens = e.Message.Usage.InputTokens
+		}
+	case "content_block_start":
+		if e.ContentBlock == nil || e.Index != len(s.resp.Content) {
+			return nil, fmt.Errorf("unexpected content block: %d", e.Index)
+		}
+
+		block := *e.ContentBlock
+		// The input of a tool call is streamed in parts.
+		block.Input = nil
+		s.resp.Content = append(s.resp.Content, block)
+
+		if block.Type == "tool_use" {
+			i := len(s.calls)
+			s.calls[e.Index] = i
+			return &adapter.CompletionDelta{
+				ToolCalls: []adapter.ToolCallDelta{{
+					Index:    i,
+					ID:       block.ID,
+					Function: adapter.ToolCallFunctionDelta{Name: block.Name},
+				}},
+			}, nil
+		}
+	case "content_block_delta":
+		if e.Delta == nil || e.Index < 0 || e.Index >= len(s.resp.Content) {
+			return nil, fmt.Errorf("unexpected content block: %d", e.Index)
+		}
+
+		block := &s.resp.Content[e.Index]
+
+		switch e.Delta.Type {
+		case "text_delta":
+			block.Text += e.Delta.Text
+			text := e.Delta.Text
+			return &adapter.CompletionDelta{Content: &text}, nil
+		case "input_json_delta":
+			block.Input = append(block.Input, e.Delta.PartialJSON...)
+			return &adapter.CompletionDelta{
+				ToolCalls: []adapter.ToolCallDelta{{
+					Index:    s.calls[e.Index],
+					Function: adapter.ToolCallFunctionDelta{Arguments: e.Delta.PartialJSON},
+				}},
+			}, nil
+		}
+	case "message_delta":
+		if e.Delta != nil {
+			s.resp.StopReason = e.Delta.StopReason
+		}
+		if e.Usage != nil {
+			s.resp.Usage.OutputTokens = e.Usage.OutputTokens
+			return &adapter.CompletionDelta{
+				Usage: &adapter.CompletionUsage{
+					PromptTokens:     s.resp.Usage.InputTokens,
+					CompletionTokens: s.resp.Usage.OutputTokens,
+				},
+			}, nil
+		}
+	case "message_stop":
+		s.done = true
+	case "error":
+		if e.Error == nil {
+			return nil, errors.New("response failed")
+		}
+		return nil, fmt.Errorf("response failed: %s: %s", e.Error.Type, e.Error.Message)
+	}
+
+	return nil, nil
+}
+
+func getCompletionParams(messages []adapter.Message, params adapter.CompletionParams) (messagesRequest, error) {
+	if params.ResponseFormat != nil && params.ResponseFormat.OfResponseFormatJSONSchema != nil {
+		return messagesRequest{}, fmt.Errorf("structured output: %w", adapter.ErrNotSupported)
+	}
+
+	r := messagesRequest{
+		Model:         params.Model,
+		MaxTokens:     defaultMaxTokens,
+		StopSequences: params.Stop,
+		Temperature:   params.Temperature,
+		TopP:          params.TopP,
+	}
+	if params.MaxTokens != nil {
+		r.MaxTokens = *params.MaxTokens
+	}
+
+	var system []string
+	for _, message := range messages {
+		if message.OfSystemMessage != nil {
+			system = append(system, message.OfSystemMessage.Content)
+			continue
+		}
+
+		m, err := getMessage(&message)
+		if err != nil {
+			return messagesRequest{}, err
+		}
+
+		// The API expects the roles to alternate, so the content of
+		// consecutive messages of the same role is combined, which is
+		// also the case for multiple results of tool calls.
+		if n := len(r.Messages); n > 0 && r.Messages[n-1].Role == m.Role {
+			r.Messages[n-1].Content = append(r.Messages[n-1].Content, m.Content...)
+		} else {
+			r.Messages = append(r.Messages, m)
+		}
+	}
+	r.System = strings.Join(system, "\n\n")
+
+	for _, tool := range params.Tools {
+		r.Tools = append(r.Tools, getTool(&tool))
+	}
+
+	return r, nil
+}
+
+func getCompletionResponse(resp *messagesResponse) (adapter.Completion, error) {
+	var text []string
+
+	result := adapter.Completion{
+		FinishReason: resp.StopReason,
+		Usage: &adapter.CompletionUsage{
+			PromptTokens:     resp.Usage.InputTokens,
+			CompletionTokens: resp.Usage.OutputTokens,
+		},
+	}
+
+	for _, block := range resp.Content {
+		switch block.Type {
+		case "text":
+			text = append(text, block.Text)
+		case "tool_use":
+			args := string(block.Input)
+			if args == "" {
+				args = "{}"
+			}
+			result.Message.ToolCalls = append(result.Message.ToolCalls, adapter.ToolCall{
+				ID: block.ID,
+				Function: adapter.ToolCallFunction{
+					Name:      block.Name,
+					Arguments: args,
+				},
+			})
+		}
+	}
+
+	if len(text) > 0 {
+		content := strings.Join(text, "")
+		if resp.StopReason == "refusal" {
+			result.Message.Refusal = &content
+		} else {
+			result.Message.Content = &content
+		}
+	}
+
+	return result, nil
+}
+
+func getTool(tool *adapter.Tool) toolParam {
+	// The API requires the schema of the input, even if the tool has no
+	// parameters.
+	schema := tool.Function.Parameters
+	if schema == nil {
+		schema = map[string]any{"type": "object"}
+	}
+
+	t := toolParam{
+		Name:        tool.Function.Name,
+		InputSchema: schema,
+	}
+	if tool.Function.Description != nil {
+		t.Description = *tool.Function.Description
+	}
+	return t
+}
+
+func getToolInput(arguments string) (json.RawMessage, error) {
+	if strings.TrimSpace(arguments) == "" {
+		return json.RawMessage("{}"), nil
+	}
+	if !json.Valid([]byte(arguments)) {
+		return nil, fmt.Errorf("tool call arguments are not a valid JSON: %s", arguments)
+	}
+	return json.RawMessage(arguments), nil
+}
@@ -0,0 +1,325 @@
+package anthropic
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/umk/llmservices/pkg/adapter"
+)
+
+// newServer starts a stand-in of the Messages API, which records the request
+// and responds with the status and the body given.
+func newServer(t *testing.T, status int, contentType, body string) (*Adapter, *http.Request, *map[string]any) {
+	t.Helper()
+
+	var (
+		req     http.Request
+		reqBody map[string]any
+	)
+
+	s := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		req = *r
+
+		b, _ := io.ReadAll(r.Body)
+		if err := json.Unmarshal(b, &reqBody); err != nil {
+			t.Errorf("request is not a JSON object: %s", b)
+		}
+
+		w.Header().Set("Content-Type", contentType)
+		w.WriteHeader(status)
+		io.WriteString(w, body)
+	}))
+	t.Cleanup(s.Close)
+
+	return &Adapter{BaseURL: s.URL + "/v1/", Key: "key"}, &req, &reqBody
+}
+
+func ptr[T any](v T) *T {
+	return &v
+}
+
+func TestCompletionRequest(t *testing.T) {
+	a, req, body := newServer(t, http.StatusOK, "application/json", `{
+		"content": [
+			{"type": "text", "text": "Let me check."},
+			{"type": "tool_use", "id": "toolu_2", "name": "get_time", "input": {"zone": "UTC"}}
+		],
+		"stop_reason": "tool_use",
+		"usage": {"input_tokens": 12, "output_tokens": 5}
+	}`)
+
+	messages := []adapter.Message{
+		{OfSystemMessage: &adapter.SystemMessage{Content: "Be brief."}},
+		{OfSystemMessage: &adapter.SystemMessage{Content: "Use tools."}},
+		adapter.CreateUserMessage("What time is it?"),
+		{OfAssistantMessage: &adapter.AssistantMessage{
+			ToolCalls: []adapter.ToolCall{
+				{ID: "toolu_0", Function: adapter.ToolCallFunction{Name: "get_time", Arguments: `{"zone":"CET"}`}},
+				{ID: "toolu_1", Function: adapter.ToolCallFunction{Name: "get_date"}},
+			},
+		}},
+		{OfToolMessage: &adapter.ToolMessage{ToolCallID: "toolu_0", Content: []adapter.ContentPartText{{Text: "10:00"}}}},
+		{OfToolMessage: &adapter.ToolMessage{ToolCallID: "toolu_1", Content: []adapter.ContentPartText{{Text: "Monday"}}}},
+	}
+
+	resp, err := a.Completion(context.Background(), messages, adapter.CompletionParams{
+		Model:       "claude",
+		Temperature: ptr(0.5),
+		Stop:        []string{"END"},
+		Tools: []adapter.Tool{
+			{Function: adapter.ToolFunction{
+				Name:        "get_time",
+				Description: ptr("Gets the time."),
+				Parameters:  map[string]any{"type": "object", "properties": map[string]any{"zone": map[string]any{"type": "string"}}},
+			}},
+			{Function: adapter.ToolFunction{Name: "get_date"}},
+		},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if req.URL.Path != "/v1/messages" {
+		t.Errorf("path = %s, want /v1/messages", req.URL.Path)
+	}
+	if v := req.Header.Get("x-api-key"); v != "key" {
+		t.Errorf("x-api-key = %q, want key", v)
+	}
+	if v := req.Header.Get("anthropic-version"); v != apiVersion {
+		t.Errorf("anthropic-version = %q, want %s", v, apiVersion)
+	}
+
+	var want map[string]any
+	if err := json.Unmarshal([]byte(`{
+		"model": "claude",
+		"max_tokens": 4096,
+		"system": "Be brief.\n\nUse tools.",
+		"temperature": 0.5,
+		"stop_sequences": ["END"],
+		"messages": [
+			{"role": "user", "content": [{"type": "text", "text": "What time is it?"}]},
+			{"role": "assistant", "content": [
+				{"type": "tool_use", "id": "toolu_0", "name": "get_time", "input": {"zone": "CET"}},
+				{"type": "tool_use", "id": "toolu_1", "name": "get_date", "input": {}}
+			]},
+			{"role": "user", "content": [
+				{"type": "tool_result", "tool_use_id": "toolu_0", "content": [{"type": "text", "text": "10:00"}]},
+				{"type": "tool_result", "tool_use_id": "toolu_1", "content": [{"type": "text", "text": "Monday"}]}
+			]}
+		],
+		"tools": [
+			{"name": "get_time", "description": "Gets the time.", "input_schema": {"type": "object", "properties": {"zone": {"type": "string"}}}},
+			{"name": "get_date", "input_schema": {"type": "object"}}
+		]
+	}`), &want); err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(*body, want) {
+		got, _ := json.MarshalIndent(*body, "", "  ")
+		t.Errorf("request body:\n%s", got)
+	}
+
+	wantResp := adapter.Completion{
+		Message: adapter.AssistantMessage{
+			Content: ptr("Let me check."),
+			ToolCalls: []adapter.ToolCall{
+				{ID: "toolu_2", Function: adapter.ToolCallFunction{Name: "get_time", Arguments: `{"zone": "UTC"}`}},
+			},
+		},
+		FinishReason: "tool_use",
+		Usage:        &adapter.CompletionUsage{PromptTokens: 12, CompletionTokens: 5},
+	}
+	if !reflect.DeepEqual(resp, wantResp) {
+		t.Errorf("response = %+v, want %+v", resp, wantResp)
+	}
+}
+
+func TestCompletionRefusal(t *testing.T) {
+	a, _, _ := newServer(t, http.StatusOK, "application/json", `{
+		"content": [{"type": "text", "text": "I can't help with that."}],
+		"stop_reason": "refusal",
+		"usage": {"input_tokens": 1, "output_tokens": 1}
+	}`)
+
+	resp, err := a.Completion(context.Background(), []adapter.Message{adapter.CreateUserMessage("Hi")}, adapter.CompletionParams{Model: "claude"})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if resp.Message.Content != nil || resp.Message.Refusal == nil || *resp.Message.Refusal != "I can't help with that." {
+		t.Errorf("message = %+v, want the refusal", resp.Message)
+	}
+}
+
+func TestCompletionInvalidRequest(t *testing.T) {
+	a := &Adapter{BaseURL: "http://127.0.0.1:0/"}
+
+	tests := []struct {
+		name     string
+		messages []adapter.Message
+		params   adapter.CompletionParams
+	}{
+		{
+			name:     "empty content part",
+			messages: []adapter.Message{{OfUserMessage: &adapter.UserMessage{Parts: []adapter.ContentPart{{}}}}},
+		},
+		{
+			name:     "empty message",
+			messages: []adapter.Message{{}},
+		},
+		{
+			name: "invalid arguments",
+			messages: []adapter.Message{{OfAssistantMessage: &adapter.AssistantMessage{
+				ToolCalls: []adapter.ToolCall{{ID: "1", Function: adapter.ToolCallFunction{Name: "f", Arguments: "{"}}},
+			}}},
+		},
+		{
+			name:     "structured output",
+			messages: []adapter.Message{adapter.CreateUserMessage("Hi")},
+			params: adapter.CompletionParams{ResponseFormat: &adapter.ResponseFormat{
+				OfResponseFormatJSONSchema: &adapter.ResponseFormatJSONSchema{},
+			}},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if _, err := a.Completion(context.Background(), tt.messages, tt.params); err == nil {
+				t.Error("Completion() succeeded")
+			}
+		})
+	}
+}
+
+func TestCompletionError(t *testing.T) {
+	a, _, _ := newServer(t, http.StatusTooManyRequests, "application/json",
+		`{"type": "error", "error": {"type": "rate_limit_error", "message": "Rate limited"}}`)
+
+	_, err := a.Completion(context.Background(), []adapter.Message{adapter.CreateUserMessage("Hi")}, adapter.CompletionParams{Model: "claude"})
+
+	var statusErr *adapter.StatusError
+	if !errors.As(err, &statusErr) {
+		t.Fatalf("error = %v, want a status error", err)
+	}
+	if statusErr.StatusCode != http.StatusTooManyRequests || !strings.Contains(statusErr.Body, "rate_limit_error") {
+		t.Errorf("error = %v, want the status and the body of the response", statusErr)
+	}
+}
+
+func getStream(events ...string) string {
+	var b strings.Builder
+	for _, e := range events {
+		var v struct {
+			Type string `json:"type"`
+		}
+		json.Unmarshal([]byte(e), &v)
+		fmt.Fprintf(&b, "event: %s\ndata: %s\n\n", v.Type, e)
+	}
+	return b.String()
+}
+
+func TestCompletionStream(t *testing.T) {
+	a, _, body := newServer(t, http.StatusOK, "text/event-stream", getStream(
+		`{"type": "message_start", "message": {"content": [], "usage": {"input_tokens": 10, "output_tokens": 1}}}`,
+		`{"type": "content_block_start", "index": 0, "content_block": {"type": "text", "text": ""}}`,
+		`{"type": "ping"}`,
+		`{"type": "content_block_delta", "index": 0, "delta": {"type": "text_delta", "text": "Hello"}}`,
+		`{"type": "content_block_delta", "index": 0, "delta": {"type": "text_delta", "text": ", world"}}`,
+		`{"type": "content_block_stop", "index": 0}`,
+		`{"type": "content_block_start", "index": 1, "content_block": {"type": "tool_use", "id": "toolu_0", "name": "f", "input": {}}}`,
+		`{"type": "content_block_delta", "index": 1, "delta": {"type": "input_json_delta", "partial_json": "{\"a\":"}}`,
+		`{"type": "content_block_delta", "index": 1, "delta": {"type": "input_json_delta", "partial_json": " 1}"}}`,
+		`{"type": "content_block_stop", "index": 1}`,
+		`{"type": "message_delta", "delta": {"stop_reason": "tool_use"}, "usage": {"output_tokens": 7}}`,
+		`{"type": "message_stop"}`,
+	))
+
+	var deltas []adapter.CompletionDelta
+	resp, err := a.CompletionStream(context.Background(), []adapter.Message{adapter.CreateUserMessage("Hi")}, adapter.CompletionParams{Model: "claude"},
+		func(ctx context.Context, delta adapter.CompletionDelta) error {
+			deltas = append(deltas, delta)
+			return nil
+		})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if (*body)["stream"] != true {
+		t.Errorf("request body = %v, want stream to be true", *body)
+	}
+
+	wantDeltas := []adapter.CompletionDelta{
+		{Content: ptr("Hello")},
+		{Content: ptr(", world")},
+		{ToolCalls: []adapter.ToolCallDelta{{Index: 0, ID: "toolu_0", Function: adapter.ToolCallFunctionDelta{Name: "f"}}}},
+		{ToolCalls: []adapter.ToolCallDelta{{Index: 0, Function: adapter.ToolCallFunctionDelta{Arguments: `{"a":`}}}},
+		{ToolCalls: []adapter.ToolCallDelta{{Index: 0, Function: adapter.ToolCallFunctionDelta{Arguments: ` 1}`}}}},
+		{Usage: &adapter.CompletionUsage{PromptTokens: 10, CompletionTokens: 7}},
+	}
+	if !reflect.DeepEqual(deltas, wantDeltas) {
+		got, _ := json.Marshal(deltas)
+		t.Errorf("deltas = %s", got)
+	}
+
+	wantResp := adapter.Completion{
+		Message: adapter.AssistantMessage{
+			Content: ptr("Hello, world"),
+			ToolCalls: []adapter.ToolCall{
+				{ID: "toolu_0", Function: adapter.ToolCallFunction{Name: "f", Arguments: `{"a": 1}`}},
+			},
+		},
+		FinishReason: "tool_use",
+		Usage:        &adapter.CompletionUsage{PromptTokens: 10, CompletionTokens: 7},
+	}
+	if !reflect.DeepEqual(resp, wantResp) {
+		t.Errorf("response = %+v, want %+v", resp, wantResp)
+	}
+}
+
+func TestCompletionStreamError(t *testing.T) {
+	tests := []struct {
+		name   string
+		events []string
+	}{
+		{
+			name: "error event",
+			events: []string{
+				`{"type": "message_start", "message": {"content": [], "usage": {"input_tokens": 10}}}`,
+				`{"type": "error", "error": {"type": "overloaded_error", "message": "Overloaded"}}`,
+			},
+		},
+		{
+			name: "no message stop",
+			events: []string{
+				`{"type": "message_start", "message": {"content": [], "usage": {"input_tokens": 10}}}`,
+				`{"type": "content_block_start", "index": 0, "content_block": {"type": "text", "text": ""}}`,
+			},
+		},
+		{
+			name: "unknown content block",
+			events: []string{
+				`{"type": "content_block_delta", "index": 3, "delta": {"type": "text_delta", "text": "Hello"}}`,
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			a, _, _ := newServer(t, http.StatusOK, "text/event-stream", getStream(tt.events...))
+
+			_, err := a.CompletionStream(context.Background(), []adapter.Message{adapter.CreateUserMessage("Hi")}, adapter.CompletionParams{Model: "claude"},
+				func(ctx context.Context, delta adapter.CompletionDelta) error { return nil })
+			if err == nil {
+				t.Error("CompletionStream() succeeded")
+			}
+		})
+	}
+}
@@ -0,0 +1,48 @@
+package anthropic
+
+import (
+	"errors"
+
+	"github.com/umk/llmservices/pkg/adapter"
+)
+
+func getContentPart(part *adapter.ContentPart) (contentBlock, error) {
+	switch {
+	case part.OfContentPartText != nil:
+		return getTextContentPart(part), nil
+	case part.OfContentPartImageUrl != nil:
+		return getImageContentPart(part), nil
+	default:
+		return contentBlock{}, errors.New("unexpected content part type")
+	}
+}
+
+func getTextContentPart(part *adapter.ContentPart) contentBlock {
+	return contentBlock{
+		Type: "text",
+		Text: part.OfContentPartText.Text,
+	}
+}
+
+func getImageContentPart(part *adapter.ContentPart) contentBlock {
+	image := part.OfContentPartImageUrl
+
+	if mediaType, data, ok := image.Data(); ok {
+		return contentBlock{
+			Type: "image",
+			Source: &imageSource{
+				Type:      "base64",
+				MediaType: mediaType,
+				Data:      data,
+			},
+		}
+	}
+
+	return contentBlock{
+		Type: "image",
+		Source: &imageSource{
+			Type: "url",
+			URL:  image.ImageUrl,
+		},
+	}
+}
@@ -0,0 +1,13 @@
+package anthropic
+
+import (
+	"context"
+
+	"github.com/umk/llmservices/pkg/adapter"
+)
+
+// Embeddings is not supported, because Anthropic doesn't provide an
+// embeddings model of its own.
+func (c *Adapter) Embeddings(ctx context.Context, input []string, params adapter.EmbeddingsParams) (adapter.Embeddings, error) {
+	return adapter.Embeddings{}, adapter.ErrNotSupported
+}
@@ -0,0 +1,72 @@
+package anthropic
+
+import (
+	"errors"
+
+	"github.com/umk/llmservices/pkg/adapter"
+)
+
+func getMessage(message *adapter.Message) (messageParam, error) {
+	switch {
+	case message.OfUserMessage != nil:
+		return getUserMessage(message.OfUserMessage)
+	case message.OfAssistantMessage != nil:
+		return getAssistantMessage(message.OfAssistantMessage)
+	case message.OfToolMessage != nil:
+		return getToolMessage(message.OfToolMessage), nil
+	default:
+		return messageParam{}, errors.New("unexpected message type")
+	}
+}
+
+func getUserMessage(userMessage *adapter.UserMessage) (messageParam, error) {
+	result := messageParam{Role: "user"}
+	for _, part := range userMessage.Parts {
+		block, err := getContentPart(&part)
+		if err != nil {
+			return messageParam{}, err
+		}
+		result.Content = append(result.Content, block)
+	}
+	return result, nil
+}
+
+func getAssistantMessage(assistantMessage *adapter.AssistantMessage) (messageParam, error) {
+	result := messageParam{Role: "assistant"}
+	if text, err := assistantMessage.Text(); err == nil && text != "" {
+		result.Content = append(result.Content, contentBlock{
+			Type: "text",
+			Text: text,
+		})
+	}
+	for _, call := range assistantMessage.ToolCalls {
+		input, err := getToolInput(call.Function.Arguments)
+		if err != nil {
+			return messageParam{}, err
+		}
+		result.Content = append(result.Content, contentBlock{
+			Type:  "tool_use",
+			ID:    call.ID,
+			Name:  call.Function.Name,
+			Input: input,
+		})
+	}
+	return result, nil
+}
+
+func getToolMessage(toolMessage *adapter.ToolMessage) messageParam {
+	block := contentBlock{
+		Type:      "tool_result",
+		ToolUseID: toolMessage.ToolCallID,
+	}
+	for _, part := range toolMessage.Content {
+		block.Content = append(block.Content, contentBlock{
+			Type: "text",
+			Text: part.Text,
+		})
+	}
+	return messageParam{
+		Role:    "user",
+		Content: []contentBlock{block},
+	}
+}
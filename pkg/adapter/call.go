@@ -1,5 +1,10 @@
 package adapter
 
+import (
+	"crypto/rand"
+	"encoding/hex"
+)
+
 type ToolCall struct {
 	ID       string           `json:"id" validate:"required"`
 	Function ToolCallFunction `json:"function" validate:"required"`
@@ -9,3 +14,12 @@ type ToolCallFunction struct {
 	Name      string `json:"name" validate:"required"`
 	Arguments string `json:"arguments" validate:"required"`
 }
+
+// NewToolCallID generates an ID for a tool call. It's used by adapters of
+// providers that don't identify tool calls, which is required to match the
+// calls with their results.
+func NewToolCallID() string {
+	b := make([]byte, 8)
+	rand.Read(b)
+	return "call_" + hex.EncodeToString(b)
+}
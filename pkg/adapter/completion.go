@@ -3,6 +3,7 @@ package adapter
 type CompletionParams struct {
 	Model            string          `json:"model" validate:"required"`
 	FrequencyPenalty *float64        `json:"frequency_penalty,omitempty" validate:"omitempty,gte=-2.0,lte=2.0"`
+	MaxTokens        *int64          `json:"max_tokens,omitempty" validate:"omitempty,min=1"`
 	PresencePenalty  *float64        `json:"presence_penalty,omitempty" validate:"omitempty,gte=-2.0,lte=2.0"`
 	ResponseFormat   *ResponseFormat `json:"response_format,omitempty"`
 	Stop             []string        `json:"stop,omitempty" validate:"omitempty,max=4"`
@@ -14,6 +15,16 @@ type CompletionParams struct {
 type Completion struct {
 	Message AssistantMessage `json:"message" validate:"required"`
 	Usage   *CompletionUsage `json:"usage,omitempty"`
+	// Reason the provider stopped generating the completion, as reported by
+	// the provider, like "stop" or "end_turn".
+	FinishReason string `json:"finish_reason,omitempty"`
+	// Number of attempts made to get the completion.
+	Attempts int `json:"attempts,omitempty"`
+	// ID of the client that provided the completion, if the request could
+	// be sent to one of several clients.
+	Backend string `json:"backend,omitempty"`
+	// Whether the completion was taken from the cache.
+	Cached bool `json:"cached,omitempty"`
 }
 
 type CompletionUsage struct {
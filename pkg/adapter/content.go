@@ -1,5 +1,7 @@
 package adapter
 
+import "strings"
+
 type ContentPartText struct {
 	Text string `json:"text" validate:"required"`
 }
@@ -12,3 +14,24 @@ type ContentPart struct {
 	OfContentPartText     *ContentPartText  `json:"text,omitempty"`
 	OfContentPartImageUrl *ContentPartImage `json:"image_url,omitempty"`
 }
+
+// Data returns the media type and base64 encoded data of the image, if the
+// image is specified as a data URL.
+func (p *ContentPartImage) Data() (mediaType string, data string, ok bool) {
+	v, ok := strings.CutPrefix(p.ImageUrl, "data:")
+	if !ok {
+		return "", "", false
+	}
+
+	meta, data, ok := strings.Cut(v, ",")
+	if !ok {
+		return "", "", false
+	}
+
+	mediaType, ok = strings.CutSuffix(meta, ";base64")
+	if !ok {
+		return "", "", false
+	}
+
+	return mediaType, data, true
+}
@@ -0,0 +1,48 @@
+package adapter
+
+import "context"
+
+// DeltaHandler receives chunks of a completion in the order they are
+// generated by the model.
+type DeltaHandler func(ctx context.Context, delta CompletionDelta) error
+
+type CompletionDelta struct {
+	Content   *string          `json:"content,omitempty"`
+	Refusal   *string          `json:"refusal,omitempty"`
+	ToolCalls []ToolCallDelta  `json:"tool_calls,omitempty"`
+	Usage     *CompletionUsage `json:"usage,omitempty"`
+}
+
+type ToolCallDelta struct {
+	// Index of the tool call in the assistant message.
+	Index    int                   `json:"index"`
+	ID       string                `json:"id,omitempty"`
+	Function ToolCallFunctionDelta `json:"function"`
+}
+
+type ToolCallFunctionDelta struct {
+	Name      string `json:"name,omitempty"`
+	Arguments string `json:"arguments,omitempty"`
+}
+
+// Delta returns the whole completion as a single chunk.
+func (c *Completion) Delta() CompletionDelta {
+	d := CompletionDelta{
+		Content: c.Message.Content,
+		Refusal: c.Message.Refusal,
+		Usage:   c.Usage,
+	}
+
+	for i, call := range c.Message.ToolCalls {
+		d.ToolCalls = append(d.ToolCalls, ToolCallDelta{
+			Index: i,
+			ID:    call.ID,
+			Function: ToolCallFunctionDelta{
+				Name:      call.Function.Name,
+				Arguments: call.Function.Arguments,
+			},
+		})
+	}
+
+	return d
+}
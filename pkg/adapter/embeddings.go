@@ -6,8 +6,16 @@ type EmbeddingsParams struct {
 }
 
 type Embeddings struct {
-	Data  []float64        `json:"data" validate:"required,min=1"`
+	// Embeddings of the inputs in the same order as the inputs.
+	Data  [][]float64      `json:"data" validate:"required,min=1"`
 	Usage *EmbeddingsUsage `json:"usage,omitempty"`
+	// Number of attempts made to get the embeddings.
+	Attempts int `json:"attempts,omitempty"`
+	// ID of the client that provided the embeddings, if the request could
+	// be sent to one of several clients.
+	Backend string `json:"backend,omitempty"`
+	// Whether the embeddings were taken from the cache.
+	Cached bool `json:"cached,omitempty"`
 }
 
 type EmbeddingsUsage struct {
@@ -0,0 +1,24 @@
+package adapter
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+)
+
+var ErrNotSupported = errors.New("operation is not supported by adapter")
+
+// StatusError is returned by adapters when the API of a provider responds
+// with an unsuccessful HTTP status.
+type StatusError struct {
+	StatusCode int
+	Header     http.Header
+	Body       string
+}
+
+func (e *StatusError) Error() string {
+	if e.Body == "" {
+		return fmt.Sprintf("%d %s", e.StatusCode, http.StatusText(e.StatusCode))
+	}
+	return fmt.Sprintf("%d %s: %s", e.StatusCode, http.StatusText(e.StatusCode), e.Body)
+}
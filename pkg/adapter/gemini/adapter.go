@@ -0,0 +1,37 @@
+package gemini
+
+import (
+	"net/http"
+	"strings"
+
+	"github.com/umk/llmservices/internal/rest"
+)
+
+type Adapter struct {
+	// Base URL of the API, like https://generativelanguage.googleapis.com/v1beta/
+	BaseURL string
+	Key     string
+	// HTTP client to send requests with. If nil, the default client is used.
+	Client *http.Client
+}
+
+func (c *Adapter) header() http.Header {
+	h := make(http.Header)
+	if c.Key != "" {
+		h.Set("x-goog-api-key", c.Key)
+	}
+	return h
+}
+
+// url returns the URL of the method of a model, like models/{model}:generateContent
+func (c *Adapter) url(model string, method string) string {
+	return rest.URL(c.BaseURL, getModelName(model)+":"+method)
+}
+
+// getModelName returns the resource name of the model, like models/{model}
+func getModelName(model string) string {
+	if !strings.HasPrefix(model, "models/") {
+		model = "models/" + model
+	}
+	return model
+}
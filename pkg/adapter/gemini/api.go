@@ -0,0 +1,108 @@
+package gemini
+
+import "encoding/json"
+
+type generateContentRequest struct {
+	Contents          []content         `json:"contents"`
+	SystemInstruction *content          `json:"systemInstruction,omitempty"`
+	Tools             []tool            `json:"tools,omitempty"`
+	GenerationConfig  *generationConfig `json:"generationConfig,omitempty"`
+}
+
+type generateContentResponse struct {
+	Candidates     []candidate     `json:"candidates"`
+	PromptFeedback *promptFeedback `json:"promptFeedback,omitempty"`
+	UsageMetadata  *usageMetadata  `json:"usageMetadata,omitempty"`
+	// Failure of the streamed response, which is reported once the response
+	// has started.
+	Error *apiError `json:"error,omitempty"`
+}
+
+type apiError struct {
+	Code    int    `json:"code"`
+	Message string `json:"message"`
+	Status  string `json:"status"`
+}
+
+type content struct {
+	Role  string `json:"role,omitempty"`
+	Parts []part `json:"parts"`
+}
+
+type part struct {
+	Text             string            `json:"text,omitempty"`
+	InlineData       *blob             `json:"inlineData,omitempty"`
+	FunctionCall     *functionCall     `json:"functionCall,omitempty"`
+	FunctionResponse *functionResponse `json:"functionResponse,omitempty"`
+}
+
+type blob struct {
+	MimeType string `json:"mimeType"`
+	Data     string `json:"data"`
+}
+
+type functionCall struct {
+	ID   string          `json:"id,omitempty"`
+	Name string          `json:"name"`
+	Args json.RawMessage `json:"args,omitempty"`
+}
+
+type functionResponse struct {
+	ID       string          `json:"id,omitempty"`
+	Name     string          `json:"name"`
+	Response json.RawMessage `json:"response"`
+}
+
+type tool struct {
+	FunctionDeclarations []functionDeclaration `json:"functionDeclarations"`
+}
+
+type functionDeclaration struct {
+	Name        string         `json:"name"`
+	Description string         `json:"description,omitempty"`
+	Parameters  map[string]any `json:"parameters,omitempty"`
+}
+
+type generationConfig struct {
+	StopSequences      []string       `json:"stopSequences,omitempty"`
+	Temperature        *float64       `json:"temperature,omitempty"`
+	TopP               *float64       `json:"topP,omitempty"`
+	MaxOutputTokens    *int64         `json:"maxOutputTokens,omitempty"`
+	PresencePenalty    *float64       `json:"presencePenalty,omitempty"`
+	FrequencyPenalty   *float64       `json:"frequencyPenalty,omitempty"`
+	ResponseMimeType   string         `json:"responseMimeType,omitempty"`
+	ResponseJSONSchema map[string]any `json:"responseJsonSchema,omitempty"`
+}
+
+type candidate struct {
+	Content      content `json:"content"`
+	FinishReason string  `json:"finishReason,omitempty"`
+}
+
+type promptFeedback struct {
+	BlockReason string `json:"blockReason,omitempty"`
+}
+
+type usageMetadata struct {
+	PromptTokenCount     int64 `json:"promptTokenCount"`
+	CandidatesTokenCount int64 `json:"candidatesTokenCount"`
+}
+
+type batchEmbedContentsRequest struct {
+	Requests []embedContentRequest `json:"requests"`
+}
+
+type embedContentRequest struct {
+	Model                string  `json:"model"`
+	Content              content `json:"content"`
+	OutputDimensionality *int64  `json:"outputDimensionality,omitempty"`
+}
+
+type batchEmbedContentsResponse struct {
+	Embeddings    []contentEmbedding `json:"embeddings"`
+	UsageMetadata *usageMetadata     `json:"usageMetadata,omitempty"`
+}
+
+type contentEmbedding struct {
+	Values []float64 `json:"values"`
+}
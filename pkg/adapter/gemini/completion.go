@@ -0,0 +1,309 @@
+package gemini
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strings"
+
+	"github.com/umk/llmservices/internal/rest"
+	"github.com/umk/llmservices/pkg/adapter"
+)
+
+func (c *Adapter) Completion(ctx context.Context, messages []adapter.Message, params adapter.CompletionParams) (adapter.Completion, error) {
+	p, err := getCompletionParams(messages, params)
+	if err != nil {
+		return adapter.Completion{}, err
+	}
+
+	var resp generateContentResponse
+	if err := rest.Post(ctx, c.Client, c.url(params.Model, "generateContent"), c.header(), p, &resp); err != nil {
+		return adapter.Completion{}, err
+	}
+
+	return getCompletionResponse(&resp)
+}
+
+func (c *Adapter) CompletionStream(ctx context.Context, messages []adapter.Message, params adapter.CompletionParams, handler adapter.DeltaHandler) (adapter.Completion, error) {
+	p, err := getCompletionParams(messages, params)
+	if err != nil {
+		return adapter.Completion{}, err
+	}
+
+	r, err := rest.Send(ctx, c.Client, c.url(params.Model, "streamGenerateContent")+"?alt=sse", c.header(), p)
+	if err != nil {
+		return adapter.Completion{}, err
+	}
+	defer r.Body.Close()
+
+	// Response is a sequence of server-sent events, each of which is a part
+	// of the response with the same fields as the whole one.
+	var s streamState
+
+	sc := bufio.NewScanner(r.Body)
+	sc.Buffer(nil, 1024*1024)
+	for sc.Scan() {
+		data, ok := strings.CutPrefix(sc.Text(), "data:")
+		if !ok {
+			continue
+		}
+
+		var chunk generateContentResponse
+		if err := json.Unmarshal([]byte(strings.TrimSpace(data)), &chunk); err != nil {
+			return adapter.Completion{}, fmt.Errorf("failed to decode response: %w", err)
+		}
+
+		delta, err := s.add(&chunk)
+		if err != nil {
+			return adapter.Completion{}, err
+		}
+		if delta == nil {
+			continue
+		}
+
+		if err := handler(ctx, *delta); err != nil {
+			return adapter.Completion{}, err
+		}
+	}
+	if err := sc.Err(); err != nil {
+		return adapter.Completion{}, err
+	}
+	// The last part of the response has the reason the candidate finished.
+	if len(s.resp.Candidates) > 0 && s.resp.Candidates[0].FinishReason == "" {
+		return adapter.Completion{}, errors.New("response ended unexpectedly")
+	}
+
+	return getCompletionResponse(&s.resp)
+}
+
+// streamState accumulates the parts of the streamed response.
+type streamState struct {
+	resp generateContentResponse
+	// Number of the function calls streamed so far.
+	calls int
+}
+
+// add applies the part of the response, and returns the delta to pass to
+// the handler, if any.
+func (s *streamState) add(chunk *generateContentResponse) (*adapter.CompletionDelta, error) {
+	if e := chunk.Error; e != nil {
+		return nil, fmt.Errorf("response failed: %s: %s", e.Status, e.Message)
+	}
+
+	var delta adapter.CompletionDelta
+	changed := false
+
+	if chunk.PromptFeedback != nil {
+		s.resp.PromptFeedback = chunk.PromptFeedback
+	}
+
+	// The usage is reported for the whole response so far.
+	if chunk.UsageMetadata != nil {
+		s.resp.UsageMetadata = chunk.UsageMetadata
+		delta.Usage = getCompletionUsage(chunk.UsageMetadata)
+		changed = true
+	}
+
+	if len(chunk.Candidates) > 0 {
+		if len(s.resp.Candidates) == 0 {
+			s.resp.Candidates = []candidate{{Content: content{Role: "model"}}}
+		}
+
+		c := &s.resp.Candidates[0]
+		if r := chunk.Candidates[0].FinishReason; r != "" {
+			c.FinishReason = r
+		}
+
+		var text []string
+		for _, p := range chunk.Candidates[0].Content.Parts {
+			switch {
+			case p.FunctionCall != nil:
+				// The calls are streamed whole, so their IDs are set once to
+				// match the ones of the completion.
+				if p.FunctionCall.ID == "" {
+					p.FunctionCall.ID = adapter.NewToolCallID()
+				}
+				args := string(p.FunctionCall.Args)
+				if args == "" {
+					args = "{}"
+				}
+				delta.ToolCalls = append(delta.ToolCalls, adapter.ToolCallDelta{
+					Index: s.calls,
+					ID:    p.FunctionCall.ID,
+					Function: adapter.ToolCallFunctionDelta{
+						Name:      p.FunctionCall.Name,
+						Arguments: args,
+					},
+				})
+				s.calls++
+			case p.Text != "":
+				text = append(text, p.Text)
+			default:
+				continue
+			}
+			c.Content.Parts = append(c.Content.Parts, p)
+			changed = true
+		}
+
+		if len(text) > 0 {
+			t := strings.Join(text, "")
+			delta.Content = &t
+		}
+	}
+
+	if !changed {
+		return nil, nil
+	}
+
+	return &delta, nil
+}
+
+func getCompletionParams(messages []adapter.Message, params adapter.CompletionParams) (generateContentRequest, error) {
+	r := generateContentRequest{
+		GenerationConfig: &generationConfig{
+			StopSequences:    params.Stop,
+			Temperature:      params.Temperature,
+			TopP:             params.TopP,
+			MaxOutputTokens:  params.MaxTokens,
+			PresencePenalty:  params.PresencePenalty,
+			FrequencyPenalty: params.FrequencyPenalty,
+		},
+	}
+
+	setResponseFormat(r.GenerationConfig, params.ResponseFormat)
+
+	var system []part
+	for i := range messages {
+		message := &messages[i]
+		if message.OfSystemMessage != nil {
+			system = append(system, part{Text: message.OfSystemMessage.Content})
+			continue
+		}
+
+		c, err := getMessage(messages[:i], message)
+		if err != nil {
+			return generateContentRequest{}, err
+		}
+
+		// Results of the parallel function calls must be sent in a single
+		// turn, so consecutive contents of the same role are combined.
+		if n := len(r.Contents); n > 0 && r.Contents[n-1].Role == c.Role {
+			r.Contents[n-1].Parts = append(r.Contents[n-1].Parts, c.Parts...)
+		} else {
+			r.Contents = append(r.Contents, c)
+		}
+	}
+	if len(system) > 0 {
+		r.SystemInstruction = &content{Parts: system}
+	}
+
+	if len(params.Tools) > 0 {
+		var t tool
+		for _, v := range params.Tools {
+			t.FunctionDeclarations = append(t.FunctionDeclarations, getFunctionDeclaration(&v))
+		}
+		r.Tools = append(r.Tools, t)
+	}
+
+	return r, nil
+}
+
+func getCompletionResponse(resp *generateContentResponse) (adapter.Completion, error) {
+	result := adapter.Completion{
+		Usage: getCompletionUsage(resp.UsageMetadata),
+	}
+
+	if len(resp.Candidates) == 0 {
+		if resp.PromptFeedback != nil && resp.PromptFeedback.BlockReason != "" {
+			refusal := fmt.Sprintf("Prompt was blocked: %s", resp.PromptFeedback.BlockReason)
+			result.Message.Refusal = &refusal
+			return result, nil
+		}
+		return adapter.Completion{}, errors.New("response contains no candidates")
+	}
+
+	c := resp.Candidates[0]
+	result.FinishReason = c.FinishReason
+
+	var text []string
+	for _, p := range c.Content.Parts {
+		switch {
+		case p.FunctionCall != nil:
+			id := p.FunctionCall.ID
+			if id == "" {
+				id = adapter.NewToolCallID()
+			}
+			args := string(p.FunctionCall.Args)
+			if args == "" {
+				args = "{}"
+			}
+			result.Message.ToolCalls = append(result.Message.ToolCalls, adapter.ToolCall{
+				ID: id,
+				Function: adapter.ToolCallFunction{
+					Name:      p.FunctionCall.Name,
+					Arguments: args,
+				},
+			})
+		case p.Text != "":
+			text = append(text, p.Text)
+		}
+	}
+
+	if len(text) > 0 {
+		content := strings.Join(text, "")
+		result.Message.Content = &content
+	} else if len(result.Message.ToolCalls) == 0 && isBlocked(c.FinishReason) {
+		refusal := fmt.Sprintf("Response was blocked: %s", c.FinishReason)
+		result.Message.Refusal = &refusal
+	}
+
+	return result, nil
+}
+
+func getCompletionUsage(usage *usageMetadata) *adapter.CompletionUsage {
+	if usage == nil {
+		return nil
+	}
+
+	return &adapter.CompletionUsage{
+		PromptTokens:     usage.PromptTokenCount,
+		CompletionTokens: usage.CandidatesTokenCount,
+	}
+}
+
+func setResponseFormat(config *generationConfig, format *adapter.ResponseFormat) {
+	if format == nil {
+		return
+	}
+
+	if format.OfResponseFormatText != nil {
+		config.ResponseMimeType = "text/plain"
+	}
+
+	if format.OfResponseFormatJSONSchema != nil {
+		config.ResponseMimeType = "application/json"
+		config.ResponseJSONSchema = format.OfResponseFormatJSONSchema.JSONSchema.Schema
+	}
+}
+
+func getFunctionDeclaration(tool *adapter.Tool) functionDeclaration {
+	f := functionDeclaration{
+		Name:       tool.Function.Name,
+		Parameters: tool.Function.Parameters,
+	}
+	if tool.Function.Description != nil {
+		f.Description = *tool.Function.Description
+	}
+	return f
+}
+
+func isBlocked(finishReason string) bool {
+	switch finishReason {
+	case "SAFETY", "RECITATION", "BLOCKLIST", "PROHIBITED_CONTENT", "SPII":
+		return true
+	default:
+		return false
+	}
+}
@@ -0,0 +1,405 @@
+package gemini
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/umk/llmservices/pkg/adapter"
+)
+
+// newServer starts a stand-in of the Gemini API, which records the request
+// and responds with the status and the body given.
+func newServer(t *testing.T, status int, contentType, body string) (*Adapter, *http.Request, *map[string]any) {
+	t.Helper()
+
+	var (
+		req     http.Request
+		reqBody map[string]any
+	)
+
+	s := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		req = *r
+
+		b, _ := io.ReadAll(r.Body)
+		if err := json.Unmarshal(b, &reqBody); err != nil {
+			t.Errorf("request is not a JSON object: %s", b)
+		}
+
+		w.Header().Set("Content-Type", contentType)
+		w.WriteHeader(status)
+		io.WriteString(w, body)
+	}))
+	t.Cleanup(s.Close)
+
+	return &Adapter{BaseURL: s.URL + "/v1beta/", Key: "key"}, &req, &reqBody
+}
+
+func ptr[T any](v T) *T {
+	return &v
+}
+
+func TestCompletionRequest(t *testing.T) {
+	a, req, body := newServer(t, http.StatusOK, "application/json", `{
+		"candidates": [{
+			"content": {"role": "model", "parts": [
+				{"text": "Let me "},
+				{"text": "check."},
+				{"functionCall": {"id": "call_2", "name": "get_time", "args": {"zone": "UTC"}}}
+			]},
+			"finishReason": "STOP"
+		}],
+		"usageMetadata": {"promptTokenCount": 12, "candidatesTokenCount": 5}
+	}`)
+
+	messages := []adapter.Message{
+		{OfSystemMessage: &adapter.SystemMessage{Content: "Be brief."}},
+		{OfUserMessage: &adapter.UserMessage{Parts: []adapter.ContentPart{
+			{OfContentPartText: &adapter.ContentPartText{Text: "What time is it here?"}},
+			{OfContentPartImageUrl: &adapter.ContentPartImage{ImageUrl: "data:image/png;base64,AAAA"}},
+		}}},
+		{OfAssistantMessage: &adapter.AssistantMessage{
+			ToolCalls: []adapter.ToolCall{
+				{ID: "call_0", Function: adapter.ToolCallFunction{Name: "get_time", Arguments: `{"zone":"CET"}`}},
+				{ID: "call_1", Function: adapter.ToolCallFunction{Name: "get_date"}},
+			},
+		}},
+		{OfToolMessage: &adapter.ToolMessage{ToolCallID: "call_0", Content: []adapter.ContentPartText{{Text: "10:00"}}}},
+		{OfToolMessage: &adapter.ToolMessage{ToolCallID: "call_1", Content: []adapter.ContentPartText{{Text: `{"day":"Monday"}`}}}},
+	}
+
+	resp, err := a.Completion(context.Background(), messages, adapter.CompletionParams{
+		Model:       "gemini",
+		Temperature: ptr(0.5),
+		MaxTokens:   ptr(int64(100)),
+		Stop:        []string{"END"},
+		Tools: []adapter.Tool{
+			{Function: adapter.ToolFunction{
+				Name:        "get_time",
+				Description: ptr("Gets the time."),
+				Parameters:  map[string]any{"type": "object", "properties": map[string]any{"zone": map[string]any{"type": "string"}}},
+			}},
+			{Function: adapter.ToolFunction{Name: "get_date"}},
+		},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if req.URL.Path != "/v1beta/models/gemini:generateContent" {
+		t.Errorf("path = %s, want /v1beta/models/gemini:generateContent", req.URL.Path)
+	}
+	if v := req.Header.Get("x-goog-api-key"); v != "key" {
+		t.Errorf("x-goog-api-key = %q, want key", v)
+	}
+
+	var want map[string]any
+	if err := json.Unmarshal([]byte(`{
+		"systemInstruction": {"parts": [{"text": "Be brief."}]},
+		"contents": [
+			{"role": "user", "parts": [
+				{"text": "What time is it here?"},
+				{"inlineData": {"mimeType": "image/png", "data": "AAAA"}}
+			]},
+			{"role": "model", "parts": [
+				{"functionCall": {"name": "get_time", "args": {"zone": "CET"}}},
+				{"functionCall": {"name": "get_date", "args": {}}}
+			]},
+			{"role": "user", "parts": [
+				{"functionResponse": {"name": "get_time", "response": {"content": "10:00"}}},
+				{"functionResponse": {"name": "get_date", "response": {"day": "Monday"}}}
+			]}
+		],
+		"tools": [{"functionDeclarations": [
+			{"name": "get_time", "description": "Gets the time.", "parameters": {"type": "object", "properties": {"zone": {"type": "string"}}}},
+			{"name": "get_date"}
+		]}],
+		"generationConfig": {"stopSequences": ["END"], "temperature": 0.5, "maxOutputTokens": 100}
+	}`), &want); err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(*body, want) {
+		got, _ := json.MarshalIndent(*body, "", "  ")
+		t.Errorf("request body:\n%s", got)
+	}
+
+	wantResp := adapter.Completion{
+		Message: adapter.AssistantMessage{
+			Content: ptr("Let me check."),
+			ToolCalls: []adapter.ToolCall{
+				{ID: "call_2", Function: adapter.ToolCallFunction{Name: "get_time", Arguments: `{"zone": "UTC"}`}},
+			},
+		},
+		FinishReason: "STOP",
+		Usage:        &adapter.CompletionUsage{PromptTokens: 12, CompletionTokens: 5},
+	}
+	if !reflect.DeepEqual(resp, wantResp) {
+		t.Errorf("response = %+v, want %+v", resp, wantResp)
+	}
+}
+
+func TestCompletionResponseFormat(t *testing.T) {
+	a, _, body := newServer(t, http.StatusOK, "application/json",
+		`{"candidates": [{"content": {"parts": [{"text": "{}"}]}, "finishReason": "STOP"}]}`)
+
+	schema := map[string]any{"type": "object"}
+	_, err := a.Completion(context.Background(), []adapter.Message{adapter.CreateUserMessage("Hi")}, adapter.CompletionParams{
+		Model: "models/gemini",
+		ResponseFormat: &adapter.ResponseFormat{
+			OfResponseFormatJSONSchema: &adapter.ResponseFormatJSONSchema{
+				JSONSchema: adapter.JSONSchema{Name: "a", Schema: schema},
+			},
+		},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	config, _ := (*body)["generationConfig"].(map[string]any)
+	if config["responseMimeType"] != "application/json" || !reflect.DeepEqual(config["responseJsonSchema"], schema) {
+		t.Errorf("generationConfig = %v, want the schema of the response", config)
+	}
+}
+
+func TestCompletionToolCallID(t *testing.T) {
+	a, _, _ := newServer(t, http.StatusOK, "application/json", `{
+		"candidates": [{"content": {"parts": [{"functionCall": {"name": "f"}}]}, "finishReason": "STOP"}]
+	}`)
+
+	resp, err := a.Completion(context.Background(), []adapter.Message{adapter.CreateUserMessage("Hi")}, adapter.CompletionParams{Model: "gemini"})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	calls := resp.Message.ToolCalls
+	if len(calls) != 1 || calls[0].ID == "" || calls[0].Function.Arguments != "{}" {
+		t.Errorf("tool calls = %+v, want a call with an ID and empty arguments", calls)
+	}
+}
+
+func TestCompletionBlocked(t *testing.T) {
+	tests := []struct {
+		name string
+		body string
+		want string
+	}{
+		{
+			name: "prompt",
+			body: `{"promptFeedback": {"blockReason": "SAFETY"}}`,
+			want: "Prompt was blocked: SAFETY",
+		},
+		{
+			name: "response",
+			body: `{"candidates": [{"content": {"parts": []}, "finishReason": "RECITATION"}]}`,
+			want: "Response was blocked: RECITATION",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			a, _, _ := newServer(t, http.StatusOK, "application/json", tt.body)
+
+			resp, err := a.Completion(context.Background(), []adapter.Message{adapter.CreateUserMessage("Hi")}, adapter.CompletionParams{Model: "gemini"})
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			if resp.Message.Content != nil || resp.Message.Refusal == nil || *resp.Message.Refusal != tt.want {
+				t.Errorf("message = %+v, want the refusal %q", resp.Message, tt.want)
+			}
+		})
+	}
+}
+
+func TestCompletionInvalidRequest(t *testing.T) {
+	a := &Adapter{BaseURL: "http://127.0.0.1:0/"}
+
+	tests := []struct {
+		name     string
+		messages []adapter.Message
+	}{
+		{
+			name:     "empty content part",
+			messages: []adapter.Message{{OfUserMessage: &adapter.UserMessage{Parts: []adapter.ContentPart{{}}}}},
+		},
+		{
+			name: "image URL",
+			messages: []adapter.Message{{OfUserMessage: &adapter.UserMessage{Parts: []adapter.ContentPart{
+				{OfContentPartImageUrl: &adapter.ContentPartImage{ImageUrl: "https://example.com/a.png"}},
+			}}}},
+		},
+		{
+			name:     "empty message",
+			messages: []adapter.Message{{}},
+		},
+		{
+			name: "invalid arguments",
+			messages: []adapter.Message{{OfAssistantMessage: &adapter.AssistantMessage{
+				ToolCalls: []adapter.ToolCall{{ID: "1", Function: adapter.ToolCallFunction{Name: "f", Arguments: "{"}}},
+			}}},
+		},
+		{
+			name: "unknown tool call",
+			messages: []adapter.Message{{OfToolMessage: &adapter.ToolMessage{
+				ToolCallID: "1", Content: []adapter.ContentPartText{{Text: "a"}},
+			}}},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if _, err := a.Completion(context.Background(), tt.messages, adapter.CompletionParams{Model: "gemini"}); err == nil {
+				t.Error("Completion() succeeded")
+			}
+		})
+	}
+}
+
+func TestCompletionError(t *testing.T) {
+	a, _, _ := newServer(t, http.StatusTooManyRequests, "application/json",
+		`{"error": {"code": 429, "message": "Resource exhausted", "status": "RESOURCE_EXHAUSTED"}}`)
+
+	_, err := a.Completion(context.Background(), []adapter.Message{adapter.CreateUserMessage("Hi")}, adapter.CompletionParams{Model: "gemini"})
+
+	var statusErr *adapter.StatusError
+	if !errors.As(err, &statusErr) {
+		t.Fatalf("error = %v, want a status error", err)
+	}
+	if statusErr.StatusCode != http.StatusTooManyRequests || !strings.Contains(statusErr.Body, "RESOURCE_EXHAUSTED") {
+		t.Errorf("error = %v, want the status and the body of the response", statusErr)
+	}
+}
+
+func getStream(chunks ...string) string {
+	var b strings.Builder
+	for _, c := range chunks {
+		fmt.Fprintf(&b, "data: %s\r\n\r\n", c)
+	}
+	return b.String()
+}
+
+func TestCompletionStream(t *testing.T) {
+	a, req, _ := newServer(t, http.StatusOK, "text/event-stream", getStream(
+		`{"candidates": [{"content": {"role": "model", "parts": [{"text": "Hello"}]}}], "usageMetadata": {"promptTokenCount": 10}}`,
+		`{"candidates": [{"content": {"role": "model", "parts": [{"text": ", world"}]}}]}`,
+		`{"candidates": [{"content": {"role": "model", "parts": [{"functionCall": {"id": "call_0", "name": "f", "args": {"a": 1}}}]}, "finishReason": "STOP"}], `+
+			`"usageMetadata": {"promptTokenCount": 10, "candidatesTokenCount": 7}}`,
+	))
+
+	var deltas []adapter.CompletionDelta
+	resp, err := a.CompletionStream(context.Background(), []adapter.Message{adapter.CreateUserMessage("Hi")}, adapter.CompletionParams{Model: "gemini"},
+		func(ctx context.Context, delta adapter.CompletionDelta) error {
+			deltas = append(deltas, delta)
+			return nil
+		})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if req.URL.Path != "/v1beta/models/gemini:streamGenerateContent" || req.URL.Query().Get("alt") != "sse" {
+		t.Errorf("URL = %s, want the streamed method with alt=sse", req.URL)
+	}
+
+	wantDeltas := []adapter.CompletionDelta{
+		{Content: ptr("Hello"), Usage: &adapter.CompletionUsage{PromptTokens: 10}},
+		{Content: ptr(", world")},
+		{
+			ToolCalls: []adapter.ToolCallDelta{{Index: 0, ID: "call_0", Function: adapter.ToolCallFunctionDelta{Name: "f", Arguments: `{"a": 1}`}}},
+			Usage:     &adapter.CompletionUsage{PromptTokens: 10, CompletionTokens: 7},
+		},
+	}
+	if !reflect.DeepEqual(deltas, wantDeltas) {
+		got, _ := json.Marshal(deltas)
+		t.Errorf("deltas = %s", got)
+	}
+
+	wantResp := adapter.Completion{
+		Message: adapter.AssistantMessage{
+			Content: ptr("Hello, world"),
+			ToolCalls: []adapter.ToolCall{
+				{ID: "call_0", Function: adapter.ToolCallFunction{Name: "f", Arguments: `{"a": 1}`}},
+			},
+		},
+		FinishReason: "STOP",
+		Usage:        &adapter.CompletionUsage{PromptTokens: 10, CompletionTokens: 7},
+	}
+	if !reflect.DeepEqual(resp, wantResp) {
+		t.Errorf("response = %+v, want %+v", resp, wantResp)
+	}
+}
+
+func TestCompletionStreamToolCallID(t *testing.T) {
+	a, _, _ := newServer(t, http.StatusOK, "text/event-stream", getStream(
+		`{"candidates": [{"content": {"parts": [{"functionCall": {"name": "f"}}, {"functionCall": {"name": "g"}}]}, "finishReason": "STOP"}]}`,
+	))
+
+	var deltas []adapter.CompletionDelta
+	resp, err := a.CompletionStream(context.Background(), []adapter.Message{adapter.CreateUserMessage("Hi")}, adapter.CompletionParams{Model: "gemini"},
+		func(ctx context.Context, delta adapter.CompletionDelta) error {
+			deltas = append(deltas, delta)
+			return nil
+		})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// The IDs made for the calls are the same in the chunks and in the
+	// completion.
+	if len(deltas) != 1 || len(deltas[0].ToolCalls) != 2 || len(resp.Message.ToolCalls) != 2 {
+		t.Fatalf("deltas = %+v, response = %+v, want 2 tool calls", deltas, resp)
+	}
+	for i, c := range resp.Message.ToolCalls {
+		d := deltas[0].ToolCalls[i]
+		if c.ID == "" || d.ID != c.ID || d.Index != i {
+			t.Errorf("tool call %d has ID %q in the chunk and %q in the completion", i, d.ID, c.ID)
+		}
+	}
+}
+
+func TestCompletionStreamError(t *testing.T) {
+	tests := []struct {
+		name   string
+		chunks []string
+	}{
+		{
+			name: "error",
+			chunks: []string{
+				`{"candidates": [{"content": {"parts": [{"text": "Hello"}]}}]}`,
+				`{"error": {"code": 503, "message": "Overloaded", "status": "UNAVAILABLE"}}`,
+			},
+		},
+		{
+			name: "no finish reason",
+			chunks: []string{
+				`{"candidates": [{"content": {"parts": [{"text": "Hello"}]}}]}`,
+			},
+		},
+		{
+			name:   "no candidates",
+			chunks: []string{`{"usageMetadata": {"promptTokenCount": 10}}`},
+		},
+		{
+			name:   "invalid JSON",
+			chunks: []string{`{"candidates": [`},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			a, _, _ := newServer(t, http.StatusOK, "text/event-stream", getStream(tt.chunks...))
+
+			_, err := a.CompletionStream(context.Background(), []adapter.Message{adapter.CreateUserMessage("Hi")}, adapter.CompletionParams{Model: "gemini"},
+				func(ctx context.Context, delta adapter.CompletionDelta) error { return nil })
+			if err == nil {
+				t.Error("CompletionStream() succeeded")
+			}
+		})
+	}
+}
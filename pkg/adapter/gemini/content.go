@@ -0,0 +1,41 @@
+package gemini
+
+import (
+	"errors"
+	"fmt"
+
+	"github.com/umk/llmservices/pkg/adapter"
+)
+
+func getContentPart(p *adapter.ContentPart) (part, error) {
+	switch {
+	case p.OfContentPartText != nil:
+		return getTextContentPart(p), nil
+	case p.OfContentPartImageUrl != nil:
+		return getImageContentPart(p)
+	default:
+		return part{}, errors.New("unexpected content part type")
+	}
+}
+
+func getTextContentPart(p *adapter.ContentPart) part {
+	return part{Text: p.OfContentPartText.Text}
+}
+
+// getImageContentPart returns the image as inline data. The API accepts by
+// URI only the files uploaded to it, so other URLs are not supported.
+func getImageContentPart(p *adapter.ContentPart) (part, error) {
+	image := p.OfContentPartImageUrl
+
+	mediaType, data, ok := image.Data()
+	if !ok {
+		return part{}, fmt.Errorf("image URL is not supported, only data URLs are: %s", image.ImageUrl)
+	}
+
+	return part{
+		InlineData: &blob{
+			MimeType: mediaType,
+			Data:     data,
+		},
+	}, nil
+}
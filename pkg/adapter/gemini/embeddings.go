@@ -0,0 +1,57 @@
+package gemini
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/umk/llmservices/internal/rest"
+	"github.com/umk/llmservices/pkg/adapter"
+)
+
+func (c *Adapter) Embeddings(ctx context.Context, input []string, params adapter.EmbeddingsParams) (adapter.Embeddings, error) {
+	p := getEmbeddingsParams(input, params)
+
+	var resp batchEmbedContentsResponse
+	if err := rest.Post(ctx, c.Client, c.url(params.Model, "batchEmbedContents"), c.header(), p, &resp); err != nil {
+		return adapter.Embeddings{}, err
+	}
+	if len(resp.Embeddings) != len(input) {
+		return adapter.Embeddings{}, fmt.Errorf("unexpected number of embeddings: %d", len(resp.Embeddings))
+	}
+
+	return getEmbeddingsResponse(&resp), nil
+}
+
+func getEmbeddingsParams(input []string, params adapter.EmbeddingsParams) batchEmbedContentsRequest {
+	model := getModelName(params.Model)
+
+	requests := make([]embedContentRequest, len(input))
+	for i, v := range input {
+		requests[i] = embedContentRequest{
+			Model: model,
+			Content: content{
+				Parts: []part{{Text: v}},
+			},
+			OutputDimensionality: params.Dimensions,
+		}
+	}
+
+	return batchEmbedContentsRequest{Requests: requests}
+}
+
+func getEmbeddingsResponse(resp *batchEmbedContentsResponse) adapter.Embeddings {
+	data := make([][]float64, len(resp.Embeddings))
+	for i, e := range resp.Embeddings {
+		data[i] = e.Values
+	}
+
+	result := adapter.Embeddings{
+		Data: data,
+	}
+	if resp.UsageMetadata != nil {
+		result.Usage = &adapter.EmbeddingsUsage{
+			PromptTokens: resp.UsageMetadata.PromptTokenCount,
+		}
+	}
+	return result
+}
@@ -0,0 +1,58 @@
+package gemini
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"reflect"
+	"testing"
+
+	"github.com/umk/llmservices/pkg/adapter"
+)
+
+func TestEmbeddings(t *testing.T) {
+	a, req, body := newServer(t, http.StatusOK, "application/json", `{
+		"embeddings": [{"values": [0.1, 0.2]}, {"values": [0.3, 0.4]}],
+		"usageMetadata": {"promptTokenCount": 4}
+	}`)
+
+	resp, err := a.Embeddings(context.Background(), []string{"a", "b"}, adapter.EmbeddingsParams{
+		Model:      "embedding",
+		Dimensions: ptr(int64(2)),
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if req.URL.Path != "/v1beta/models/embedding:batchEmbedContents" {
+		t.Errorf("path = %s, want /v1beta/models/embedding:batchEmbedContents", req.URL.Path)
+	}
+
+	var want map[string]any
+	if err := json.Unmarshal([]byte(`{"requests": [
+		{"model": "models/embedding", "content": {"parts": [{"text": "a"}]}, "outputDimensionality": 2},
+		{"model": "models/embedding", "content": {"parts": [{"text": "b"}]}, "outputDimensionality": 2}
+	]}`), &want); err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(*body, want) {
+		got, _ := json.MarshalIndent(*body, "", "  ")
+		t.Errorf("request body:\n%s", got)
+	}
+
+	wantResp := adapter.Embeddings{
+		Data:  [][]float64{{0.1, 0.2}, {0.3, 0.4}},
+		Usage: &adapter.EmbeddingsUsage{PromptTokens: 4},
+	}
+	if !reflect.DeepEqual(resp, wantResp) {
+		t.Errorf("response = %+v, want %+v", resp, wantResp)
+	}
+}
+
+func TestEmbeddingsCount(t *testing.T) {
+	a, _, _ := newServer(t, http.StatusOK, "application/json", `{"embeddings": [{"values": [0.1]}]}`)
+
+	if _, err := a.Embeddings(context.Background(), []string{"a", "b"}, adapter.EmbeddingsParams{Model: "embedding"}); err == nil {
+		t.Error("Embeddings() succeeded with fewer embeddings than inputs")
+	}
+}
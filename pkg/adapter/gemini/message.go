@@ -0,0 +1,92 @@
+package gemini
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+
+	"github.com/umk/llmservices/pkg/adapter"
+)
+
+// getMessage converts the message to Gemini content. The previous messages
+// are used to resolve the names of called functions by their IDs.
+func getMessage(previous []adapter.Message, message *adapter.Message) (content, error) {
+	switch {
+	case message.OfUserMessage != nil:
+		return getUserMessage(message.OfUserMessage)
+	case message.OfAssistantMessage != nil:
+		return getAssistantMessage(message.OfAssistantMessage)
+	case message.OfToolMessage != nil:
+		return getToolMessage(previous, message.OfToolMessage)
+	default:
+		return content{}, errors.New("unexpected message type")
+	}
+}
+
+func getUserMessage(userMessage *adapter.UserMessage) (content, error) {
+	result := content{Role: "user"}
+	for _, part := range userMessage.Parts {
+		p, err := getContentPart(&part)
+		if err != nil {
+			return content{}, err
+		}
+		result.Parts = append(result.Parts, p)
+	}
+	return result, nil
+}
+
+func getAssistantMessage(assistantMessage *adapter.AssistantMessage) (content, error) {
+	result := content{Role: "model"}
+	if text, err := assistantMessage.Text(); err == nil && text != "" {
+		result.Parts = append(result.Parts, part{Text: text})
+	}
+	for _, call := range assistantMessage.ToolCalls {
+		args := json.RawMessage(call.Function.Arguments)
+		if len(args) == 0 {
+			args = json.RawMessage("{}")
+		} else if !json.Valid(args) {
+			return content{}, fmt.Errorf("tool call arguments are not a valid JSON: %s", call.Function.Arguments)
+		}
+		result.Parts = append(result.Parts, part{
+			FunctionCall: &functionCall{
+				Name: call.Function.Name,
+				Args: args,
+			},
+		})
+	}
+	return result, nil
+}
+
+func getToolMessage(previous []adapter.Message, toolMessage *adapter.ToolMessage) (content, error) {
+	call, ok := adapter.FindToolCall(previous, toolMessage.ToolCallID)
+	if !ok {
+		return content{}, fmt.Errorf("tool call not found: %s", toolMessage.ToolCallID)
+	}
+
+	var text string
+	for _, part := range toolMessage.Content {
+		text += part.Text
+	}
+
+	return content{
+		Role: "user",
+		Parts: []part{{
+			FunctionResponse: &functionResponse{
+				Name:     call.Function.Name,
+				Response: getFunctionResponse(text),
+			},
+		}},
+	}, nil
+}
+
+// getFunctionResponse returns the tool response as a JSON object, which
+// is required by the API. Responses that are not objects are wrapped.
+func getFunctionResponse(text string) json.RawMessage {
+	var v map[string]any
+	if err := json.Unmarshal([]byte(text), &v); err == nil {
+		return json.RawMessage(text)
+	}
+
+	b, _ := json.Marshal(map[string]string{"content": text})
+	return b
+}
@@ -77,3 +77,64 @@ func CreateAssistantMessage(content string) Message {
 		},
 	}
 }
+
+// FindToolCall looks up the tool call by its ID in the assistant messages,
+// starting from the most recent one.
+func FindToolCall(messages []Message, callID string) (ToolCall, bool) {
+	for i := len(messages) - 1; i >= 0; i-- {
+		m := messages[i].OfAssistantMessage
+		if m == nil {
+			continue
+		}
+		for _, call := range m.ToolCalls {
+			if call.ID == callID {
+				return call, true
+			}
+		}
+	}
+
+	return ToolCall{}, false
+}
+
+// GetEstimatedSize returns the size of the message contents in bytes, which
+// can be converted to an approximate number of tokens.
+func GetEstimatedSize(message *Message) int64 {
+	var size int64
+
+	switch {
+	case message.OfSystemMessage != nil:
+		size += int64(len(message.OfSystemMessage.Content))
+
+	case message.OfUserMessage != nil:
+		for _, p := range message.OfUserMessage.Parts {
+			switch {
+			case p.OfContentPartText != nil:
+				size += int64(len(p.OfContentPartText.Text))
+			case p.OfContentPartImageUrl != nil:
+				size += int64(len(p.OfContentPartImageUrl.ImageUrl))
+			}
+		}
+
+	case message.OfToolMessage != nil:
+		size += int64(len(message.OfToolMessage.ToolCallID))
+		for _, p := range message.OfToolMessage.Content {
+			size += int64(len(p.Text))
+		}
+
+	case message.OfAssistantMessage != nil:
+		switch {
+		case message.OfAssistantMessage.Content != nil:
+			size += int64(len(*message.OfAssistantMessage.Content))
+		case message.OfAssistantMessage.Refusal != nil:
+			size += int64(len(*message.OfAssistantMessage.Refusal))
+		}
+
+		for _, c := range message.OfAssistantMessage.ToolCalls {
+			size += int64(len(c.ID))
+			size += int64(len(c.Function.Name))
+			size += int64(len(c.Function.Arguments))
+		}
+	}
+
+	return size
+}
@@ -0,0 +1,43 @@
+package ollama
+
+import "net/http"
+
+type Adapter struct {
+	// Base URL of the API, like http://localhost:11434/
+	BaseURL string
+	// Key is sent as a bearer token, if the server is behind a proxy that
+	// requires authentication.
+	Key string
+	// Options of the model, like "num_ctx" or "seed", which are sent with
+	// every request. The request parameters override these options.
+	Options map[string]any
+	// Duration the model stays loaded in memory after the request, like "5m".
+	KeepAlive *string
+	// HTTP client to send requests with. If nil, the default client is used.
+	Client *http.Client
+}
+
+func (c *Adapter) header() http.Header {
+	h := make(http.Header)
+	if c.Key != "" {
+		h.Set("Authorization", "Bearer "+c.Key)
+	}
+	return h
+}
+
+// getOptions returns the model options combined with the options specific
+// for the request.
+func (c *Adapter) getOptions(options map[string]any) map[string]any {
+	if len(c.Options) == 0 && len(options) == 0 {
+		return nil
+	}
+
+	r := make(map[string]any, len(c.Options)+len(options))
+	for k, v := range c.Options {
+		r[k] = v
+	}
+	for k, v := range options {
+		r[k] = v
+	}
+	return r
+}
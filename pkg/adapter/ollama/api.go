@@ -0,0 +1,62 @@
+package ollama
+
+import "encoding/json"
+
+type chatRequest struct {
+	Model     string          `json:"model"`
+	Messages  []messageParam  `json:"messages"`
+	Tools     []toolParam     `json:"tools,omitempty"`
+	Format    json.RawMessage `json:"format,omitempty"`
+	Options   map[string]any  `json:"options,omitempty"`
+	Stream    bool            `json:"stream"`
+	KeepAlive *string         `json:"keep_alive,omitempty"`
+}
+
+type chatResponse struct {
+	Message         messageParam `json:"message"`
+	Done            bool         `json:"done"`
+	DoneReason      string       `json:"done_reason,omitempty"`
+	PromptEvalCount int64        `json:"prompt_eval_count"`
+	EvalCount       int64        `json:"eval_count"`
+}
+
+type messageParam struct {
+	Role      string     `json:"role"`
+	Content   string     `json:"content"`
+	Images    []string   `json:"images,omitempty"`
+	ToolCalls []toolCall `json:"tool_calls,omitempty"`
+	ToolName  string     `json:"tool_name,omitempty"`
+}
+
+type toolCall struct {
+	Function toolCallFunction `json:"function"`
+}
+
+type toolCallFunction struct {
+	Name      string          `json:"name"`
+	Arguments json.RawMessage `json:"arguments"`
+}
+
+type toolParam struct {
+	Type     string       `json:"type"`
+	Function toolFunction `json:"function"`
+}
+
+type toolFunction struct {
+	Name        string         `json:"name"`
+	Description string         `json:"description,omitempty"`
+	Parameters  map[string]any `json:"parameters"`
+}
+
+type embedRequest struct {
+	Model      string         `json:"model"`
+	Input      []string       `json:"input"`
+	Dimensions *int64         `json:"dimensions,omitempty"`
+	Options    map[string]any `json:"options,omitempty"`
+	KeepAlive  *string        `json:"keep_alive,omitempty"`
+}
+
+type embedResponse struct {
+	Embeddings      [][]float64 `json:"embeddings"`
+	PromptEvalCount int64       `json:"prompt_eval_count"`
+}
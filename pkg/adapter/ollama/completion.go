@@ -0,0 +1,236 @@
+package ollama
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+
+	"github.com/umk/llmservices/internal/rest"
+	"github.com/umk/llmservices/pkg/adapter"
+)
+
+func (c *Adapter) Completion(ctx context.Context, messages []adapter.Message, params adapter.CompletionParams) (adapter.Completion, error) {
+	p, err := c.getCompletionParams(ctx, messages, params)
+	if err != nil {
+		return adapter.Completion{}, err
+	}
+
+	var resp chatResponse
+	if err := rest.Post(ctx, c.Client, rest.URL(c.BaseURL, "api/chat"), c.header(), p, &resp); err != nil {
+		return adapter.Completion{}, err
+	}
+
+	return getCompletionResponse(&resp, getToolCalls(resp.Message.ToolCalls)), nil
+}
+
+func (c *Adapter) CompletionStream(ctx context.Context, messages []adapter.Message, params adapter.CompletionParams, handler adapter.DeltaHandler) (adapter.Completion, error) {
+	p, err := c.getCompletionParams(ctx, messages, params)
+	if err != nil {
+		return adapter.Completion{}, err
+	}
+	p.Stream = true
+
+	r, err := rest.Send(ctx, c.Client, rest.URL(c.BaseURL, "api/chat"), c.header(), p)
+	if err != nil {
+		return adapter.Completion{}, err
+	}
+	defer r.Body.Close()
+
+	// Response is a sequence of JSON objects separated by new lines, where
+	// the last object contains the statistics of the request.
+	var acc chatResponse
+	// Tool calls with the IDs sent with the deltas.
+	var calls []adapter.ToolCall
+
+	s := bufio.NewScanner(r.Body)
+	s.Buffer(nil, 1024*1024)
+	for s.Scan() {
+		if len(s.Bytes()) == 0 {
+			continue
+		}
+
+		var chunk chatResponse
+		if err := json.Unmarshal(s.Bytes(), &chunk); err != nil {
+			return adapter.Completion{}, fmt.Errorf("failed to decode response: %w", err)
+		}
+
+		chunkCalls := getToolCalls(chunk.Message.ToolCalls)
+		delta := getCompletionDelta(&chunk, chunkCalls, len(calls))
+
+		acc.Message.Content += chunk.Message.Content
+		calls = append(calls, chunkCalls...)
+		if chunk.Done {
+			acc.Done = true
+			acc.DoneReason = chunk.DoneReason
+			acc.PromptEvalCount = chunk.PromptEvalCount
+			acc.EvalCount = chunk.EvalCount
+		}
+
+		if delta.Content == nil && len(delta.ToolCalls) == 0 && delta.Usage == nil {
+			continue
+		}
+
+		if err := handler(ctx, delta); err != nil {
+			return adapter.Completion{}, err
+		}
+	}
+	if err := s.Err(); err != nil {
+		return adapter.Completion{}, err
+	}
+	if !acc.Done {
+		return adapter.Completion{}, errors.New("response ended unexpectedly")
+	}
+
+	return getCompletionResponse(&acc, calls), nil
+}
+
+func (c *Adapter) getCompletionParams(ctx context.Context, messages []adapter.Message, params adapter.CompletionParams) (chatRequest, error) {
+	r := chatRequest{
+		Model:     params.Model,
+		Options:   c.getOptions(getModelOptions(params)),
+		KeepAlive: c.KeepAlive,
+	}
+
+	format, err := getFormat(params.ResponseFormat)
+	if err != nil {
+		return chatRequest{}, err
+	}
+	r.Format = format
+
+	for i := range messages {
+		m, err := c.getMessage(ctx, messages[:i], &messages[i])
+		if err != nil {
+			return chatRequest{}, err
+		}
+		r.Messages = append(r.Messages, m)
+	}
+
+	for _, tool := range params.Tools {
+		r.Tools = append(r.Tools, getTool(&tool))
+	}
+
+	return r, nil
+}
+
+func getModelOptions(params adapter.CompletionParams) map[string]any {
+	r := make(map[string]any)
+	if params.Temperature != nil {
+		r["temperature"] = *params.Temperature
+	}
+	if params.TopP != nil {
+		r["top_p"] = *params.TopP
+	}
+	if params.PresencePenalty != nil {
+		r["presence_penalty"] = *params.PresencePenalty
+	}
+	if params.FrequencyPenalty != nil {
+		r["frequency_penalty"] = *params.FrequencyPenalty
+	}
+	if params.MaxTokens != nil {
+		r["num_predict"] = *params.MaxTokens
+	}
+	if len(params.Stop) > 0 {
+		r["stop"] = params.Stop
+	}
+	return r
+}
+
+func getFormat(format *adapter.ResponseFormat) (json.RawMessage, error) {
+	if format == nil || format.OfResponseFormatJSONSchema == nil {
+		return nil, nil
+	}
+
+	return json.Marshal(format.OfResponseFormatJSONSchema.JSONSchema.Schema)
+}
+
+// getCompletionResponse converts the response with the tool calls converted
+// beforehand, since the IDs of the tool calls are generated on conversion.
+func getCompletionResponse(resp *chatResponse, calls []adapter.ToolCall) adapter.Completion {
+	result := adapter.Completion{
+		FinishReason: resp.DoneReason,
+		Usage: &adapter.CompletionUsage{
+			PromptTokens:     resp.PromptEvalCount,
+			CompletionTokens: resp.EvalCount,
+		},
+	}
+
+	if resp.Message.Content != "" {
+		content := resp.Message.Content
+		result.Message.Content = &content
+	}
+
+	result.Message.ToolCalls = calls
+
+	return result
+}
+
+// getCompletionDelta converts a chunk of the streamed response along with its
+// tool calls. The number of tool calls received so far is used to index the
+// tool calls of the chunk.
+func getCompletionDelta(chunk *chatResponse, calls []adapter.ToolCall, prev int) adapter.CompletionDelta {
+	var result adapter.CompletionDelta
+
+	if chunk.Message.Content != "" {
+		content := chunk.Message.Content
+		result.Content = &content
+	}
+
+	for i, c := range calls {
+		result.ToolCalls = append(result.ToolCalls, adapter.ToolCallDelta{
+			Index: prev + i,
+			ID:    c.ID,
+			Function: adapter.ToolCallFunctionDelta{
+				Name:      c.Function.Name,
+				Arguments: c.Function.Arguments,
+			},
+		})
+	}
+
+	if chunk.Done {
+		result.Usage = &adapter.CompletionUsage{
+			PromptTokens:     chunk.PromptEvalCount,
+			CompletionTokens: chunk.EvalCount,
+		}
+	}
+
+	return result
+}
+
+func getToolCalls(calls []toolCall) []adapter.ToolCall {
+	var r []adapter.ToolCall
+	for _, call := range calls {
+		r = append(r, getToolCall(&call))
+	}
+	return r
+}
+
+func getToolCall(call *toolCall) adapter.ToolCall {
+	args := string(call.Function.Arguments)
+	if args == "" || args == "null" {
+		args = "{}"
+	}
+
+	return adapter.ToolCall{
+		ID: adapter.NewToolCallID(),
+		Function: adapter.ToolCallFunction{
+			Name:      call.Function.Name,
+			Arguments: args,
+		},
+	}
+}
+
+func getTool(t *adapter.Tool) toolParam {
+	r := toolParam{
+		Type: "function",
+		Function: toolFunction{
+			Name:       t.Function.Name,
+			Parameters: t.Function.Parameters,
+		},
+	}
+	if t.Function.Description != nil {
+		r.Function.Description = *t.Function.Description
+	}
+	return r
+}
@@ -0,0 +1,261 @@
+package ollama
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/umk/llmservices/pkg/adapter"
+)
+
+// newServer starts a stand-in of the Ollama API, which records the request
+// and responds with the status and the body given.
+func newServer(t *testing.T, status int, contentType, body string) (*Adapter, *http.Request, *map[string]any) {
+	t.Helper()
+
+	var (
+		req     http.Request
+		reqBody map[string]any
+	)
+
+	s := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		req = *r
+
+		b, _ := io.ReadAll(r.Body)
+		if err := json.Unmarshal(b, &reqBody); err != nil {
+			t.Errorf("request is not a JSON object: %s", b)
+		}
+
+		w.Header().Set("Content-Type", contentType)
+		w.WriteHeader(status)
+		io.WriteString(w, body)
+	}))
+	t.Cleanup(s.Close)
+
+	return &Adapter{BaseURL: s.URL + "/", Key: "key"}, &req, &reqBody
+}
+
+func ptr[T any](v T) *T {
+	return &v
+}
+
+func TestCompletionRequest(t *testing.T) {
+	a, req, body := newServer(t, http.StatusOK, "application/json", `{
+		"message": {
+			"role": "assistant",
+			"content": "Let me check.",
+			"tool_calls": [{"function": {"name": "get_time", "arguments": {"zone": "UTC"}}}]
+		},
+		"done": true,
+		"done_reason": "stop",
+		"prompt_eval_count": 12,
+		"eval_count": 5
+	}`)
+	a.Options = map[string]any{"num_ctx": 4096, "temperature": 1}
+	a.KeepAlive = ptr("5m")
+
+	messages := []adapter.Message{
+		{OfSystemMessage: &adapter.SystemMessage{Content: "Be brief."}},
+		{OfUserMessage: &adapter.UserMessage{Parts: []adapter.ContentPart{
+			{OfContentPartText: &adapter.ContentPartText{Text: "What time is it here?"}},
+			{OfContentPartImageUrl: &adapter.ContentPartImage{ImageUrl: "data:image/png;base64,AAAA"}},
+		}}},
+		{OfAssistantMessage: &adapter.AssistantMessage{
+			ToolCalls: []adapter.ToolCall{
+				{ID: "call_0", Function: adapter.ToolCallFunction{Name: "get_time", Arguments: `{"zone":"CET"}`}},
+			},
+		}},
+		{OfToolMessage: &adapter.ToolMessage{ToolCallID: "call_0", Content: []adapter.ContentPartText{{Text: "10:00"}}}},
+	}
+
+	resp, err := a.Completion(context.Background(), messages, adapter.CompletionParams{
+		Model:       "llama",
+		Temperature: ptr(0.5),
+		MaxTokens:   ptr(int64(100)),
+		Stop:        []string{"END"},
+		Tools: []adapter.Tool{{Function: adapter.ToolFunction{
+			Name:        "get_time",
+			Description: ptr("Returns the time."),
+			Parameters:  map[string]any{"type": "object"},
+		}}},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if req.URL.Path != "/api/chat" {
+		t.Errorf("path = %s, want /api/chat", req.URL.Path)
+	}
+	if v := req.Header.Get("Authorization"); v != "Bearer key" {
+		t.Errorf("Authorization = %q, want the key", v)
+	}
+
+	var want map[string]any
+	if err := json.Unmarshal([]byte(`{
+		"model": "llama",
+		"messages": [
+			{"role": "system", "content": "Be brief."},
+			{"role": "user", "content": "What time is it here?", "images": ["AAAA"]},
+			{"role": "assistant", "content": "", "tool_calls": [
+				{"function": {"name": "get_time", "arguments": {"zone": "CET"}}}
+			]},
+			{"role": "tool", "content": "10:00", "tool_name": "get_time"}
+		],
+		"tools": [{"type": "function", "function": {
+			"name": "get_time",
+			"description": "Returns the time.",
+			"parameters": {"type": "object"}
+		}}],
+		"options": {"num_ctx": 4096, "temperature": 0.5, "num_predict": 100, "stop": ["END"]},
+		"stream": false,
+		"keep_alive": "5m"
+	}`), &want); err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(*body, want) {
+		got, _ := json.MarshalIndent(*body, "", "  ")
+		t.Errorf("request body:\n%s", got)
+	}
+
+	if len(resp.Message.ToolCalls) != 1 || resp.Message.ToolCalls[0].ID == "" {
+		t.Fatalf("got tool calls %+v, want one with an ID", resp.Message.ToolCalls)
+	}
+	resp.Message.ToolCalls[0].ID = ""
+
+	wantResp := adapter.Completion{
+		Message: adapter.AssistantMessage{
+			Content: ptr("Let me check."),
+			ToolCalls: []adapter.ToolCall{
+				{Function: adapter.ToolCallFunction{Name: "get_time", Arguments: `{"zone": "UTC"}`}},
+			},
+		},
+		Usage:        &adapter.CompletionUsage{PromptTokens: 12, CompletionTokens: 5},
+		FinishReason: "stop",
+	}
+	if !reflect.DeepEqual(resp, wantResp) {
+		t.Errorf("response = %+v, want %+v", resp, wantResp)
+	}
+}
+
+func TestCompletionResponseFormat(t *testing.T) {
+	a, _, body := newServer(t, http.StatusOK, "application/json", `{"message": {"role": "assistant", "content": "{}"}, "done": true}`)
+
+	schema := map[string]any{"type": "object"}
+	if _, err := a.Completion(context.Background(), []adapter.Message{adapter.CreateUserMessage("Hi")}, adapter.CompletionParams{
+		Model: "llama",
+		ResponseFormat: &adapter.ResponseFormat{
+			OfResponseFormatJSONSchema: &adapter.ResponseFormatJSONSchema{
+				JSONSchema: adapter.JSONSchema{Name: "a", Schema: schema},
+			},
+		},
+	}); err != nil {
+		t.Fatal(err)
+	}
+
+	if f := (*body)["format"]; !reflect.DeepEqual(f, schema) {
+		t.Errorf("format = %v, want the schema", f)
+	}
+}
+
+func TestCompletionError(t *testing.T) {
+	a, _, _ := newServer(t, http.StatusNotFound, "application/json", `{"error": "model not found"}`)
+
+	_, err := a.Completion(context.Background(), []adapter.Message{adapter.CreateUserMessage("Hi")}, adapter.CompletionParams{Model: "llama"})
+
+	var statusErr *adapter.StatusError
+	if !errors.As(err, &statusErr) || statusErr.StatusCode != http.StatusNotFound {
+		t.Fatalf("got error %v, want the status error", err)
+	}
+	if !strings.Contains(statusErr.Body, "model not found") {
+		t.Errorf("body = %q, want the error of the provider", statusErr.Body)
+	}
+}
+
+func getStream(chunks ...string) string {
+	return strings.Join(chunks, "\n") + "\n"
+}
+
+func TestCompletionStream(t *testing.T) {
+	a, _, body := newServer(t, http.StatusOK, "application/x-ndjson", getStream(
+		`{"message": {"role": "assistant", "content": "Hel"}, "done": false}`,
+		``,
+		`{"message": {"role": "assistant", "content": "lo"}, "done": false}`,
+		`{"message": {"role": "assistant", "content": "", "tool_calls": [{"function": {"name": "get_time", "arguments": {}}}]}, "done": false}`,
+		`{"message": {"role": "assistant", "content": ""}, "done": true, "done_reason": "stop", "prompt_eval_count": 7, "eval_count": 3}`,
+	))
+
+	var deltas []adapter.CompletionDelta
+	resp, err := a.CompletionStream(context.Background(), []adapter.Message{adapter.CreateUserMessage("Hi")}, adapter.CompletionParams{Model: "llama"},
+		func(ctx context.Context, delta adapter.CompletionDelta) error {
+			deltas = append(deltas, delta)
+			return nil
+		})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if (*body)["stream"] != true {
+		t.Error("stream is not requested")
+	}
+
+	if len(resp.Message.ToolCalls) != 1 {
+		t.Fatalf("got tool calls %+v, want one", resp.Message.ToolCalls)
+	}
+	id := resp.Message.ToolCalls[0].ID
+
+	wantDeltas := []adapter.CompletionDelta{
+		{Content: ptr("Hel")},
+		{Content: ptr("lo")},
+		{ToolCalls: []adapter.ToolCallDelta{{
+			Index:    0,
+			ID:       id,
+			Function: adapter.ToolCallFunctionDelta{Name: "get_time", Arguments: "{}"},
+		}}},
+		{Usage: &adapter.CompletionUsage{PromptTokens: 7, CompletionTokens: 3}},
+	}
+	if !reflect.DeepEqual(deltas, wantDeltas) {
+		t.Errorf("deltas = %+v, want %+v", deltas, wantDeltas)
+	}
+
+	wantResp := adapter.Completion{
+		Message: adapter.AssistantMessage{
+			Content: ptr("Hello"),
+			ToolCalls: []adapter.ToolCall{
+				{ID: id, Function: adapter.ToolCallFunction{Name: "get_time", Arguments: "{}"}},
+			},
+		},
+		Usage:        &adapter.CompletionUsage{PromptTokens: 7, CompletionTokens: 3},
+		FinishReason: "stop",
+	}
+	if !reflect.DeepEqual(resp, wantResp) {
+		t.Errorf("response = %+v, want %+v", resp, wantResp)
+	}
+}
+
+func TestCompletionStreamError(t *testing.T) {
+	tests := []struct {
+		name string
+		body string
+	}{
+		{"ended", getStream(`{"message": {"role": "assistant", "content": "Hel"}, "done": false}`)},
+		{"invalid chunk", getStream(`{"message": `)},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			a, _, _ := newServer(t, http.StatusOK, "application/x-ndjson", tt.body)
+
+			_, err := a.CompletionStream(context.Background(), []adapter.Message{adapter.CreateUserMessage("Hi")}, adapter.CompletionParams{Model: "llama"},
+				func(ctx context.Context, delta adapter.CompletionDelta) error { return nil })
+			if err == nil {
+				t.Error("CompletionStream() succeeded, want an error")
+			}
+		})
+	}
+}
@@ -0,0 +1,89 @@
+package ollama
+
+import (
+	"context"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"net/url"
+	"syscall"
+	"time"
+
+	"github.com/umk/llmservices/internal/rest"
+	"github.com/umk/llmservices/pkg/adapter"
+)
+
+const (
+	// Maximum size of an image downloaded by its URL.
+	maxImageSize = 20 * 1024 * 1024
+	// Time to download an image by its URL.
+	imageTimeout = 30 * time.Second
+)
+
+var errImageAddress = errors.New("address of the image is not public")
+
+// imageClient downloads the images only from the public addresses, so the
+// URLs of the messages cannot reach the services of the host or its network.
+// The addresses are checked once resolved, including those of the redirects.
+var imageClient = &http.Client{
+	Timeout: imageTimeout,
+	Transport: &http.Transport{
+		Proxy: nil,
+		DialContext: (&net.Dialer{
+			Timeout: imageTimeout,
+			Control: checkImageAddress,
+		}).DialContext,
+		TLSHandshakeTimeout: imageTimeout,
+	},
+}
+
+// getImage returns base64 encoded data of the image. Ollama doesn't accept
+// images by URL, so the images are downloaded.
+func (c *Adapter) getImage(ctx context.Context, image *adapter.ContentPartImage) (string, error) {
+	if _, data, ok := image.Data(); ok {
+		return data, nil
+	}
+
+	u, err := url.Parse(image.ImageUrl)
+	if err != nil || (u.Scheme != "http" && u.Scheme != "https") {
+		return "", fmt.Errorf("image URL is not supported: %s", image.ImageUrl)
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, image.ImageUrl, nil)
+	if err != nil {
+		return "", err
+	}
+
+	resp, err := rest.Do(imageClient, req)
+	if err != nil {
+		return "", fmt.Errorf("failed to download image: %w", err)
+	}
+	defer resp.Body.Close()
+
+	b, err := io.ReadAll(io.LimitReader(resp.Body, maxImageSize+1))
+	if err != nil {
+		return "", fmt.Errorf("failed to download image: %w", err)
+	}
+	if len(b) > maxImageSize {
+		return "", fmt.Errorf("image is too large: %s", image.ImageUrl)
+	}
+
+	return base64.StdEncoding.EncodeToString(b), nil
+}
+
+func checkImageAddress(network, address string, _ syscall.RawConn) error {
+	host, _, err := net.SplitHostPort(address)
+	if err != nil {
+		return err
+	}
+
+	ip := net.ParseIP(host)
+	if ip == nil || !ip.IsGlobalUnicast() || ip.IsPrivate() {
+		return fmt.Errorf("%w: %s", errImageAddress, host)
+	}
+
+	return nil
+}
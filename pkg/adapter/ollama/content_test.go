@@ -0,0 +1,79 @@
+package ollama
+
+import (
+	"context"
+	"errors"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/umk/llmservices/pkg/adapter"
+)
+
+func TestCheckImageAddress(t *testing.T) {
+	tests := []struct {
+		address string
+		allowed bool
+	}{
+		{"8.8.8.8:443", true},
+		{"[2001:4860:4860::8888]:443", true},
+		{"127.0.0.1:80", false},
+		{"[::1]:80", false},
+		{"[::ffff:127.0.0.1]:80", false},
+		{"10.0.0.1:80", false},
+		{"172.16.5.4:80", false},
+		{"192.168.1.1:80", false},
+		{"[fd00::1]:80", false},
+		{"169.254.169.254:80", false},
+		{"[fe80::1]:80", false},
+		{"0.0.0.0:80", false},
+		{"224.0.0.1:80", false},
+		{"localhost:80", false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.address, func(t *testing.T) {
+			err := checkImageAddress("tcp", tt.address, nil)
+			if tt.allowed && err != nil {
+				t.Errorf("got error %v, want the address allowed", err)
+			}
+			if !tt.allowed && !errors.Is(err, errImageAddress) {
+				t.Errorf("got error %v, want %v", err, errImageAddress)
+			}
+		})
+	}
+}
+
+func TestGetImage(t *testing.T) {
+	a := &Adapter{}
+
+	data, err := a.getImage(context.Background(), &adapter.ContentPartImage{ImageUrl: "data:image/png;base64,AAAA"})
+	if err != nil || data != "AAAA" {
+		t.Errorf("got %q, %v, want the data of the URL", data, err)
+	}
+
+	if _, err := a.getImage(context.Background(), &adapter.ContentPartImage{ImageUrl: "file:///etc/passwd"}); err == nil {
+		t.Error("got the image of a file URL, want an error")
+	}
+}
+
+func TestGetImageLocal(t *testing.T) {
+	s := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		t.Error("the image is downloaded from the loopback address")
+	}))
+	t.Cleanup(s.Close)
+
+	// The server listens on the loopback address, which is not public.
+	_, err := (&Adapter{}).getImage(context.Background(), &adapter.ContentPartImage{ImageUrl: s.URL + "/image.png"})
+	if !errors.Is(err, errImageAddress) {
+		t.Errorf("got error %v, want %v", err, errImageAddress)
+	}
+
+	// The addresses are checked after the name is resolved.
+	_, port, _ := net.SplitHostPort(s.Listener.Addr().String())
+	_, err = (&Adapter{}).getImage(context.Background(), &adapter.ContentPartImage{ImageUrl: "http://localhost:" + port + "/image.png"})
+	if !errors.Is(err, errImageAddress) {
+		t.Errorf("got error %v, want %v", err, errImageAddress)
+	}
+}
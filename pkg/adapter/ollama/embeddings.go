@@ -0,0 +1,42 @@
+package ollama
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/umk/llmservices/internal/rest"
+	"github.com/umk/llmservices/pkg/adapter"
+)
+
+func (c *Adapter) Embeddings(ctx context.Context, input []string, params adapter.EmbeddingsParams) (adapter.Embeddings, error) {
+	p := c.getEmbeddingsParams(input, params)
+
+	var resp embedResponse
+	if err := rest.Post(ctx, c.Client, rest.URL(c.BaseURL, "api/embed"), c.header(), p, &resp); err != nil {
+		return adapter.Embeddings{}, err
+	}
+	if len(resp.Embeddings) != len(input) {
+		return adapter.Embeddings{}, fmt.Errorf("unexpected number of embeddings: %d", len(resp.Embeddings))
+	}
+
+	return getEmbeddingsResponse(&resp), nil
+}
+
+func (c *Adapter) getEmbeddingsParams(input []string, params adapter.EmbeddingsParams) embedRequest {
+	return embedRequest{
+		Model:      params.Model,
+		Input:      input,
+		Dimensions: params.Dimensions,
+		Options:    c.getOptions(nil),
+		KeepAlive:  c.KeepAlive,
+	}
+}
+
+func getEmbeddingsResponse(resp *embedResponse) adapter.Embeddings {
+	return adapter.Embeddings{
+		Data: resp.Embeddings,
+		Usage: &adapter.EmbeddingsUsage{
+			PromptTokens: resp.PromptEvalCount,
+		},
+	}
+}
@@ -0,0 +1,61 @@
+package ollama
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"reflect"
+	"testing"
+
+	"github.com/umk/llmservices/pkg/adapter"
+)
+
+func TestEmbeddings(t *testing.T) {
+	a, req, body := newServer(t, http.StatusOK, "application/json", `{
+		"embeddings": [[0.1, 0.2], [0.3, 0.4]],
+		"prompt_eval_count": 4
+	}`)
+	a.Options = map[string]any{"num_ctx": 512}
+
+	resp, err := a.Embeddings(context.Background(), []string{"a", "b"}, adapter.EmbeddingsParams{
+		Model:      "embedding",
+		Dimensions: ptr(int64(2)),
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if req.URL.Path != "/api/embed" {
+		t.Errorf("path = %s, want /api/embed", req.URL.Path)
+	}
+
+	var want map[string]any
+	if err := json.Unmarshal([]byte(`{
+		"model": "embedding",
+		"input": ["a", "b"],
+		"dimensions": 2,
+		"options": {"num_ctx": 512}
+	}`), &want); err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(*body, want) {
+		got, _ := json.MarshalIndent(*body, "", "  ")
+		t.Errorf("request body:\n%s", got)
+	}
+
+	wantResp := adapter.Embeddings{
+		Data:  [][]float64{{0.1, 0.2}, {0.3, 0.4}},
+		Usage: &adapter.EmbeddingsUsage{PromptTokens: 4},
+	}
+	if !reflect.DeepEqual(resp, wantResp) {
+		t.Errorf("response = %+v, want %+v", resp, wantResp)
+	}
+}
+
+func TestEmbeddingsCount(t *testing.T) {
+	a, _, _ := newServer(t, http.StatusOK, "application/json", `{"embeddings": [[0.1]]}`)
+
+	if _, err := a.Embeddings(context.Background(), []string{"a", "b"}, adapter.EmbeddingsParams{Model: "embedding"}); err == nil {
+		t.Error("Embeddings() succeeded with fewer embeddings than inputs")
+	}
+}
@@ -0,0 +1,89 @@
+package ollama
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strings"
+
+	"github.com/umk/llmservices/pkg/adapter"
+)
+
+// getMessage converts the message to Ollama message. The previous messages
+// are used to resolve the names of called tools by their IDs.
+func (c *Adapter) getMessage(ctx context.Context, previous []adapter.Message, message *adapter.Message) (messageParam, error) {
+	switch {
+	case message.OfSystemMessage != nil:
+		return getSystemMessage(message.OfSystemMessage), nil
+	case message.OfUserMessage != nil:
+		return c.getUserMessage(ctx, message.OfUserMessage)
+	case message.OfAssistantMessage != nil:
+		return getAssistantMessage(message.OfAssistantMessage)
+	case message.OfToolMessage != nil:
+		return getToolMessage(previous, message.OfToolMessage), nil
+	default:
+		return messageParam{}, errors.New("unexpected message type")
+	}
+}
+
+func getSystemMessage(systemMessage *adapter.SystemMessage) messageParam {
+	return messageParam{
+		Role:    "system",
+		Content: systemMessage.Content,
+	}
+}
+
+func (c *Adapter) getUserMessage(ctx context.Context, userMessage *adapter.UserMessage) (messageParam, error) {
+	result := messageParam{Role: "user"}
+
+	var text []string
+	for _, part := range userMessage.Parts {
+		switch {
+		case part.OfContentPartText != nil:
+			text = append(text, part.OfContentPartText.Text)
+		case part.OfContentPartImageUrl != nil:
+			image, err := c.getImage(ctx, part.OfContentPartImageUrl)
+			if err != nil {
+				return messageParam{}, err
+			}
+			result.Images = append(result.Images, image)
+		}
+	}
+	result.Content = strings.Join(text, "\n")
+
+	return result, nil
+}
+
+func getAssistantMessage(assistantMessage *adapter.AssistantMessage) (messageParam, error) {
+	result := messageParam{Role: "assistant"}
+	if text, err := assistantMessage.Text(); err == nil {
+		result.Content = text
+	}
+	for _, call := range assistantMessage.ToolCalls {
+		args := json.RawMessage(call.Function.Arguments)
+		if len(args) == 0 {
+			args = json.RawMessage("{}")
+		} else if !json.Valid(args) {
+			return messageParam{}, fmt.Errorf("tool call arguments are not a valid JSON: %s", call.Function.Arguments)
+		}
+		result.ToolCalls = append(result.ToolCalls, toolCall{
+			Function: toolCallFunction{
+				Name:      call.Function.Name,
+				Arguments: args,
+			},
+		})
+	}
+	return result, nil
+}
+
+func getToolMessage(previous []adapter.Message, toolMessage *adapter.ToolMessage) messageParam {
+	result := messageParam{Role: "tool"}
+	if call, ok := adapter.FindToolCall(previous, toolMessage.ToolCallID); ok {
+		result.ToolName = call.Function.Name
+	}
+	for _, part := range toolMessage.Content {
+		result.Content += part.Text
+	}
+	return result
+}
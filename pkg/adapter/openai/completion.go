@@ -2,6 +2,7 @@ package openai
 
 import (
 	"context"
+	"errors"
 	"fmt"
 
 	"github.com/openai/openai-go"
@@ -13,18 +14,48 @@ func (c *Adapter) Completion(ctx context.Context, messages []adapter.Message, pa
 
 	resp, err := c.Client.Chat.Completions.New(ctx, p)
 	if err != nil {
-		return adapter.Completion{}, err
+		return adapter.Completion{}, getError(err)
 	}
 
 	return getCompletionResponse(resp)
 }
 
+func (c *Adapter) CompletionStream(ctx context.Context, messages []adapter.Message, params adapter.CompletionParams, handler adapter.DeltaHandler) (adapter.Completion, error) {
+	p := getCompletionParams(messages, params)
+	p.StreamOptions = openai.ChatCompletionStreamOptionsParam{
+		IncludeUsage: openai.Bool(true),
+	}
+
+	s := c.Client.Chat.Completions.NewStreaming(ctx, p)
+	defer s.Close()
+
+	var acc openai.ChatCompletionAccumulator
+	for s.Next() {
+		chunk := s.Current()
+		if !acc.AddChunk(chunk) {
+			return adapter.Completion{}, errors.New("failed to accumulate completion chunk")
+		}
+
+		if delta, ok := getCompletionDelta(&chunk); ok {
+			if err := handler(ctx, delta); err != nil {
+				return adapter.Completion{}, err
+			}
+		}
+	}
+	if err := s.Err(); err != nil {
+		return adapter.Completion{}, getError(err)
+	}
+
+	return getCompletionResponse(&acc.ChatCompletion)
+}
+
 func getCompletionParams(messages []adapter.Message, params adapter.CompletionParams) openai.ChatCompletionNewParams {
 	r := openai.ChatCompletionNewParams{
-		Model:            params.Model,
-		FrequencyPenalty: getOpt(params.FrequencyPenalty),
-		PresencePenalty:  getOpt(params.PresencePenalty),
-		ResponseFormat:   getResponseFormat(params.ResponseFormat),
+		Model:               params.Model,
+		FrequencyPenalty:    getOpt(params.FrequencyPenalty),
+		MaxCompletionTokens: getOpt(params.MaxTokens),
+		PresencePenalty:     getOpt(params.PresencePenalty),
+		ResponseFormat:      getResponseFormat(params.ResponseFormat),
 		Stop: openai.ChatCompletionNewParamsStopUnion{
 			OfStringArray: params.Stop,
 		},
@@ -59,6 +90,7 @@ func getCompletionResponse(resp *openai.ChatCompletion) (adapter.Completion, err
 			Content: content,
 			Refusal: refusal,
 		},
+		FinishReason: string(resp.Choices[0].FinishReason),
 		Usage: &adapter.CompletionUsage{
 			PromptTokens:     resp.Usage.PromptTokens,
 			CompletionTokens: resp.Usage.CompletionTokens,
@@ -78,6 +110,44 @@ func getCompletionResponse(resp *openai.ChatCompletion) (adapter.Completion, err
 	return result, nil
 }
 
+func getCompletionDelta(chunk *openai.ChatCompletionChunk) (adapter.CompletionDelta, bool) {
+	var result adapter.CompletionDelta
+	ok := false
+
+	if len(chunk.Choices) > 0 {
+		delta := chunk.Choices[0].Delta
+		if delta.Content != "" {
+			result.Content = &delta.Content
+			ok = true
+		}
+		if delta.Refusal != "" {
+			result.Refusal = &delta.Refusal
+			ok = true
+		}
+		for _, call := range delta.ToolCalls {
+			result.ToolCalls = append(result.ToolCalls, adapter.ToolCallDelta{
+				Index: int(call.Index),
+				ID:    call.ID,
+				Function: adapter.ToolCallFunctionDelta{
+					Name:      call.Function.Name,
+					Arguments: call.Function.Arguments,
+				},
+			})
+			ok = true
+		}
+	}
+
+	if chunk.Usage.PromptTokens > 0 || chunk.Usage.CompletionTokens > 0 {
+		result.Usage = &adapter.CompletionUsage{
+			PromptTokens:     chunk.Usage.PromptTokens,
+			CompletionTokens: chunk.Usage.CompletionTokens,
+		}
+		ok = true
+	}
+
+	return result, ok
+}
+
 func getResponseFormat(format *adapter.ResponseFormat) openai.ChatCompletionNewParamsResponseFormatUnion {
 	if format == nil {
 		return openai.ChatCompletionNewParamsResponseFormatUnion{}
@@ -8,35 +8,44 @@ import (
 	"github.com/umk/llmservices/pkg/adapter"
 )
 
-func (c *Adapter) Embeddings(ctx context.Context, input string, params adapter.EmbeddingsParams) (adapter.Embeddings, error) {
+func (c *Adapter) Embeddings(ctx context.Context, input []string, params adapter.EmbeddingsParams) (adapter.Embeddings, error) {
 	p := getEmbeddingsParams(input, params)
 
 	resp, err := c.Client.Embeddings.New(ctx, p)
 	if err != nil {
-		return adapter.Embeddings{}, err
+		return adapter.Embeddings{}, getError(err)
 	}
-	if len(resp.Data) != 1 {
+	if len(resp.Data) != len(input) {
 		return adapter.Embeddings{}, fmt.Errorf("unexpected number of embeddings: %d", len(resp.Data))
 	}
 
-	return getEmbeddingsResponse(resp), nil
+	return getEmbeddingsResponse(resp)
 }
 
-func getEmbeddingsParams(input string, params adapter.EmbeddingsParams) openai.EmbeddingNewParams {
+func getEmbeddingsParams(input []string, params adapter.EmbeddingsParams) openai.EmbeddingNewParams {
 	return openai.EmbeddingNewParams{
 		Dimensions: getOpt(params.Dimensions),
 		Model:      params.Model,
 		Input: openai.EmbeddingNewParamsInputUnion{
-			OfString: openai.String(input),
+			OfArrayOfStrings: input,
 		},
 	}
 }
 
-func getEmbeddingsResponse(resp *openai.CreateEmbeddingResponse) adapter.Embeddings {
+func getEmbeddingsResponse(resp *openai.CreateEmbeddingResponse) (adapter.Embeddings, error) {
+	// The embeddings are not guaranteed to be ordered as the inputs.
+	data := make([][]float64, len(resp.Data))
+	for _, e := range resp.Data {
+		if e.Index < 0 || e.Index >= int64(len(data)) || data[e.Index] != nil {
+			return adapter.Embeddings{}, fmt.Errorf("unexpected index of embedding: %d", e.Index)
+		}
+		data[e.Index] = e.Embedding
+	}
+
 	return adapter.Embeddings{
-		Data: resp.Data[0].Embedding,
+		Data: data,
 		Usage: &adapter.EmbeddingsUsage{
 			PromptTokens: resp.Usage.PromptTokens,
 		},
-	}
+	}, nil
 }
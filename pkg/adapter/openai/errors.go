@@ -0,0 +1,27 @@
+package openai
+
+import (
+	"errors"
+
+	"github.com/openai/openai-go"
+	"github.com/umk/llmservices/pkg/adapter"
+)
+
+// getError converts the error returned by API to *adapter.StatusError, so
+// the status of the response can be inspected by callers.
+func getError(err error) error {
+	var apiErr *openai.Error
+	if !errors.As(err, &apiErr) {
+		return err
+	}
+
+	r := &adapter.StatusError{
+		StatusCode: apiErr.StatusCode,
+		Body:       apiErr.Message,
+	}
+	if apiErr.Response != nil {
+		r.Header = apiErr.Response.Header
+	}
+
+	return r
+}
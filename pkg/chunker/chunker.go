@@ -0,0 +1,225 @@
+package chunker
+
+import (
+	"fmt"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+)
+
+type Strategy string
+
+const (
+	// Recursive splits the text by paragraphs, then lines, sentences and
+	// words, until the pieces fit into a chunk.
+	Recursive Strategy = "recursive"
+	// Sentence splits the text by sentences.
+	Sentence Strategy = "sentence"
+	// Markdown splits the text by headings, so that a chunk never contains
+	// more than one section.
+	Markdown Strategy = "markdown"
+	// Token splits the text by words into the chunks of the same size.
+	Token Strategy = "token"
+)
+
+type Config struct {
+	// Strategy of splitting the text. Defaults to the recursive one.
+	Strategy Strategy `json:"strategy" validate:"omitempty,oneof=recursive sentence markdown token"`
+	// Maximum size of a chunk in tokens.
+	Size int `json:"size" validate:"required,min=1"`
+	// Number of tokens at the end of a chunk repeated in the next one.
+	Overlap int `json:"overlap" validate:"omitempty,min=0,ltfield=Size"`
+}
+
+type Chunk struct {
+	Text string `json:"text"`
+	// Offsets of the chunk in the text in bytes.
+	Start int `json:"start"`
+	End   int `json:"end"`
+}
+
+// Split splits the text into chunks according to the config. Sizes of
+// chunks are estimated from the number of bytes per token.
+func Split(text string, config Config, bytesPerTok float32) ([]Chunk, error) {
+	if config.Size <= 0 {
+		return nil, fmt.Errorf("chunk size must be positive: %d", config.Size)
+	}
+	if config.Overlap < 0 || config.Overlap >= config.Size {
+		return nil, fmt.Errorf("overlap must be less than chunk size: %d", config.Overlap)
+	}
+
+	s := splitter{
+		text:    text,
+		size:    max(int(float32(config.Size)*bytesPerTok), 1),
+		overlap: int(float32(config.Overlap) * bytesPerTok),
+	}
+
+	whole := span{0, len(text)}
+
+	var pieces [][]span
+	switch config.Strategy {
+	case "", Recursive:
+		pieces = [][]span{s.split(whole, recursiveSeparators)}
+	case Sentence:
+		pieces = [][]span{s.splitSentences(whole)}
+	case Markdown:
+		for _, section := range s.splitSections(whole) {
+			pieces = append(pieces, s.split(section, recursiveSeparators))
+		}
+	case Token:
+		pieces = [][]span{s.split(whole, wordSeparators)}
+	default:
+		return nil, fmt.Errorf("strategy is not supported: %s", config.Strategy)
+	}
+
+	var chunks []Chunk
+	for _, p := range pieces {
+		chunks = append(chunks, s.merge(p)...)
+	}
+
+	return chunks, nil
+}
+
+var (
+	recursiveSeparators = []string{"\n\n", "\n", ". ", " ", ""}
+	wordSeparators      = []string{" ", ""}
+)
+
+// span is a part of the text between the byte offsets.
+type span struct {
+	start, end int
+}
+
+func (s span) len() int { return s.end - s.start }
+
+type splitter struct {
+	text    string
+	size    int
+	overlap int
+}
+
+// split splits the span by the first of the separators it contains, and
+// splits the parts that don't fit into a chunk by the next separators.
+// The separators are kept at the end of the parts. The empty separator
+// splits the span into parts of the chunk size.
+func (s *splitter) split(sp span, separators []string) []span {
+	if sp.len() <= s.size {
+		return []span{sp}
+	}
+
+	for i, sep := range separators {
+		if sep == "" {
+			return s.cut(sp)
+		}
+
+		parts := s.splitBy(sp, sep)
+		if len(parts) == 1 {
+			continue
+		}
+
+		var r []span
+		for _, p := range parts {
+			r = append(r, s.split(p, separators[i+1:])...)
+		}
+		return r
+	}
+
+	return s.cut(sp)
+}
+
+func (s *splitter) splitBy(sp span, sep string) []span {
+	var r []span
+
+	start := sp.start
+	for {
+		i := strings.Index(s.text[start:sp.end], sep)
+		if i < 0 {
+			break
+		}
+		end := start + i + len(sep)
+		r = append(r, span{start, end})
+		start = end
+	}
+
+	if start < sp.end {
+		r = append(r, span{start, sp.end})
+	}
+
+	return r
+}
+
+// cut splits the span into parts of the chunk size, not breaking the UTF-8
+// encoded characters.
+func (s *splitter) cut(sp span) []span {
+	var r []span
+
+	for start := sp.start; start < sp.end; {
+		end := min(start+s.size, sp.end)
+		for end < sp.end && !utf8.RuneStart(s.text[end]) {
+			end--
+		}
+		if end == start {
+			_, n := utf8.DecodeRuneInString(s.text[start:])
+			end = start + n
+		}
+		r = append(r, span{start, end})
+		start = end
+	}
+
+	return r
+}
+
+// merge joins the adjacent pieces into chunks as large as possible. Each
+// next chunk starts with the trailing pieces of the previous one, which
+// fit into the overlap.
+func (s *splitter) merge(pieces []span) []Chunk {
+	var chunks []Chunk
+
+	var cur []span
+	emit := func() {
+		if c, ok := s.chunk(span{cur[0].start, cur[len(cur)-1].end}); ok {
+			chunks = append(chunks, c)
+		}
+	}
+
+	for _, p := range pieces {
+		if len(cur) > 0 && p.end-cur[0].start > s.size {
+			emit()
+
+			// Keep the pieces for the overlap, leaving the room for
+			// the next piece.
+			for len(cur) > 0 {
+				n := cur[len(cur)-1].end - cur[0].start
+				if n <= s.overlap && n+p.len() <= s.size {
+					break
+				}
+				cur = cur[1:]
+			}
+		}
+		cur = append(cur, p)
+	}
+
+	if len(cur) > 0 {
+		emit()
+	}
+
+	return chunks
+}
+
+// chunk creates a chunk of the span without the leading and trailing
+// whitespace. Returns false if the span contains only whitespace.
+func (s *splitter) chunk(sp span) (Chunk, bool) {
+	t := s.text[sp.start:sp.end]
+
+	l := len(t) - len(strings.TrimLeftFunc(t, unicode.IsSpace))
+	r := len(strings.TrimRightFunc(t, unicode.IsSpace))
+	if l >= r {
+		return Chunk{}, false
+	}
+
+	return Chunk{
+		Text:  t[l:r],
+		Start: sp.start + l,
+		End:   sp.start + r,
+	}, true
+}
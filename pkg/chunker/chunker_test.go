@@ -0,0 +1,168 @@
+package chunker
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+	"unicode/utf8"
+)
+
+var strategies = []Strategy{Recursive, Sentence, Markdown, Token}
+
+var texts = map[string]string{
+	"prose": "The quick brown fox jumps over the lazy dog. It barks! Does it?\n\n" +
+		"A second paragraph follows, which is a bit longer than the first one.\n" +
+		"It has two lines.",
+	"multibyte": "Съешь же ещё этих мягких французских булок, да выпей чаю. " +
+		"日本語のテキストには空白がありません。😀😃😄😁😆😅😂🤣",
+	"markdown": "# Title\n\nIntro text.\n\n## Section\n\n```\n# not a heading\n```\n\n" +
+		"Section text goes here.\n### Subsection\nMore text.",
+	"no spaces": strings.Repeat("abcdefghij", 10),
+}
+
+func TestSplitOffsets(t *testing.T) {
+	configs := []Config{
+		{Size: 10},
+		{Size: 16, Overlap: 5},
+		{Size: 40, Overlap: 15},
+		{Size: 1000},
+	}
+
+	for _, strategy := range strategies {
+		for name, text := range texts {
+			for _, config := range configs {
+				config.Strategy = strategy
+
+				chunks, err := Split(text, config, 1)
+				if err != nil {
+					t.Fatal(err)
+				}
+				if len(chunks) == 0 {
+					t.Fatalf("%s/%s/%d: no chunks", strategy, name, config.Size)
+				}
+
+				for i, c := range chunks {
+					if text[c.Start:c.End] != c.Text {
+						t.Errorf("%s/%s/%d: chunk %d text %q doesn't match its offsets", strategy, name, config.Size, i, c.Text)
+					}
+					if !utf8.ValidString(c.Text) {
+						t.Errorf("%s/%s/%d: chunk %d splits a rune: %q", strategy, name, config.Size, i, c.Text)
+					}
+					// A single rune may be larger than a tiny chunk.
+					if len(c.Text) > max(config.Size, utf8.UTFMax) {
+						t.Errorf("%s/%s/%d: chunk %d has %d bytes", strategy, name, config.Size, i, len(c.Text))
+					}
+					if strings.TrimSpace(c.Text) != c.Text || c.Text == "" {
+						t.Errorf("%s/%s/%d: chunk %d isn't trimmed: %q", strategy, name, config.Size, i, c.Text)
+					}
+
+					if i > 0 {
+						prev := chunks[i-1]
+						if c.Start < prev.Start {
+							t.Errorf("%s/%s/%d: chunk %d starts before the previous one", strategy, name, config.Size, i)
+						}
+						if overlap := prev.End - c.Start; overlap > config.Overlap {
+							t.Errorf("%s/%s/%d: chunk %d overlaps by %d bytes", strategy, name, config.Size, i, overlap)
+						}
+					}
+				}
+			}
+		}
+	}
+}
+
+func TestSplitWhitespace(t *testing.T) {
+	for _, strategy := range strategies {
+		for _, text := range []string{"", " ", " \n\n\t \n"} {
+			chunks, err := Split(text, Config{Strategy: strategy, Size: 2}, 1)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if len(chunks) != 0 {
+				t.Errorf("%s: got %v for %q, want no chunks", strategy, chunks, text)
+			}
+		}
+	}
+}
+
+func TestSplit(t *testing.T) {
+	tests := []struct {
+		name   string
+		text   string
+		config Config
+		want   []string
+	}{
+		{
+			name:   "recursive paragraphs",
+			text:   "aaa bbb\n\nccc ddd",
+			config: Config{Size: 10},
+			want:   []string{"aaa bbb", "ccc ddd"},
+		},
+		{
+			name:   "recursive overlap",
+			text:   "aa bb cc dd ee",
+			config: Config{Size: 9, Overlap: 3},
+			want:   []string{"aa bb cc", "cc dd ee"},
+		},
+		{
+			name:   "sentence",
+			text:   "One two. Three four! Five?",
+			config: Config{Strategy: Sentence, Size: 12},
+			want:   []string{"One two.", "Three four!", "Five?"},
+		},
+		{
+			name:   "markdown sections",
+			text:   "# A\nx\n# B\ny",
+			config: Config{Strategy: Markdown, Size: 100},
+			want:   []string{"# A\nx", "# B\ny"},
+		},
+		{
+			name:   "markdown fence",
+			text:   "# A\n```\n# x\n```\n# B",
+			config: Config{Strategy: Markdown, Size: 100},
+			want:   []string{"# A\n```\n# x\n```", "# B"},
+		},
+		{
+			name:   "token",
+			text:   "a b c d e f",
+			config: Config{Strategy: Token, Size: 4},
+			want:   []string{"a b", "c d", "e f"},
+		},
+		{
+			name:   "cut runes",
+			text:   "ééé",
+			config: Config{Size: 3},
+			want:   []string{"é", "é", "é"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			chunks, err := Split(tt.text, tt.config, 1)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			got := make([]string, len(chunks))
+			for i, c := range chunks {
+				got[i] = c.Text
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("got %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestSplitInvalidConfig(t *testing.T) {
+	for _, config := range []Config{
+		{Size: 0},
+		{Size: 10, Overlap: 10},
+		{Size: 10, Overlap: -1},
+		{Size: 10, Strategy: "unknown"},
+	} {
+		if _, err := Split("text", config, 1); err == nil {
+			t.Errorf("Split() with %+v succeeded, want an error", config)
+		}
+	}
+}
@@ -0,0 +1,37 @@
+package chunker
+
+import (
+	"regexp"
+	"strings"
+)
+
+var (
+	headingRx = regexp.MustCompile(`^#{1,6}(\s|$)`)
+	fenceRx   = regexp.MustCompile("^(```|~~~)")
+)
+
+// splitSections splits the span into sections, each starting with a
+// heading. Headings inside the fenced code blocks are ignored.
+func (s *splitter) splitSections(sp span) []span {
+	var r []span
+
+	start := sp.start
+	fenced := false
+
+	for _, line := range s.splitBy(sp, "\n") {
+		t := strings.TrimLeft(s.text[line.start:line.end], " ")
+
+		if fenceRx.MatchString(t) {
+			fenced = !fenced
+		} else if !fenced && headingRx.MatchString(t) && line.start > start {
+			r = append(r, span{start, line.start})
+			start = line.start
+		}
+	}
+
+	if start < sp.end {
+		r = append(r, span{start, sp.end})
+	}
+
+	return r
+}
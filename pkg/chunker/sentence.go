@@ -0,0 +1,28 @@
+package chunker
+
+import "regexp"
+
+// sentenceEndRx matches the punctuation and whitespace at the end of
+// a sentence, or an empty line between paragraphs.
+var sentenceEndRx = regexp.MustCompile(`[.!?…]+["'”’)\]]*\s+|\n\s*\n`)
+
+var sentenceSeparators = []string{"\n", " ", ""}
+
+// splitSentences splits the span into sentences, and the sentences that
+// don't fit into a chunk by lines and words.
+func (s *splitter) splitSentences(sp span) []span {
+	var r []span
+
+	start := sp.start
+	for _, m := range sentenceEndRx.FindAllStringIndex(s.text[sp.start:sp.end], -1) {
+		end := sp.start + m[1]
+		r = append(r, s.split(span{start, end}, sentenceSeparators)...)
+		start = end
+	}
+
+	if start < sp.end {
+		r = append(r, s.split(span{start, sp.end}, sentenceSeparators)...)
+	}
+
+	return r
+}
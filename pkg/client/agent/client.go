@@ -1,5 +1,10 @@
 package agent
 
-import "github.com/umk/llmservices/pkg/client/thread"
+import (
+	"github.com/umk/llmservices/pkg/client/thread"
+	"go.opentelemetry.io/otel"
+)
+
+var tracer = otel.Tracer("github.com/umk/llmservices/pkg/client/agent")
 
 type Client thread.Client
@@ -5,22 +5,44 @@ import (
 	"errors"
 	"fmt"
 	"regexp"
+	"slices"
 	"strings"
 	"unicode"
 
 	"github.com/umk/llmservices/internal/msg"
 	"github.com/umk/llmservices/pkg/adapter"
 	thread_ "github.com/umk/llmservices/pkg/client/thread"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/trace"
 )
 
 var responseRx = regexp.MustCompile(`<(thought|action|action_input|observation|answer)>`)
 
+// Strategy is the way the agent calls the tools.
+type Strategy string
+
+const (
+	// StrategyReAct makes the model follow the ReAct protocol with XML-style
+	// tags in the content of its messages, which works with any model.
+	StrategyReAct Strategy = "react"
+	// StrategyTools makes the model call the tools natively, and call the
+	// final answer tool once it's ready to answer.
+	StrategyTools Strategy = "tools"
+	// StrategyAuto calls the tools natively, unless the provider rejects
+	// the tools, in which case it falls back to the ReAct protocol.
+	StrategyAuto Strategy = "auto"
+)
+
 type ResponseParams struct {
 	adapter.CompletionParams
-	Description string          `json:"description"`
-	Iterations  int             `json:"iterations" validate:"required,min=1"`
-	Retries     *int            `json:"retries,omitempty" validate:"min=0"`
-	Handler     ResponseHandler `json:"-"`
+	Description string `json:"description"`
+	// Strategy of calling the tools, which is react unless specified.
+	Strategy   Strategy        `json:"strategy,omitempty" validate:"omitempty,oneof=react tools auto"`
+	Iterations int             `json:"iterations" validate:"required,min=1"`
+	Retries    *int            `json:"retries,omitempty" validate:"omitempty,min=0"`
+	Stream     bool            `json:"stream"`
+	Handler    ResponseHandler `json:"-"`
 }
 
 type Response struct {
@@ -28,6 +50,9 @@ type Response struct {
 	Answer string         `json:"answer,omitempty"`
 	Error  string         `json:"error,omitempty"`
 	Done   bool           `json:"done"`
+	// ID of the client that provided the last completion, if the request
+	// could be sent to one of several clients.
+	Backend string `json:"backend,omitempty"`
 }
 
 type ResponseHandler interface {
@@ -36,19 +61,29 @@ type ResponseHandler interface {
 }
 
 func (c *Client) Response(ctx context.Context, thread thread_.Thread, params ResponseParams) (Response, error) {
-	if params.ResponseFormat.OfResponseFormatJSONSchema != nil {
+	if params.ResponseFormat != nil && params.ResponseFormat.OfResponseFormatJSONSchema != nil {
 		return Response{}, errors.New("cannot use structured output for agent")
 	}
 
-	t, err := setSystemMessage(thread, params)
+	strategy := params.Strategy
+	switch strategy {
+	case "":
+		strategy = StrategyReAct
+	case StrategyAuto:
+		strategy = StrategyTools
+	}
+
+	if strategy == StrategyTools && slices.ContainsFunc(params.Tools, func(t adapter.Tool) bool {
+		return t.Function.Name == finalAnswerName
+	}) {
+		return Response{}, fmt.Errorf("tool name is reserved: %s", finalAnswerName)
+	}
+
+	t, err := setSystemMessage(thread, params, strategy)
 	if err != nil {
 		return Response{}, err
 	}
 
-	// Cannot use the built-in functionality for tools calling, so just clear
-	// the tools in the request parameters.
-	params.Tools = nil
-
 	// By default share the budget of retries with iterations.
 	iteration := params.Iterations
 	retries := &iteration
@@ -59,30 +94,80 @@ func (c *Client) Response(ctx context.Context, thread thread_.Thread, params Res
 	}
 
 	// Iterate by getting completions and calling tools
-	for {
+	for i := 0; ; i++ {
 		iteration--
 
-		r, err := c.responseIterate(ctx, t, params, retries)
+		r, err := c.responseIterate(ctx, i, t, params, strategy, retries)
+		if err != nil && i == 0 && params.Strategy == StrategyAuto && isToolsRejected(err) {
+			// The tools are rejected by the first request, so the response
+			// starts over with the ReAct protocol.
+			strategy = StrategyReAct
+			if t, err = setSystemMessage(thread, params, strategy); err != nil {
+				return Response{}, err
+			}
+			r, err = c.responseIterate(ctx, i, t, params, strategy, retries)
+		}
 		if err != nil {
 			return Response{}, err
 		}
 
 		if r.Done || iteration == 0 {
-			return r, err
+			return r, nil
 		}
+
+		t = r.Thread
 	}
 }
 
 func (c *Client) responseIterate(
+	ctx context.Context,
+	iteration int,
+	thread thread_.Thread,
+	params ResponseParams,
+	strategy Strategy,
+	retries *int,
+) (_ Response, err error) {
+	ctx, span := tracer.Start(ctx, "agent iteration", trace.WithAttributes(
+		attribute.Int("llmservices.iteration", iteration),
+		attribute.String("llmservices.agent.strategy", string(strategy)),
+	))
+	defer func() {
+		if err != nil {
+			span.RecordError(err)
+			span.SetStatus(codes.Error, err.Error())
+		}
+		span.End()
+	}()
+
+	if strategy == StrategyTools {
+		return c.toolsIterate(ctx, thread, params, retries)
+	}
+
+	return c.reactIterate(ctx, thread, params, retries)
+}
+
+// reactIterate gets a completion that follows the ReAct protocol, and calls
+// the tool requested by it.
+func (c *Client) reactIterate(
 	ctx context.Context,
 	thread thread_.Thread,
 	params ResponseParams,
 	retries *int,
 ) (Response, error) {
+	// Cannot use the built-in functionality for tools calling, so just clear
+	// the tools in the request parameters.
+	params.Tools = nil
+
 	var output structuredCompl
 
+	var delta adapter.DeltaHandler
+	if s, ok := params.Handler.(thread_.StreamHandler); ok && params.Stream {
+		delta = s.Delta
+	}
+
 	for t := thread; ; *retries-- {
-		o, err := c.getStructuredCompl(ctx, &t, params.CompletionParams)
+		// Only the answer is streamed, rather than the markup of the protocol.
+		o, err := c.getStructuredCompl(ctx, &t, params.CompletionParams, newAnswerStream(delta))
 		if err != nil {
 			return Response{}, err
 		}
@@ -124,13 +209,16 @@ func (c *Client) responseIterate(
 
 	if output.done {
 		return Response{
-			Thread: thread,
-			Answer: output.answer,
-			Done:   true,
+			Thread:  thread,
+			Answer:  output.answer,
+			Done:    true,
+			Backend: output.backend,
 		}, nil
 	}
 
 	if output.action != "" {
+		trace.SpanFromContext(ctx).SetAttributes(attribute.String("llmservices.agent.action", output.action))
+
 		resp, err := params.Handler.Call(ctx, adapter.ToolCallFunction{
 			Name:      output.action,
 			Arguments: output.parameter,
@@ -152,13 +240,19 @@ func (c *Client) responseIterate(
 	}
 
 	return Response{
-		Thread: thread,
-		Done:   false,
+		Thread:  thread,
+		Done:    false,
+		Backend: output.backend,
 	}, nil
 }
 
-func (c *Client) getStructuredCompl(ctx context.Context, thread *thread_.Thread, params adapter.CompletionParams) (structuredCompl, error) {
-	resp, err := (*thread_.Client)(c).Completion(ctx, *thread, params)
+func (c *Client) getStructuredCompl(
+	ctx context.Context,
+	thread *thread_.Thread,
+	params adapter.CompletionParams,
+	delta adapter.DeltaHandler,
+) (structuredCompl, error) {
+	resp, err := (*thread_.Client)(c).CompletionStream(ctx, *thread, params, delta)
 	if err != nil {
 		return structuredCompl{}, err
 	}
@@ -168,25 +262,30 @@ func (c *Client) getStructuredCompl(ctx context.Context, thread *thread_.Thread,
 		return structuredCompl{}, err
 	}
 
-	if r.Refusal != nil || r.Content == nil {
+	if r.Refusal != nil {
 		return structuredCompl{
-			answer: *r.Refusal,
-			done:   true,
+			answer:  *r.Refusal,
+			done:    true,
+			backend: resp.Backend,
 		}, nil
 	}
 
 	*thread = resp.Thread
 
-	return parseResponse(*r.Content), nil
+	// A completion without content is treated as the one that doesn't follow
+	// the protocol.
+	if r.Content == nil {
+		return structuredCompl{backend: resp.Backend}, nil
+	}
+
+	output := parseResponse(*r.Content)
+	output.backend = resp.Backend
+
+	return output, nil
 }
 
-func setSystemMessage(thread thread_.Thread, params ResponseParams) (thread_.Thread, error) {
-	// Create system message that contains agent description and the tools
-	// available for calling.
-	c, err := msg.RenderAgentSystemMessage(msg.AgentSystemMessageParams{
-		Description: params.Description,
-		Tools:       params.Tools,
-	})
+func setSystemMessage(thread thread_.Thread, params ResponseParams, strategy Strategy) (thread_.Thread, error) {
+	c, err := getSystemMessage(params, strategy)
 	if err != nil {
 		return thread_.Thread{}, err
 	}
@@ -198,6 +297,7 @@ func setSystemMessage(thread thread_.Thread, params ResponseParams) (thread_.Thr
 		if i < len(thread.Frames) {
 			f := &thread.Frames[i]
 			if len(f.Messages) == 0 {
+				i++
 				continue
 			}
 
@@ -217,6 +317,22 @@ func setSystemMessage(thread thread_.Thread, params ResponseParams) (thread_.Thr
 	}
 }
 
+func getSystemMessage(params ResponseParams, strategy Strategy) (string, error) {
+	if strategy == StrategyTools {
+		return msg.RenderAgentToolsSystemMessage(msg.AgentToolsSystemMessageParams{
+			Description:     params.Description,
+			FinalAnswerTool: finalAnswerName,
+		})
+	}
+
+	// Create system message that contains agent description and the tools
+	// available for calling.
+	return msg.RenderAgentSystemMessage(msg.AgentSystemMessageParams{
+		Description: params.Description,
+		Tools:       params.Tools,
+	})
+}
+
 type structuredCompl struct {
 	thoughts    []string
 	action      string
@@ -224,6 +340,7 @@ type structuredCompl struct {
 	observation string
 	answer      string
 	done        bool
+	backend     string
 }
 
 func parseResponse(response string) (output structuredCompl) {
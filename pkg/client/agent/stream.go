@@ -0,0 +1,105 @@
+package agent
+
+import (
+	"context"
+	"strings"
+	"unicode"
+
+	"github.com/umk/llmservices/pkg/adapter"
+)
+
+const (
+	answerOpen  = "<answer>"
+	answerClose = "</answer>"
+)
+
+// answerStream passes to the handler only the content of the answer of
+// a completion that follows the ReAct protocol, so that the thoughts and
+// the actions aren't streamed as the text of the response. Like the answer
+// parsed from the completion, the streamed one is trimmed of spaces.
+type answerStream struct {
+	handler adapter.DeltaHandler
+	// Content that is not passed yet, because it may be a part of a tag or
+	// the trailing spaces of the answer.
+	pending string
+	answer  bool
+	started bool
+	done    bool
+}
+
+// newAnswerStream creates the handler of the chunks of a single completion.
+// If the handler is nil, nil is returned.
+func newAnswerStream(handler adapter.DeltaHandler) adapter.DeltaHandler {
+	if handler == nil {
+		return nil
+	}
+
+	s := &answerStream{handler: handler}
+	return s.delta
+}
+
+func (s *answerStream) delta(ctx context.Context, delta adapter.CompletionDelta) error {
+	var content string
+	if delta.Content != nil {
+		content = s.add(*delta.Content)
+	}
+
+	if content == "" && delta.Refusal == nil && delta.Usage == nil {
+		return nil
+	}
+
+	d := adapter.CompletionDelta{Refusal: delta.Refusal, Usage: delta.Usage}
+	if content != "" {
+		d.Content = &content
+	}
+
+	return s.handler(ctx, d)
+}
+
+// add consumes the chunk of the completion, and returns the content of
+// the answer that can be passed to the handler.
+func (s *answerStream) add(chunk string) string {
+	if s.done {
+		return ""
+	}
+
+	s.pending += chunk
+
+	if !s.answer {
+		i := strings.Index(s.pending, answerOpen)
+		if i < 0 {
+			s.pending = s.pending[len(s.pending)-getPrefixLen(s.pending, answerOpen):]
+			return ""
+		}
+		s.pending = s.pending[i+len(answerOpen):]
+		s.answer = true
+	}
+
+	if !s.started {
+		s.pending = strings.TrimLeftFunc(s.pending, unicode.IsSpace)
+		s.started = s.pending != ""
+	}
+
+	var content string
+	if i := strings.Index(s.pending, answerClose); i >= 0 {
+		content = strings.TrimRightFunc(s.pending[:i], unicode.IsSpace)
+		s.pending, s.done = "", true
+	} else {
+		n := len(s.pending) - getPrefixLen(s.pending, answerClose)
+		content = strings.TrimRightFunc(s.pending[:n], unicode.IsSpace)
+		s.pending = s.pending[len(content):]
+	}
+
+	return content
+}
+
+// getPrefixLen returns the length of the longest suffix of s, which is
+// a prefix of the tag.
+func getPrefixLen(s, tag string) int {
+	for n := min(len(s), len(tag)-1); n > 0; n-- {
+		if strings.HasSuffix(s, tag[:n]) {
+			return n
+		}
+	}
+	return 0
+}
@@ -0,0 +1,93 @@
+package agent
+
+import (
+	"context"
+	"strings"
+	"testing"
+
+	"github.com/umk/llmservices/pkg/adapter"
+)
+
+func TestAnswerStream(t *testing.T) {
+	tests := []struct {
+		name   string
+		chunks []string
+		want   string
+	}{
+		{
+			name:   "answer only",
+			chunks: []string{"<answer>Hello!</answer>"},
+			want:   "Hello!",
+		},
+		{
+			name:   "thought and answer",
+			chunks: []string{"<thought>The user greets me.</thought>\n<answer>\n  Hello, ", "world!\n</answer>"},
+			want:   "Hello, world!",
+		},
+		{
+			name:   "split tags",
+			chunks: []string{"<thought>a < b</thought><ans", "wer>", "x <", "/b> y  ", " z</ans", "wer> ignored"},
+			want:   "x </b> y   z",
+		},
+		{
+			name:   "by characters",
+			chunks: strings.Split("<thought>t</thought> <answer> 1 < 2 </answer>", ""),
+			want:   "1 < 2",
+		},
+		{
+			name:   "action",
+			chunks: []string{"<thought>t</thought><action>search</action><action_input>{}</action_input>"},
+			want:   "",
+		},
+		{
+			name:   "unclosed answer",
+			chunks: []string{"<answer>Hel", "lo "},
+			want:   "Hello",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var b strings.Builder
+			h := newAnswerStream(func(ctx context.Context, delta adapter.CompletionDelta) error {
+				if delta.Content == nil || *delta.Content == "" {
+					t.Error("delta without content")
+				} else {
+					b.WriteString(*delta.Content)
+				}
+				return nil
+			})
+
+			for _, c := range tt.chunks {
+				if err := h(context.Background(), adapter.CompletionDelta{Content: &c}); err != nil {
+					t.Fatal(err)
+				}
+			}
+
+			if got := b.String(); got != tt.want {
+				t.Errorf("streamed %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestAnswerStreamUsage(t *testing.T) {
+	var deltas []adapter.CompletionDelta
+	h := newAnswerStream(func(ctx context.Context, delta adapter.CompletionDelta) error {
+		deltas = append(deltas, delta)
+		return nil
+	})
+
+	content := "<thought>t</thought>"
+	if err := h(context.Background(), adapter.CompletionDelta{Content: &content, Usage: &adapter.CompletionUsage{}}); err != nil {
+		t.Fatal(err)
+	}
+
+	if len(deltas) != 1 || deltas[0].Content != nil || deltas[0].Usage == nil {
+		t.Errorf("deltas = %+v, want the usage without the content", deltas)
+	}
+
+	if newAnswerStream(nil) != nil {
+		t.Error("newAnswerStream(nil) isn't nil")
+	}
+}
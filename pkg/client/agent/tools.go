@@ -0,0 +1,222 @@
+package agent
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"regexp"
+	"slices"
+	"strings"
+
+	"github.com/umk/llmservices/internal/msg"
+	"github.com/umk/llmservices/pkg/adapter"
+	thread_ "github.com/umk/llmservices/pkg/client/thread"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/trace"
+)
+
+const finalAnswerName = "final_answer"
+
+var finalAnswerDescription = "Provides the final answer to the user once the task is done."
+
+var finalAnswerTool = adapter.Tool{
+	Function: adapter.ToolFunction{
+		Name:        finalAnswerName,
+		Description: &finalAnswerDescription,
+		Parameters: map[string]any{
+			"type": "object",
+			"properties": map[string]any{
+				"answer": map[string]any{
+					"type":        "string",
+					"description": "The final answer.",
+				},
+			},
+			"required": []string{"answer"},
+		},
+	},
+}
+
+// toolsIterate gets a completion with the native tool calls, and calls the
+// tools requested by it. The content that comes with the tool calls is
+// passed to the handler as a thought.
+func (c *Client) toolsIterate(
+	ctx context.Context,
+	thread thread_.Thread,
+	params ResponseParams,
+	retries *int,
+) (Response, error) {
+	p := params.CompletionParams
+	p.Tools = append(slices.Clone(params.Tools), finalAnswerTool)
+
+	var delta adapter.DeltaHandler
+	if s, ok := params.Handler.(thread_.StreamHandler); ok && params.Stream {
+		delta = s.Delta
+	}
+
+	for t := thread; ; *retries-- {
+		resp, err := (*thread_.Client)(c).CompletionStream(ctx, t, p, delta)
+		if err != nil {
+			return Response{}, err
+		}
+
+		r, err := resp.Thread.Response()
+		if err != nil {
+			return Response{}, err
+		}
+
+		if r.Refusal != nil {
+			return Response{
+				Thread:  resp.Thread,
+				Answer:  *r.Refusal,
+				Done:    true,
+				Backend: resp.Backend,
+			}, nil
+		}
+
+		var content string
+		if r.Content != nil {
+			content = strings.TrimSpace(*r.Content)
+		}
+
+		if len(r.ToolCalls) > 0 {
+			return c.callTools(ctx, resp.Thread, r, content, params, resp.Backend)
+		}
+
+		// The model may answer without calling the final answer tool.
+		if content != "" {
+			return Response{
+				Thread:  resp.Thread,
+				Answer:  content,
+				Done:    true,
+				Backend: resp.Backend,
+			}, nil
+		}
+
+		if *retries == 0 {
+			m, err := msg.RenderAgentFatalErrorMessage(msg.AgentFatalErrorMessageParams{})
+			if err != nil {
+				return Response{}, err
+			}
+
+			return Response{
+				Thread: thread, // don't include futile retries into response
+				Error:  m,
+				Done:   true,
+			}, nil
+		}
+
+		m, err := msg.RenderAgentToolsErrorMessage(msg.AgentToolsErrorMessageParams{
+			FinalAnswerTool: finalAnswerName,
+		})
+		if err != nil {
+			return Response{}, err
+		}
+
+		t = resp.Thread
+		f := &t.Frames[len(t.Frames)-1]
+		f.Messages = append(f.Messages, adapter.CreateUserMessage(m))
+	}
+}
+
+func (c *Client) callTools(
+	ctx context.Context,
+	thread thread_.Thread,
+	r adapter.AssistantMessage,
+	thought string,
+	params ResponseParams,
+	backend string,
+) (Response, error) {
+	if thought != "" {
+		if err := params.Handler.Thought(ctx, thought); err != nil {
+			return Response{}, err
+		}
+	}
+
+	var (
+		answer  string
+		done    bool
+		actions []string
+	)
+
+	f := &thread.Frames[len(thread.Frames)-1]
+
+	// Every tool call gets a response, so that the thread can be continued.
+	for _, call := range r.ToolCalls {
+		var resp string
+		var err error
+
+		switch {
+		case call.Function.Name == finalAnswerName:
+			if resp, err = getFinalAnswer(call.Function.Arguments); err == nil {
+				answer, done = resp, true
+			}
+		case slices.ContainsFunc(params.Tools, func(t adapter.Tool) bool {
+			return t.Function.Name == call.Function.Name
+		}):
+			actions = append(actions, call.Function.Name)
+			resp, err = params.Handler.Call(ctx, call.Function)
+		default:
+			err = fmt.Errorf("calling not existing function: %s", call.Function.Name)
+		}
+
+		if err != nil {
+			m, renderErr := msg.RenderToolErrorMessage(msg.ToolErrorMessageParams{
+				Error: err.Error(),
+			})
+			if renderErr != nil {
+				m = err.Error()
+			}
+			resp = m
+		}
+
+		f.Messages = append(f.Messages, adapter.CreateToolMessage(call.ID, resp))
+	}
+
+	if len(actions) > 0 {
+		trace.SpanFromContext(ctx).SetAttributes(attribute.StringSlice("llmservices.agent.actions", actions))
+	}
+
+	return Response{
+		Thread:  thread,
+		Answer:  answer,
+		Done:    done,
+		Backend: backend,
+	}, nil
+}
+
+func getFinalAnswer(arguments string) (string, error) {
+	var args struct {
+		Answer *string `json:"answer"`
+	}
+
+	if err := json.Unmarshal([]byte(arguments), &args); err != nil {
+		return "", err
+	}
+
+	if args.Answer == nil {
+		return "", errors.New("answer is required")
+	}
+
+	return *args.Answer, nil
+}
+
+var (
+	toolsRx       = regexp.MustCompile(`(?i)\b(tools?|tool[ _]choice|tool[ _]calls?|function[ _]calling)\b`)
+	unsupportedRx = regexp.MustCompile(`(?i)not supported|unsupported|does not support|doesn't support|not enabled|requires`)
+)
+
+// isToolsRejected tells whether the provider has rejected the request,
+// because the model doesn't support the tools, like "llama2 does not support
+// tools" of Ollama or "tool choice requires --enable-auto-tool-choice" of
+// vLLM. Other failures of the request, like exceeding the context length,
+// aren't fixed by falling back to the ReAct protocol.
+func isToolsRejected(err error) bool {
+	var statusErr *adapter.StatusError
+	if !errors.As(err, &statusErr) || statusErr.StatusCode != http.StatusBadRequest {
+		return false
+	}
+
+	return toolsRx.MatchString(statusErr.Body) && unsupportedRx.MatchString(statusErr.Body)
+}
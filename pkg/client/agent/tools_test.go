@@ -0,0 +1,68 @@
+package agent
+
+import (
+	"errors"
+	"fmt"
+	"testing"
+
+	"github.com/umk/llmservices/pkg/adapter"
+)
+
+func TestIsToolsRejected(t *testing.T) {
+	tests := []struct {
+		name string
+		err  error
+		want bool
+	}{
+		{
+			name: "ollama",
+			err:  &adapter.StatusError{StatusCode: 400, Body: `{"error":"registry.ollama.ai/library/llama2:latest does not support tools"}`},
+			want: true,
+		},
+		{
+			name: "vllm",
+			err: &adapter.StatusError{StatusCode: 400, Body: `{"object":"error","message":"\\"auto\\" tool choice requires ` +
+				`--enable-auto-tool-choice and --tool-call-parser to be set"}`},
+			want: true,
+		},
+		{
+			name: "openai",
+			err:  &adapter.StatusError{StatusCode: 400, Body: `{"error":{"message":"'tools' is not supported with this model."}}`},
+			want: true,
+		},
+		{
+			name: "wrapped",
+			err:  fmt.Errorf("completion: %w", &adapter.StatusError{StatusCode: 400, Body: "model does not support tools"}),
+			want: true,
+		},
+		{
+			name: "context length",
+			err: &adapter.StatusError{StatusCode: 400, Body: `{"error":{"message":"This model's maximum context length is ` +
+				`8192 tokens. However, your messages resulted in 9000 tokens.","code":"context_length_exceeded"}}`},
+		},
+		{
+			name: "invalid schema",
+			err:  &adapter.StatusError{StatusCode: 400, Body: `{"error":{"message":"Invalid schema for function 'search'."}}`},
+		},
+		{
+			name: "bad request without body",
+			err:  &adapter.StatusError{StatusCode: 400},
+		},
+		{
+			name: "other status",
+			err:  &adapter.StatusError{StatusCode: 500, Body: "model does not support tools"},
+		},
+		{
+			name: "other error",
+			err:  errors.New("model does not support tools"),
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := isToolsRejected(tt.err); got != tt.want {
+				t.Errorf("isToolsRejected() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
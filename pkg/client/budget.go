@@ -0,0 +1,211 @@
+package client
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sync"
+	"time"
+)
+
+var ErrBudgetExceeded = errors.New("budget exceeded")
+
+// BudgetConfig limits the usage of the client. The requests fail without
+// being sent once any of the limits is reached.
+type BudgetConfig struct {
+	// Tokens the client may use in a day (UTC).
+	DailyTokens int64 `json:"daily_tokens" validate:"min=0"`
+	// Dollars the client may spend in a month (UTC) according to its pricing.
+	MonthlyCost float64 `json:"monthly_cost" validate:"min=0"`
+	// Tokens a session may use with the client.
+	SessionTokens int64 `json:"session_tokens" validate:"min=0"`
+}
+
+type BudgetError struct {
+	Budget string
+	Limit  float64
+	Used   float64
+}
+
+func (e *BudgetError) Error() string {
+	return fmt.Sprintf("%s budget exceeded: used %g of %g", e.Budget, e.Used, e.Limit)
+}
+
+func (e *BudgetError) Is(target error) bool {
+	return target == ErrBudgetExceeded
+}
+
+// Budgets keeps the spend of the clients by their names in the current day
+// and month. If created with a path, the spend is persisted to the file, so
+// that it's kept across restarts.
+type Budgets struct {
+	mu    sync.Mutex
+	path  string
+	spend map[string]*spend
+	dirty bool
+}
+
+type spend struct {
+	Day       string  `json:"day"`
+	DayTokens int64   `json:"day_tokens"`
+	Month     string  `json:"month"`
+	MonthCost float64 `json:"month_cost"`
+}
+
+// OpenBudgets reads the spend from the file if it exists. If the path is
+// empty, the spend is kept only in memory.
+func OpenBudgets(path string) (*Budgets, error) {
+	b := &Budgets{
+		path:  path,
+		spend: make(map[string]*spend),
+	}
+
+	if path == "" {
+		return b, nil
+	}
+
+	data, err := os.ReadFile(path)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return b, nil
+		}
+		return nil, err
+	}
+
+	if err := json.Unmarshal(data, &b.spend); err != nil {
+		return nil, fmt.Errorf("failed to read budgets: %w", err)
+	}
+
+	return b, nil
+}
+
+// Save writes the spend to the file, if it has changed since the last save.
+func (b *Budgets) Save() error {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	if b.path == "" || !b.dirty {
+		return nil
+	}
+
+	data, err := json.Marshal(b.spend)
+	if err != nil {
+		return err
+	}
+
+	// The file is replaced at once, so that it's never left partially
+	// written.
+	if err := os.MkdirAll(filepath.Dir(b.path), 0o755); err != nil {
+		return err
+	}
+
+	tmp := b.path + ".tmp"
+	if err := os.WriteFile(tmp, data, 0o644); err != nil {
+		return err
+	}
+	if err := os.Rename(tmp, b.path); err != nil {
+		return err
+	}
+
+	b.dirty = false
+
+	return nil
+}
+
+// Run saves the spend periodically until the context is done, and once more
+// after that. If a periodic save fails, it's retried on the next tick.
+func (b *Budgets) Run(ctx context.Context, interval time.Duration) error {
+	t := time.NewTicker(interval)
+	defer t.Stop()
+
+	for {
+		select {
+		case <-t.C:
+			b.Save()
+		case <-ctx.Done():
+			return b.Save()
+		}
+	}
+}
+
+func (b *Budgets) check(name string, config *BudgetConfig, now time.Time) error {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	s := b.get(name, now)
+
+	if config.DailyTokens > 0 && s.DayTokens >= config.DailyTokens {
+		return &BudgetError{Budget: "daily tokens", Limit: float64(config.DailyTokens), Used: float64(s.DayTokens)}
+	}
+
+	if config.MonthlyCost > 0 && s.MonthCost >= config.MonthlyCost {
+		return &BudgetError{Budget: "monthly cost", Limit: config.MonthlyCost, Used: s.MonthCost}
+	}
+
+	return nil
+}
+
+func (b *Budgets) add(name string, tokens int64, cost float64, now time.Time) {
+	if tokens == 0 && cost == 0 {
+		return
+	}
+
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	s := b.get(name, now)
+	s.DayTokens += tokens
+	s.MonthCost += cost
+
+	b.dirty = true
+}
+
+// get returns the spend of the client, which is reset when the day or the
+// month is over.
+func (b *Budgets) get(name string, now time.Time) *spend {
+	now = now.UTC()
+	day, month := now.Format(time.DateOnly), now.Format("2006-01")
+
+	s, ok := b.spend[name]
+	if !ok {
+		s = &spend{Day: day, Month: month}
+		b.spend[name] = s
+	}
+
+	if s.Day != day {
+		s.Day, s.DayTokens = day, 0
+	}
+	if s.Month != month {
+		s.Month, s.MonthCost = month, 0
+	}
+
+	return s
+}
+
+// checkBudget returns an error if any of the budgets of the client or the
+// session is exhausted.
+func (c *Client) checkBudget(ctx context.Context) error {
+	s := ContextStatistics(ctx)
+
+	if s != nil && s.MaxTokens > 0 {
+		if used := s.getTokens(nil); used >= s.MaxTokens {
+			return &BudgetError{Budget: "session tokens", Limit: float64(s.MaxTokens), Used: float64(used)}
+		}
+	}
+
+	config := c.config.Budget
+	if config == nil {
+		return nil
+	}
+
+	if s != nil && config.SessionTokens > 0 {
+		if used := s.getTokens(&c.name); used >= config.SessionTokens {
+			return &BudgetError{Budget: "session tokens", Limit: float64(config.SessionTokens), Used: float64(used)}
+		}
+	}
+
+	return c.budgets.check(c.name, config, time.Now())
+}
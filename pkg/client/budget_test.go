@@ -0,0 +1,141 @@
+package client
+
+import (
+	"context"
+	"errors"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/umk/llmservices/pkg/adapter"
+)
+
+func TestBudget(t *testing.T) {
+	tests := []struct {
+		name     string
+		config   Config
+		ctx      func() context.Context
+		requests int
+	}{
+		{
+			name:     "daily tokens",
+			config:   Config{Budget: &BudgetConfig{DailyTokens: 100}},
+			requests: 2,
+		},
+		{
+			name: "monthly cost",
+			config: Config{
+				Budget:  &BudgetConfig{MonthlyCost: 0.001},
+				Pricing: map[string]Price{"*": {Prompt: 10, Completion: 10}},
+			},
+			requests: 2,
+		},
+		{
+			name:   "client session tokens",
+			config: Config{Budget: &BudgetConfig{SessionTokens: 150}},
+			ctx: func() context.Context {
+				return ContextWithStatistics(context.Background(), NewStatistics())
+			},
+			requests: 3,
+		},
+		{
+			name: "session tokens",
+			ctx: func() context.Context {
+				s := NewStatistics()
+				s.MaxTokens = 50
+				return ContextWithStatistics(context.Background(), s)
+			},
+			requests: 1,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			a := &testAdapter{resp: adapter.Completion{
+				Message: adapter.AssistantMessage{Content: ptr("ok")},
+				Usage:   &adapter.CompletionUsage{PromptTokens: 40, CompletionTokens: 20},
+			}}
+			c := newTestClient(t, &tt.config, a)
+
+			ctx := context.Background()
+			if tt.ctx != nil {
+				ctx = tt.ctx()
+			}
+
+			for i := range tt.requests {
+				if _, err := c.Completion(ctx, getTestMessages("hi"), adapter.CompletionParams{}); err != nil {
+					t.Fatalf("request %d: %v", i, err)
+				}
+			}
+
+			// The request isn't sent once the budget is exhausted.
+			_, err := c.Completion(ctx, getTestMessages("hi"), adapter.CompletionParams{})
+			if !errors.Is(err, ErrBudgetExceeded) {
+				t.Fatalf("got error %v, want %v", err, ErrBudgetExceeded)
+			}
+			if a.calls != tt.requests {
+				t.Errorf("got %d calls, want %d", a.calls, tt.requests)
+			}
+		})
+	}
+}
+
+func TestBudgetsReset(t *testing.T) {
+	day := time.Date(2024, 1, 15, 12, 0, 0, 0, time.UTC)
+
+	tests := []struct {
+		name   string
+		config BudgetConfig
+		now    time.Time
+		want   error
+	}{
+		{"tokens", BudgetConfig{DailyTokens: 100}, day, ErrBudgetExceeded},
+		{"tokens next day", BudgetConfig{DailyTokens: 100}, day.AddDate(0, 0, 1), nil},
+		{"cost", BudgetConfig{MonthlyCost: 1}, day.AddDate(0, 0, 1), ErrBudgetExceeded},
+		{"cost next month", BudgetConfig{MonthlyCost: 1}, day.AddDate(0, 1, 0), nil},
+	}
+
+	for _, tt := range tests {
+		// A check resets the spend of the past day or month, so each one
+		// starts with the spend of the same day.
+		b, err := OpenBudgets("")
+		if err != nil {
+			t.Fatal(err)
+		}
+		b.add("a", 100, 1, day)
+
+		if err := b.check("a", &tt.config, tt.now); !errors.Is(err, tt.want) {
+			t.Errorf("%s: got error %v, want %v", tt.name, err, tt.want)
+		}
+		if err := b.check("b", &tt.config, tt.now); err != nil {
+			t.Errorf("%s: got error %v for another client, want none", tt.name, err)
+		}
+	}
+}
+
+func TestBudgetsSave(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "budgets", "spend.json")
+
+	b, err := OpenBudgets(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	now := time.Now()
+	b.add("a", 100, 0.25, now)
+
+	if err := b.Save(); err != nil {
+		t.Fatal(err)
+	}
+
+	// The spend is read back from the file.
+	b, err = OpenBudgets(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	s := b.get("a", now)
+	if s.DayTokens != 100 || s.MonthCost != 0.25 {
+		t.Errorf("got %+v, want the spend saved", *s)
+	}
+}
@@ -0,0 +1,375 @@
+package client
+
+import (
+	"container/list"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/umk/llmservices/pkg/adapter"
+)
+
+// CacheConfig enables caching of the responses of the client. The responses
+// are looked up by the client, the messages or the inputs, and the
+// parameters of the request.
+type CacheConfig struct {
+	// Time the responses are kept for. Unless specified, they're kept until
+	// evicted.
+	TTL *Duration `json:"ttl,omitempty"`
+	// Whether to cache the completions with the temperature other than 0,
+	// which are not deterministic.
+	Nondeterministic bool `json:"nondeterministic,omitempty"`
+}
+
+// Cache keeps the responses by their keys.
+type Cache interface {
+	Get(key string) ([]byte, bool)
+	// Set keeps the response for the time given, or until evicted if the
+	// time is 0.
+	Set(key string, value []byte, ttl time.Duration)
+}
+
+const defaultCacheSize = 1000
+
+type cacheBypassKey struct{}
+
+// ContextWithoutCache returns the context, in which the responses are
+// neither taken from the cache nor put into it.
+func ContextWithoutCache(ctx context.Context) context.Context {
+	return context.WithValue(ctx, cacheBypassKey{}, true)
+}
+
+func isCacheBypassed(ctx context.Context) bool {
+	v, _ := ctx.Value(cacheBypassKey{}).(bool)
+	return v
+}
+
+type cacheKey struct {
+	Client    string `json:"client"`
+	Account   string `json:"account"`
+	Operation string `json:"operation"`
+	Input     any    `json:"input"`
+	Params    any    `json:"params"`
+}
+
+// getCacheKey returns the key of the request, if the response to it can be
+// cached.
+func (c *Client) getCacheKey(ctx context.Context, operation string, input, params any) (string, bool) {
+	if c.cache == nil || c.config.Cache == nil || isCacheBypassed(ctx) {
+		return "", false
+	}
+
+	// The fields of the messages and the parameters are marshaled in the
+	// same order, and the keys of the maps are sorted, so the same requests
+	// have the same keys.
+	b, err := json.Marshal(cacheKey{
+		Client:    c.name,
+		Account:   c.getAccount(),
+		Operation: operation,
+		Input:     input,
+		Params:    params,
+	})
+	if err != nil {
+		return "", false
+	}
+
+	h := sha256.Sum256(b)
+
+	return hex.EncodeToString(h[:]), true
+}
+
+// getAccount tells apart the accounts of the providers the client makes the
+// requests with. Unlike the name of the client, it cannot be made the same as
+// the one of another client without knowing its key, so the clients of the
+// sessions don't get the responses cached for the global ones.
+func (c *Client) getAccount() string {
+	if f, ok := c.adapter.(*fallbackAdapter); ok {
+		accounts := make([]string, len(f.targets))
+		for i, t := range f.targets {
+			accounts[i] = t.client.getAccount() + " " + t.model
+		}
+		return strings.Join(accounts, ",")
+	}
+
+	h := sha256.Sum256([]byte(c.config.Key))
+
+	return c.config.BaseURL + " " + hex.EncodeToString(h[:])
+}
+
+func (c *Client) getComplCacheKey(ctx context.Context, messages []adapter.Message, params *adapter.CompletionParams) (string, bool) {
+	deterministic := params.Temperature != nil && *params.Temperature == 0
+	if !deterministic && c.config.Cache != nil && !c.config.Cache.Nondeterministic {
+		return "", false
+	}
+
+	return c.getCacheKey(ctx, "completion", messages, params)
+}
+
+// getCached reads the response from the cache, if it's there.
+func (c *Client) getCached(key string, resp any) bool {
+	b, ok := c.cache.Get(key)
+	if !ok {
+		return false
+	}
+
+	return json.Unmarshal(b, resp) == nil
+}
+
+func (c *Client) setCached(key string, resp any) {
+	b, err := json.Marshal(resp)
+	if err != nil {
+		return
+	}
+
+	var ttl time.Duration
+	if c.config.Cache.TTL != nil {
+		ttl = time.Duration(*c.config.Cache.TTL)
+	}
+
+	c.cache.Set(key, b, ttl)
+}
+
+// MemoryCache keeps the most recently used responses in memory.
+type MemoryCache struct {
+	mu      sync.Mutex
+	size    int
+	entries map[string]*list.Element
+	lru     *list.List
+}
+
+type memoryCacheEntry struct {
+	key     string
+	value   []byte
+	expires time.Time
+}
+
+// NewMemoryCache creates the cache that keeps up to the number of responses
+// given, evicting the least recently used ones. If the size is not positive,
+// the default one is used.
+func NewMemoryCache(size int) *MemoryCache {
+	if size <= 0 {
+		size = defaultCacheSize
+	}
+
+	return &MemoryCache{
+		size:    size,
+		entries: make(map[string]*list.Element),
+		lru:     list.New(),
+	}
+}
+
+func (m *MemoryCache) Get(key string) ([]byte, bool) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	el, ok := m.entries[key]
+	if !ok {
+		return nil, false
+	}
+
+	e := el.Value.(*memoryCacheEntry)
+	if isExpired(e.expires) {
+		m.lru.Remove(el)
+		delete(m.entries, key)
+		return nil, false
+	}
+
+	m.lru.MoveToFront(el)
+
+	return e.value, true
+}
+
+func (m *MemoryCache) Set(key string, value []byte, ttl time.Duration) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	e := &memoryCacheEntry{key: key, value: value, expires: getExpiry(ttl)}
+
+	if el, ok := m.entries[key]; ok {
+		el.Value = e
+		m.lru.MoveToFront(el)
+		return
+	}
+
+	m.entries[key] = m.lru.PushFront(e)
+
+	for m.lru.Len() > m.size {
+		el := m.lru.Back()
+		m.lru.Remove(el)
+		delete(m.entries, el.Value.(*memoryCacheEntry).key)
+	}
+}
+
+// DiskCache keeps the responses in the files of a directory, so that they're
+// kept across restarts and shared by the processes.
+type DiskCache struct {
+	dir  string
+	size int
+
+	mu sync.Mutex
+	// Number of the responses in the directory, which is approximate if
+	// the directory is shared by the processes.
+	count int
+}
+
+type diskCacheEntry struct {
+	Expires time.Time       `json:"expires,omitzero"`
+	Value   json.RawMessage `json:"value"`
+}
+
+// OpenDiskCache creates the directory to keep up to the number of responses
+// given in, if it doesn't exist. The least recently used responses are
+// evicted. If the size is not positive, the default one is used.
+func OpenDiskCache(dir string, size int) (*DiskCache, error) {
+	if size <= 0 {
+		size = defaultCacheSize
+	}
+
+	if err := os.MkdirAll(dir, 0o755); err != nil {
+		return nil, err
+	}
+
+	d := &DiskCache{dir: dir, size: size}
+
+	files, err := d.files()
+	if err != nil {
+		return nil, err
+	}
+	d.count = len(files)
+
+	d.evict()
+
+	return d, nil
+}
+
+func (d *DiskCache) Get(key string) ([]byte, bool) {
+	p := d.path(key)
+
+	b, err := os.ReadFile(p)
+	if err != nil {
+		return nil, false
+	}
+
+	var e diskCacheEntry
+	if err := json.Unmarshal(b, &e); err != nil {
+		return nil, false
+	}
+
+	if isExpired(e.Expires) {
+		os.Remove(p)
+		return nil, false
+	}
+
+	// The time of modification tells the least recently used responses.
+	now := time.Now()
+	os.Chtimes(p, now, now)
+
+	return e.Value, true
+}
+
+func (d *DiskCache) Set(key string, value []byte, ttl time.Duration) {
+	b, err := json.Marshal(diskCacheEntry{Expires: getExpiry(ttl), Value: value})
+	if err != nil {
+		return
+	}
+
+	// The file is replaced at once, so that a concurrent read never gets
+	// a partially written response.
+	p := d.path(key)
+
+	_, err = os.Stat(p)
+	added := errors.Is(err, os.ErrNotExist)
+
+	tmp, err := os.CreateTemp(d.dir, key+".*.tmp")
+	if err != nil {
+		return
+	}
+
+	_, err = tmp.Write(b)
+	err = errors.Join(err, tmp.Close())
+	if err == nil {
+		err = os.Rename(tmp.Name(), p)
+	}
+	if err != nil {
+		os.Remove(tmp.Name())
+		return
+	}
+
+	if added {
+		d.mu.Lock()
+		defer d.mu.Unlock()
+
+		d.count++
+		d.evict()
+	}
+}
+
+// evict removes the least recently used responses, if there are more of
+// them than the size of the cache.
+func (d *DiskCache) evict() {
+	if d.count <= d.size {
+		return
+	}
+
+	files, err := d.files()
+	if err != nil {
+		return
+	}
+
+	slices.SortFunc(files, func(a, b os.FileInfo) int {
+		return a.ModTime().Compare(b.ModTime())
+	})
+
+	n := 0
+	for _, f := range files[:max(len(files)-d.size, 0)] {
+		if os.Remove(filepath.Join(d.dir, f.Name())) == nil {
+			n++
+		}
+	}
+
+	d.count = len(files) - n
+}
+
+// files returns the responses in the directory.
+func (d *DiskCache) files() ([]os.FileInfo, error) {
+	entries, err := os.ReadDir(d.dir)
+	if err != nil {
+		return nil, err
+	}
+
+	files := make([]os.FileInfo, 0, len(entries))
+	for _, e := range entries {
+		if e.IsDir() || filepath.Ext(e.Name()) != ".json" {
+			continue
+		}
+		// The file may be removed by another process in the meantime.
+		if info, err := e.Info(); err == nil {
+			files = append(files, info)
+		}
+	}
+
+	return files, nil
+}
+
+func (d *DiskCache) path(key string) string {
+	return filepath.Join(d.dir, key+".json")
+}
+
+func getExpiry(ttl time.Duration) time.Time {
+	if ttl <= 0 {
+		return time.Time{}
+	}
+	return time.Now().Add(ttl)
+}
+
+func isExpired(expires time.Time) bool {
+	return !expires.IsZero() && time.Now().After(expires)
+}
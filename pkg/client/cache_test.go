@@ -0,0 +1,195 @@
+package client
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/umk/llmservices/pkg/adapter"
+)
+
+func TestCompletionCache(t *testing.T) {
+	a := &testAdapter{resp: adapter.Completion{
+		Message: adapter.AssistantMessage{Content: ptr("ok")},
+		Usage:   &adapter.CompletionUsage{PromptTokens: 3, CompletionTokens: 1},
+	}}
+	c := newTestClient(t, &Config{Cache: &CacheConfig{}}, a)
+
+	deterministic := adapter.CompletionParams{Temperature: ptr(0.0)}
+
+	tests := []struct {
+		name       string
+		ctx        context.Context
+		content    string
+		params     adapter.CompletionParams
+		wantCached bool
+	}{
+		{"miss", context.Background(), "hi", deterministic, false},
+		{"hit", context.Background(), "hi", deterministic, true},
+		{"other message", context.Background(), "hello", deterministic, false},
+		{"nondeterministic", context.Background(), "hi", adapter.CompletionParams{}, false},
+		{"bypassed", ContextWithoutCache(context.Background()), "hi", deterministic, false},
+	}
+
+	for _, tt := range tests {
+		calls := a.calls
+
+		resp, err := c.Completion(tt.ctx, getTestMessages(tt.content), tt.params)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		if resp.Cached != tt.wantCached {
+			t.Errorf("%s: got cached %v, want %v", tt.name, resp.Cached, tt.wantCached)
+		}
+		if tt.wantCached {
+			if a.calls != calls {
+				t.Errorf("%s: the request was sent to the provider", tt.name)
+			}
+			if resp.Usage != nil {
+				t.Errorf("%s: got usage %v of the cached response", tt.name, *resp.Usage)
+			}
+		} else if a.calls != calls+1 {
+			t.Errorf("%s: the request wasn't sent to the provider", tt.name)
+		}
+	}
+}
+
+func TestEmbeddingsCache(t *testing.T) {
+	a := &testAdapter{}
+	c := newTestClient(t, &Config{Cache: &CacheConfig{}, EmbeddingsBatch: 2}, a)
+
+	if _, err := c.Embeddings(context.Background(), []string{"a", "b"}, adapter.EmbeddingsParams{}); err != nil {
+		t.Fatal(err)
+	}
+
+	// The batch of the first request is taken from the cache, and only the
+	// other one is sent.
+	resp, err := c.Embeddings(context.Background(), []string{"a", "b", "cc"}, adapter.EmbeddingsParams{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if a.calls != 2 {
+		t.Errorf("got %d calls, want 2", a.calls)
+	}
+	if resp.Cached {
+		t.Error("the response is cached, but only some of the batches are")
+	}
+	if len(resp.Data) != 3 || resp.Data[2][0] != 2 {
+		t.Errorf("got %v, want the embeddings of the inputs in order", resp.Data)
+	}
+}
+
+func TestCacheKey(t *testing.T) {
+	newClient := func(name, key string) *Client {
+		return newTestClient(t, &Config{Key: key, Cache: &CacheConfig{}}, &testAdapter{}, WithName(name))
+	}
+
+	getKey := func(c *Client, params adapter.CompletionParams) string {
+		key, ok := c.getComplCacheKey(context.Background(), getTestMessages("hi"), &params)
+		if !ok {
+			t.Fatal("the request isn't cacheable")
+		}
+		return key
+	}
+
+	// The keys of the maps are marshaled in order, so the parameters built
+	// differently have the same key.
+	params := func(keys ...string) adapter.CompletionParams {
+		schema := make(map[string]any)
+		for _, k := range keys {
+			schema[k] = k
+		}
+		return adapter.CompletionParams{
+			Temperature: ptr(0.0),
+			ResponseFormat: &adapter.ResponseFormat{
+				OfResponseFormatJSONSchema: &adapter.ResponseFormatJSONSchema{
+					JSONSchema: adapter.JSONSchema{Name: "a", Schema: schema},
+				},
+			},
+		}
+	}
+
+	c := newClient("a", "key")
+
+	key := getKey(c, params("x", "y", "z"))
+	if k := getKey(c, params("z", "y", "x")); k != key {
+		t.Error("the same requests have different keys")
+	}
+	if k := getKey(newClient("a", "key"), params("x", "y", "z")); k != key {
+		t.Error("the same requests of the same clients have different keys")
+	}
+
+	if k := getKey(c, params("x", "y")); k == key {
+		t.Error("the different requests have the same key")
+	}
+	if k := getKey(newClient("b", "key"), params("x", "y", "z")); k == key {
+		t.Error("the requests of the different clients have the same key")
+	}
+	if k := getKey(newClient("a", "other"), params("x", "y", "z")); k == key {
+		t.Error("the requests of the different accounts have the same key")
+	}
+}
+
+func TestMemoryCache(t *testing.T) {
+	m := NewMemoryCache(2)
+
+	m.Set("a", []byte("1"), 0)
+	m.Set("b", []byte("2"), 0)
+
+	// The least recently used response is evicted.
+	if _, ok := m.Get("a"); !ok {
+		t.Fatal("a is not found")
+	}
+	m.Set("c", []byte("3"), 0)
+
+	if _, ok := m.Get("b"); ok {
+		t.Error("b is not evicted")
+	}
+	if v, ok := m.Get("a"); !ok || string(v) != "1" {
+		t.Errorf("got %q, %v for a, want 1", v, ok)
+	}
+
+	m.Set("d", []byte("4"), time.Millisecond)
+	time.Sleep(5 * time.Millisecond)
+	if _, ok := m.Get("d"); ok {
+		t.Error("d is not expired")
+	}
+}
+
+func TestDiskCache(t *testing.T) {
+	dir := t.TempDir()
+
+	d, err := OpenDiskCache(dir, 2)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	d.Set("a", []byte(`{"v":1}`), 0)
+	d.Set("b", []byte(`{"v":2}`), time.Millisecond)
+	time.Sleep(5 * time.Millisecond)
+
+	// The responses are kept across the instances of the cache.
+	d, err = OpenDiskCache(dir, 2)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if v, ok := d.Get("a"); !ok || string(v) != `{"v":1}` {
+		t.Errorf("got %s, %v for a, want the response", v, ok)
+	}
+	if _, ok := d.Get("b"); ok {
+		t.Error("b is not expired")
+	}
+
+	d.Set("c", []byte(`3`), 0)
+	d.Set("d", []byte(`4`), 0)
+
+	files, err := d.files()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(files) != 2 {
+		t.Errorf("got %d responses, want 2", len(files))
+	}
+}
@@ -1,18 +1,38 @@
 package client
 
 import (
+	"fmt"
+	"log/slog"
+	"strings"
+
 	"github.com/openai/openai-go"
 	"github.com/openai/openai-go/option"
 	"github.com/umk/llmservices/pkg/adapter"
+	anthropicadapter "github.com/umk/llmservices/pkg/adapter/anthropic"
+	geminiadapter "github.com/umk/llmservices/pkg/adapter/gemini"
+	ollamaadapter "github.com/umk/llmservices/pkg/adapter/ollama"
 	openaiadapter "github.com/umk/llmservices/pkg/adapter/openai"
 	"golang.org/x/sync/semaphore"
 )
 
 type Client struct {
+	name    string
 	config  *Config
 	adapter adapter.Adapter
 	s       *semaphore.Weighted
-	Samples *Samples
+	retry   retryPolicy
+	limiter rateLimiter
+	// If set, only the models permitted by the function can be requested.
+	models   func(model string) bool
+	budgets  *Budgets
+	cache    Cache
+	observer Observer
+	logger   *slog.Logger
+	// Whether to log the messages and the completions.
+	logContent bool
+	Samples    *Samples
+	// Usage of the client by model since it was created.
+	Stats *Statistics
 }
 
 const (
@@ -21,35 +41,132 @@ const (
 	minSampleSize      = 100
 )
 
-func New(p *Config) (*Client, error) {
-	a, err := Adapter(p)
+func New(p *Config, opts ...Option) (*Client, error) {
+	var o options
+	for _, opt := range opts {
+		opt(&o)
+	}
+
+	src := p
+
+	p, err := getConfig(p)
+	if err != nil {
+		return nil, err
+	}
+
+	var a adapter.Adapter
+	if len(p.Fallback) > 0 {
+		f, err := newFallbackAdapter(p.Fallback, o.clients)
+		if err != nil {
+			return nil, err
+		}
+		// Unless specified explicitly, concurrency is limited by the clients
+		// to fall back to.
+		if src.Concurrency == 0 {
+			p.Concurrency = 0
+			for _, t := range f.targets {
+				p.Concurrency += t.client.config.Concurrency
+			}
+		}
+		// Unless specified explicitly, batches are sized to fit any of the
+		// clients to fall back to.
+		if src.EmbeddingsBatch == 0 {
+			p.EmbeddingsBatch = f.targets[0].client.config.EmbeddingsBatch
+			for _, t := range f.targets[1:] {
+				p.EmbeddingsBatch = min(p.EmbeddingsBatch, t.client.config.EmbeddingsBatch)
+			}
+		}
+		a = f
+	} else {
+		a, err = Adapter(p)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	r, err := getRetryPolicy(p.Retry)
 	if err != nil {
 		return nil, err
 	}
 
+	b := o.budgets
+	if b == nil {
+		b, _ = OpenBudgets("")
+	}
+
+	cache := o.cache
+	if cache == nil && p.Cache != nil {
+		cache = NewMemoryCache(0)
+	}
+
 	return &Client{
-		config:  p,
-		adapter: a,
-		s:       semaphore.NewWeighted(int64(p.Concurrency)),
-		Samples: NewSamples(samplesCount, defaultBytesPerTok),
+		name:       o.name,
+		config:     p,
+		adapter:    a,
+		s:          semaphore.NewWeighted(int64(p.Concurrency)),
+		retry:      r,
+		limiter:    newRateLimiter(p.RateLimit),
+		budgets:    b,
+		cache:      cache,
+		observer:   o.observer,
+		logger:     o.logger,
+		logContent: o.content,
+		Samples:    NewSamples(samplesCount, defaultBytesPerTok),
+		Stats:      NewStatistics(),
 	}, nil
 }
 
+// Name returns the name the usage of the client is accounted by.
+func (c *Client) Name() string {
+	return c.name
+}
+
+// Model returns the model used for the requests that don't specify one.
+func (c *Client) Model() string {
+	return c.config.Model
+}
+
+// WithModels returns a copy of the client that rejects the requests for the
+// models not permitted by the function. The copy shares the limits and the
+// statistics with the original client.
+func (c *Client) WithModels(allowed func(model string) bool) *Client {
+	r := *c
+	r.models = allowed
+	return &r
+}
+
+func (c *Client) checkModel(model string) error {
+	if c.models != nil && !c.models(model) {
+		return fmt.Errorf("%w: %s", ErrModelNotPermitted, model)
+	}
+	return nil
+}
+
 func Adapter(p *Config) (adapter.Adapter, error) {
 	if p.Preset == nil {
 		return AdapterOpenAI(p)
 	}
 
 	switch *p.Preset {
-	case OpenAI, Ollama:
+	case OpenAI:
 		return AdapterOpenAI(p)
+	case Ollama:
+		return AdapterOllama(p)
+	case Anthropic:
+		return AdapterAnthropic(p)
+	case Gemini:
+		return AdapterGemini(p)
 	default:
-		panic("preset is not supported")
+		return nil, fmt.Errorf("preset is not supported: %s", *p.Preset)
 	}
 }
 
 func AdapterOpenAI(p *Config) (adapter.Adapter, error) {
-	p, err := getConfig(p, OpenAI, Ollama)
+	if err := checkPreset(p, OpenAI); err != nil {
+		return nil, err
+	}
+
+	p, err := getConfig(p)
 	if err != nil {
 		return nil, err
 	}
@@ -62,8 +179,83 @@ func AdapterOpenAI(p *Config) (adapter.Adapter, error) {
 	if p.Key != "" {
 		opts = append(opts, option.WithAPIKey(p.Key))
 	}
+	if p.Retry != nil {
+		// Retries are made by the client according to its policy.
+		opts = append(opts, option.WithMaxRetries(0))
+	}
 
 	return &openaiadapter.Adapter{
 		Client: openai.NewClient(opts...),
 	}, nil
 }
+
+func AdapterAnthropic(p *Config) (adapter.Adapter, error) {
+	if err := checkPreset(p, Anthropic); err != nil {
+		return nil, err
+	}
+
+	p, err := getConfig(p)
+	if err != nil {
+		return nil, err
+	}
+
+	return &anthropicadapter.Adapter{
+		BaseURL: p.BaseURL,
+		Key:     p.Key,
+	}, nil
+}
+
+func AdapterGemini(p *Config) (adapter.Adapter, error) {
+	if err := checkPreset(p, Gemini); err != nil {
+		return nil, err
+	}
+
+	p, err := getConfig(p)
+	if err != nil {
+		return nil, err
+	}
+
+	return &geminiadapter.Adapter{
+		BaseURL: p.BaseURL,
+		Key:     p.Key,
+	}, nil
+}
+
+func AdapterOllama(p *Config) (adapter.Adapter, error) {
+	if err := checkPreset(p, Ollama); err != nil {
+		return nil, err
+	}
+
+	p, err := getConfig(p)
+	if err != nil {
+		return nil, err
+	}
+
+	a := &ollamaadapter.Adapter{
+		BaseURL: getOllamaBaseURL(p.BaseURL),
+		Key:     p.Key,
+	}
+
+	if o := p.Ollama; o != nil {
+		a.KeepAlive = o.KeepAlive
+		a.Options = make(map[string]any, len(o.Options)+1)
+		for k, v := range o.Options {
+			a.Options[k] = v
+		}
+		if o.NumCtx != nil {
+			a.Options["num_ctx"] = *o.NumCtx
+		}
+	}
+
+	return a, nil
+}
+
+// getOllamaBaseURL returns the base URL of the native API of Ollama. The URL
+// of its OpenAI-compatible API, which ends with /v1/, is accepted as well.
+func getOllamaBaseURL(baseURL string) string {
+	u := strings.TrimSuffix(baseURL, "/")
+	if r, ok := strings.CutSuffix(u, "/v1"); ok {
+		return r + "/"
+	}
+	return baseURL
+}
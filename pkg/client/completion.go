@@ -2,33 +2,161 @@ package client
 
 import (
 	"context"
+	"time"
 
 	"github.com/umk/llmservices/pkg/adapter"
+	semconv "go.opentelemetry.io/otel/semconv/v1.30.0"
 )
 
 func (c *Client) Completion(ctx context.Context, messages []adapter.Message, params adapter.CompletionParams) (
 	adapter.Completion, error,
 ) {
-	if err := c.s.Acquire(ctx, 1); err != nil {
-		return adapter.Completion{}, err
-	}
-	defer c.s.Release(1)
+	return c.CompletionStream(ctx, messages, params, nil)
+}
 
+// CompletionStream is the same as Completion, but also passes the chunks of
+// completion to the handler as soon as they are generated. If the adapter
+// doesn't support streaming, the whole completion is passed as a single chunk.
+// If the handler is nil, the completion is not streamed.
+func (c *Client) CompletionStream(
+	ctx context.Context,
+	messages []adapter.Message,
+	params adapter.CompletionParams,
+	handler adapter.DeltaHandler,
+) (adapter.Completion, error) {
 	// If the model is not set, use the default one
 	if params.Model == "" {
 		params.Model = c.config.Model
 	}
 
-	resp, err := c.adapter.Completion(ctx, messages, params)
+	if err := c.checkModel(params.Model); err != nil {
+		return adapter.Completion{}, err
+	}
+
+	key, cacheable := c.getComplCacheKey(ctx, messages, &params)
+	if cacheable {
+		if resp, ok := c.getCachedCompl(ctx, key, messages, params.Model); ok {
+			if handler != nil {
+				if err := handler(ctx, resp.Delta()); err != nil {
+					return adapter.Completion{}, err
+				}
+			}
+			return resp, nil
+		}
+	}
+
+	if err := c.checkBudget(ctx); err != nil {
+		return adapter.Completion{}, err
+	}
+
+	// Once a chunk of completion is passed to the handler, the request
+	// cannot be retried.
+	streamed := false
+	if handler != nil {
+		h := handler
+		handler = func(ctx context.Context, delta adapter.CompletionDelta) error {
+			streamed = true
+			return h(ctx, delta)
+		}
+	}
+
+	toks := c.getEstimatedComplTokens(messages, params)
+	start := time.Now()
+
+	var resp adapter.Completion
+	// The slot is held by each attempt rather than by the whole request, so
+	// that other requests can run while the request waits to retry.
+	attempts, err := c.retry.do(ctx, func() error {
+		if err := c.acquire(ctx); err != nil {
+			return permanentError{err}
+		}
+		defer c.s.Release(1)
+		taken, err := c.limiter.wait(ctx, toks)
+		if err != nil {
+			return permanentError{err}
+		}
+		t := time.Now()
+		ctx, span := c.startSpan(ctx, semconv.GenAIOperationNameChat, params.Model, getCompletionAttributes(&params)...)
+		resp, err = c.getCompletion(ctx, messages, params, handler)
+		endCompletionSpan(span, &resp, err)
+		c.observeAttempt(params.Model, "completion", t, err)
+		if err == nil && resp.Usage != nil {
+			c.limiter.reconcile(taken, resp.Usage.PromptTokens+resp.Usage.CompletionTokens)
+		}
+		if err != nil && streamed {
+			return permanentError{err}
+		}
+		return err
+	})
+
+	c.recordCompletion(ctx, params.Model, start, &resp, attempts, err)
+	c.logCompletion(ctx, params.Model, start, messages, &resp, attempts, err)
 
 	if err == nil {
+		// Adapters made of other clients report attempts on their own.
+		resp.Attempts = max(resp.Attempts, 1) + attempts - 1
 		c.setSamplesFromCompl(&resp)
+
+		if cacheable {
+			c.setCached(key, &resp)
+		}
 	}
 
 	return resp, err
 }
 
+func (c *Client) getCachedCompl(ctx context.Context, key string, messages []adapter.Message, model string) (
+	adapter.Completion, bool,
+) {
+	start := time.Now()
+
+	var resp adapter.Completion
+	if !c.getCached(key, &resp) {
+		return adapter.Completion{}, false
+	}
+
+	// The tokens were used by the request the response is cached for.
+	resp.Usage = nil
+	resp.Attempts = 0
+	resp.Cached = true
+
+	c.recordCacheHit(ctx, model, start)
+	c.logCompletion(ctx, model, start, messages, &resp, 0, nil)
+
+	return resp, true
+}
+
+func (c *Client) getCompletion(
+	ctx context.Context,
+	messages []adapter.Message,
+	params adapter.CompletionParams,
+	handler adapter.DeltaHandler,
+) (adapter.Completion, error) {
+	if handler == nil {
+		return c.adapter.Completion(ctx, messages, params)
+	}
+
+	if s, ok := c.adapter.(adapter.CompletionStreamer); ok {
+		return s.CompletionStream(ctx, messages, params, handler)
+	}
+
+	resp, err := c.adapter.Completion(ctx, messages, params)
+	if err != nil {
+		return adapter.Completion{}, err
+	}
+
+	if err := handler(ctx, resp.Delta()); err != nil {
+		return adapter.Completion{}, err
+	}
+
+	return resp, nil
+}
+
 func (c *Client) setSamplesFromCompl(resp *adapter.Completion) {
+	if resp.Usage == nil {
+		return
+	}
+
 	toks := resp.Usage.CompletionTokens
 	if toks == 0 {
 		return
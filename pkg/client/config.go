@@ -13,15 +13,53 @@ type Config struct {
 	Model   string `json:"model" validate:"omitempty"`
 
 	Concurrency int `json:"concurrency" validate:"omitempty,min=1"`
+
+	// Maximum number of inputs to get embeddings for in a single request.
+	EmbeddingsBatch int `json:"embeddings_batch" validate:"omitempty,min=1"`
+
+	Retry *RetryConfig `json:"retry,omitempty"`
+
+	// Requests that would exceed the limits wait until they can be sent.
+	RateLimit *RateLimitConfig `json:"rate_limit,omitempty"`
+
+	// Clients to send requests to in order, until one of them succeeds.
+	// If specified, the client doesn't connect to a provider by itself.
+	Fallback []FallbackTarget `json:"fallback,omitempty" validate:"omitempty,dive"`
+
+	// Prices of the models to account the spend with. The key * applies to
+	// the models that are not listed.
+	Pricing map[string]Price `json:"pricing,omitempty" validate:"omitempty,dive"`
+
+	Budget *BudgetConfig `json:"budget,omitempty"`
+
+	Cache *CacheConfig `json:"cache,omitempty"`
+
+	Ollama *OllamaConfig `json:"ollama,omitempty"`
 }
 
-func getConfig(src *Config, allowed ...Preset) (*Config, error) {
-	if src.Preset != nil && slices.Contains(allowed, *src.Preset) {
-		return nil, fmt.Errorf("preset is not supported: %s", *src.Preset)
+// OllamaConfig contains the options specific for the native Ollama API.
+type OllamaConfig struct {
+	// Size of the context window in tokens.
+	NumCtx *int `json:"num_ctx,omitempty" validate:"omitempty,min=1"`
+	// Duration the model stays loaded in memory after the request, like "5m".
+	KeepAlive *string `json:"keep_alive,omitempty"`
+	// Other options of the model, like "seed" or "num_gpu".
+	Options map[string]any `json:"options,omitempty"`
+}
+
+func checkPreset(src *Config, allowed ...Preset) error {
+	if src.Preset != nil && !slices.Contains(allowed, *src.Preset) {
+		return fmt.Errorf("preset is not supported: %s", *src.Preset)
 	}
 
+	return nil
+}
+
+func getConfig(src *Config) (*Config, error) {
 	dest := Config{
-		Concurrency: 1,
+		Preset:          src.Preset,
+		Concurrency:     1,
+		EmbeddingsBatch: 1,
 	}
 
 	if src.Preset != nil {
@@ -63,5 +101,37 @@ func setConfig(dest *Config, src *Config) error {
 		dest.Concurrency = src.Concurrency
 	}
 
+	if src.EmbeddingsBatch > 0 {
+		dest.EmbeddingsBatch = src.EmbeddingsBatch
+	}
+
+	if src.Retry != nil {
+		dest.Retry = src.Retry
+	}
+
+	if src.RateLimit != nil {
+		dest.RateLimit = src.RateLimit
+	}
+
+	if len(src.Fallback) > 0 {
+		dest.Fallback = src.Fallback
+	}
+
+	if src.Ollama != nil {
+		dest.Ollama = src.Ollama
+	}
+
+	if src.Pricing != nil {
+		dest.Pricing = src.Pricing
+	}
+
+	if src.Budget != nil {
+		dest.Budget = src.Budget
+	}
+
+	if src.Cache != nil {
+		dest.Cache = src.Cache
+	}
+
 	return nil
 }
@@ -0,0 +1,24 @@
+package client
+
+import (
+	"fmt"
+	"time"
+)
+
+// Duration is a time.Duration that is represented in configuration as
+// a string, like "1.5s" or "100ms".
+type Duration time.Duration
+
+func (d Duration) MarshalText() ([]byte, error) {
+	return []byte(time.Duration(d).String()), nil
+}
+
+func (d *Duration) UnmarshalText(text []byte) error {
+	v, err := time.ParseDuration(string(text))
+	if err != nil {
+		return fmt.Errorf("invalid duration: %w", err)
+	}
+
+	*d = Duration(v)
+	return nil
+}
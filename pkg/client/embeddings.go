@@ -2,39 +2,176 @@ package client
 
 import (
 	"context"
+	"fmt"
+	"time"
 
 	"github.com/umk/llmservices/pkg/adapter"
+	semconv "go.opentelemetry.io/otel/semconv/v1.30.0"
+	"golang.org/x/sync/errgroup"
 )
 
-func (c *Client) Embeddings(ctx context.Context, input string, params adapter.EmbeddingsParams) (
+// Embeddings returns the embeddings of the inputs in the same order as the
+// inputs. The inputs are split into batches of the size supported by the
+// provider, which are sent concurrently.
+func (c *Client) Embeddings(ctx context.Context, input []string, params adapter.EmbeddingsParams) (
 	adapter.Embeddings, error,
 ) {
-	if err := c.s.Acquire(ctx, 1); err != nil {
-		return adapter.Embeddings{}, err
-	}
-	defer c.s.Release(1)
-
 	// If the model is not set, use the default one
 	if params.Model == "" {
 		params.Model = c.config.Model
 	}
 
-	resp, err := c.adapter.Embeddings(ctx, input, params)
+	if err := c.checkModel(params.Model); err != nil {
+		return adapter.Embeddings{}, err
+	}
+
+	if err := c.checkBudget(ctx); err != nil {
+		return adapter.Embeddings{}, err
+	}
+
+	n := c.config.EmbeddingsBatch
+
+	batches := make([]adapter.Embeddings, (len(input)+n-1)/n)
+
+	g, ctx := errgroup.WithContext(ctx)
+	for i := range batches {
+		batch := input[i*n : min((i+1)*n, len(input))]
+		g.Go(func() error {
+			var err error
+			batches[i], err = c.getEmbeddings(ctx, batch, params)
+			return err
+		})
+	}
+
+	if err := g.Wait(); err != nil {
+		return adapter.Embeddings{}, err
+	}
+
+	return getEmbeddingsFromBatches(batches), nil
+}
+
+func (c *Client) getEmbeddings(ctx context.Context, input []string, params adapter.EmbeddingsParams) (
+	adapter.Embeddings, error,
+) {
+	key, cacheable := c.getCacheKey(ctx, "embeddings", input, &params)
+	if cacheable {
+		if resp, ok := c.getCachedEmbeddings(ctx, key, input, params.Model); ok {
+			return resp, nil
+		}
+	}
+
+	toks := c.getEstimatedEmbeddingTokens(input)
+	start := time.Now()
+
+	var resp adapter.Embeddings
+	// The slot is held by each attempt rather than by the whole request, so
+	// that other requests can run while the request waits to retry.
+	attempts, err := c.retry.do(ctx, func() error {
+		if err := c.acquire(ctx); err != nil {
+			return permanentError{err}
+		}
+		defer c.s.Release(1)
+		taken, err := c.limiter.wait(ctx, toks)
+		if err != nil {
+			return permanentError{err}
+		}
+		t := time.Now()
+		ctx, span := c.startSpan(ctx, semconv.GenAIOperationNameEmbeddings, params.Model)
+		resp, err = c.adapter.Embeddings(ctx, input, params)
+		endEmbeddingsSpan(span, &resp, err)
+		c.observeAttempt(params.Model, "embeddings", t, err)
+		if err == nil && resp.Usage != nil {
+			c.limiter.reconcile(taken, resp.Usage.PromptTokens)
+		}
+		return err
+	})
+
+	if err == nil && len(resp.Data) != len(input) {
+		err = fmt.Errorf("unexpected number of embeddings: %d", len(resp.Data))
+	}
+
+	c.recordEmbeddings(ctx, params.Model, start, &resp, attempts, err)
+	c.logEmbeddings(ctx, params.Model, start, input, &resp, attempts, err)
 
 	if err == nil {
+		// Adapters made of other clients report attempts on their own.
+		resp.Attempts = max(resp.Attempts, 1) + attempts - 1
 		c.setSamplesFromEmbedding(input, &resp)
+
+		if cacheable {
+			c.setCached(key, &resp)
+		}
 	}
 
 	return resp, err
 }
 
-func (c *Client) setSamplesFromEmbedding(input string, resp *adapter.Embeddings) {
+func (c *Client) getCachedEmbeddings(ctx context.Context, key string, input []string, model string) (
+	adapter.Embeddings, bool,
+) {
+	start := time.Now()
+
+	var resp adapter.Embeddings
+	if !c.getCached(key, &resp) || len(resp.Data) != len(input) {
+		return adapter.Embeddings{}, false
+	}
+
+	// The tokens were used by the request the response is cached for.
+	resp.Usage = nil
+	resp.Attempts = 0
+	resp.Cached = true
+
+	c.recordCacheHit(ctx, model, start)
+	c.logEmbeddings(ctx, model, start, input, &resp, 0, nil)
+
+	return resp, true
+}
+
+func getEmbeddingsFromBatches(batches []adapter.Embeddings) adapter.Embeddings {
+	if len(batches) == 1 {
+		return batches[0]
+	}
+
+	resp := adapter.Embeddings{Cached: true}
+	for _, b := range batches {
+		resp.Data = append(resp.Data, b.Data...)
+		resp.Cached = resp.Cached && b.Cached
+		resp.Attempts += b.Attempts
+
+		if b.Usage != nil {
+			if resp.Usage == nil {
+				resp.Usage = &adapter.EmbeddingsUsage{}
+			}
+			resp.Usage.PromptTokens += b.Usage.PromptTokens
+		}
+
+		if resp.Backend == "" {
+			resp.Backend = b.Backend
+		}
+	}
+
+	return resp
+}
+
+func (c *Client) setSamplesFromEmbedding(input []string, resp *adapter.Embeddings) {
+	if resp.Usage == nil {
+		return
+	}
+
 	toks := resp.Usage.PromptTokens
 	if toks == 0 {
 		return
 	}
 
-	if b := len(input); b >= minSampleSize {
+	if b := getInputSize(input); b >= minSampleSize {
 		c.Samples.Put(float32(b) / float32(toks))
 	}
 }
+
+func getInputSize(input []string) int {
+	var size int
+	for _, v := range input {
+		size += len(v)
+	}
+	return size
+}
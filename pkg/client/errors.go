@@ -1,5 +1,11 @@
 package client
 
-import "errors"
+import (
+	"errors"
 
-var ErrNotSupportedByAdapter = errors.New("operation is not supported by adapter")
+	"github.com/umk/llmservices/pkg/adapter"
+)
+
+var ErrNotSupportedByAdapter = adapter.ErrNotSupported
+
+var ErrModelNotPermitted = errors.New("model is not permitted")
@@ -0,0 +1,152 @@
+package client
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/umk/llmservices/pkg/adapter"
+)
+
+type FallbackTarget struct {
+	// ID of the client to send requests to.
+	ClientID string `json:"client_id" validate:"required"`
+	// Model to use with the client instead of the requested one.
+	Model string `json:"model,omitempty"`
+	// Time given to the client to respond before falling back to the next one.
+	Timeout *Duration `json:"timeout,omitempty"`
+}
+
+// fallbackAdapter sends requests to the clients in order, until one of them
+// succeeds.
+type fallbackAdapter struct {
+	targets []fallbackTarget
+}
+
+type fallbackTarget struct {
+	id      string
+	client  *Client
+	model   string
+	timeout time.Duration
+}
+
+func newFallbackAdapter(targets []FallbackTarget, clients func(clientID string) (*Client, error)) (*fallbackAdapter, error) {
+	if clients == nil {
+		return nil, errors.New("clients to fall back to cannot be resolved")
+	}
+
+	a := &fallbackAdapter{}
+	for _, t := range targets {
+		c, err := clients(t.ClientID)
+		if err != nil {
+			return nil, fmt.Errorf("failed to get client %q: %w", t.ClientID, err)
+		}
+
+		target := fallbackTarget{
+			id:     t.ClientID,
+			client: c,
+			model:  t.Model,
+		}
+		if t.Timeout != nil {
+			target.timeout = time.Duration(*t.Timeout)
+		}
+
+		a.targets = append(a.targets, target)
+	}
+
+	return a, nil
+}
+
+func (a *fallbackAdapter) Completion(ctx context.Context, messages []adapter.Message, params adapter.CompletionParams) (adapter.Completion, error) {
+	return a.CompletionStream(ctx, messages, params, nil)
+}
+
+func (a *fallbackAdapter) CompletionStream(ctx context.Context, messages []adapter.Message, params adapter.CompletionParams, handler adapter.DeltaHandler) (adapter.Completion, error) {
+	var errs []error
+
+	for i, t := range a.targets {
+		// Once a chunk of completion is passed to the handler, the request
+		// cannot be sent to another client.
+		streamed := false
+
+		var h adapter.DeltaHandler
+		if handler != nil {
+			h = func(ctx context.Context, delta adapter.CompletionDelta) error {
+				streamed = true
+				return handler(ctx, delta)
+			}
+		}
+
+		p := params
+		if t.model != "" {
+			p.Model = t.model
+		}
+
+		var resp adapter.Completion
+		err := t.call(ctx, func(ctx context.Context) error {
+			var err error
+			resp, err = t.client.CompletionStream(ctx, messages, p, h)
+			return err
+		})
+		if err == nil {
+			setFallbackResponse(&resp.Backend, &resp.Attempts, t.id, i)
+			return resp, nil
+		}
+
+		errs = append(errs, fmt.Errorf("%s: %w", t.id, err))
+		if streamed || ctx.Err() != nil {
+			break
+		}
+	}
+
+	return adapter.Completion{}, errors.Join(errs...)
+}
+
+func (a *fallbackAdapter) Embeddings(ctx context.Context, input []string, params adapter.EmbeddingsParams) (adapter.Embeddings, error) {
+	var errs []error
+
+	for i, t := range a.targets {
+		p := params
+		if t.model != "" {
+			p.Model = t.model
+		}
+
+		var resp adapter.Embeddings
+		err := t.call(ctx, func(ctx context.Context) error {
+			var err error
+			resp, err = t.client.Embeddings(ctx, input, p)
+			return err
+		})
+		if err == nil {
+			setFallbackResponse(&resp.Backend, &resp.Attempts, t.id, i)
+			return resp, nil
+		}
+
+		errs = append(errs, fmt.Errorf("%s: %w", t.id, err))
+		if ctx.Err() != nil {
+			break
+		}
+	}
+
+	return adapter.Embeddings{}, errors.Join(errs...)
+}
+
+func (t *fallbackTarget) call(ctx context.Context, fn func(ctx context.Context) error) error {
+	if t.timeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, t.timeout)
+		defer cancel()
+	}
+
+	return fn(ctx)
+}
+
+// setFallbackResponse records the client that has responded, unless it was
+// recorded by a nested fallback, and counts the failed clients as attempts.
+func setFallbackResponse(backend *string, attempts *int, clientID string, failed int) {
+	if *backend == "" {
+		*backend = clientID
+	}
+	*attempts += failed
+}
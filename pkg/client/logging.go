@@ -0,0 +1,117 @@
+package client
+
+import (
+	"context"
+	"encoding/json"
+	"log/slog"
+	"time"
+
+	"github.com/umk/llmservices/pkg/adapter"
+)
+
+func (c *Client) logCompletion(
+	ctx context.Context,
+	model string,
+	start time.Time,
+	messages []adapter.Message,
+	resp *adapter.Completion,
+	attempts int,
+	err error,
+) {
+	if c.logger == nil {
+		return
+	}
+
+	attrs := c.getLogAttrs(model, "completion", start, attempts)
+
+	if err == nil {
+		if resp.Usage != nil {
+			attrs = append(attrs,
+				slog.Int64("prompt_tokens", resp.Usage.PromptTokens),
+				slog.Int64("completion_tokens", resp.Usage.CompletionTokens))
+		}
+		if resp.FinishReason != "" {
+			attrs = append(attrs, slog.String("finish_reason", resp.FinishReason))
+		}
+		if resp.Backend != "" {
+			attrs = append(attrs, slog.String("backend", resp.Backend))
+		}
+		if resp.Cached {
+			attrs = append(attrs, slog.Bool("cached", true))
+		}
+	}
+
+	if c.logContent {
+		attrs = append(attrs, slog.String("messages", getLogJSON(messages)))
+		if err == nil {
+			attrs = append(attrs, slog.String("completion", getLogJSON(resp.Message)))
+		}
+	}
+
+	c.log(ctx, "completion", attrs, err)
+}
+
+func (c *Client) logEmbeddings(
+	ctx context.Context,
+	model string,
+	start time.Time,
+	input []string,
+	resp *adapter.Embeddings,
+	attempts int,
+	err error,
+) {
+	if c.logger == nil {
+		return
+	}
+
+	attrs := c.getLogAttrs(model, "embeddings", start, attempts)
+	attrs = append(attrs, slog.Int("inputs", len(input)))
+
+	if err == nil {
+		if resp.Usage != nil {
+			attrs = append(attrs, slog.Int64("prompt_tokens", resp.Usage.PromptTokens))
+		}
+		if resp.Backend != "" {
+			attrs = append(attrs, slog.String("backend", resp.Backend))
+		}
+		if resp.Cached {
+			attrs = append(attrs, slog.Bool("cached", true))
+		}
+	}
+
+	if c.logContent {
+		attrs = append(attrs, slog.String("input", getLogJSON(input)))
+	}
+
+	c.log(ctx, "embeddings", attrs, err)
+}
+
+func (c *Client) getLogAttrs(model, operation string, start time.Time, attempts int) []slog.Attr {
+	return []slog.Attr{
+		slog.String("client", c.name),
+		slog.String("model", model),
+		slog.String("operation", operation),
+		slog.Duration("latency", time.Since(start)),
+		slog.Int("attempts", attempts),
+	}
+}
+
+func (c *Client) log(ctx context.Context, msg string, attrs []slog.Attr, err error) {
+	level := slog.LevelInfo
+	if err != nil {
+		level = slog.LevelError
+		attrs = append(attrs, slog.String("error", err.Error()))
+	}
+
+	c.logger.LogAttrs(ctx, level, msg, attrs...)
+}
+
+// getLogJSON returns the value as JSON to log, so that the content can be
+// masked before it's written.
+func getLogJSON(v any) string {
+	b, err := json.Marshal(v)
+	if err != nil {
+		return err.Error()
+	}
+	return string(b)
+}
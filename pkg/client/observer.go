@@ -0,0 +1,41 @@
+package client
+
+import (
+	"context"
+	"time"
+)
+
+// Observer receives the measurements of the requests made by the client,
+// like to export them as metrics.
+type Observer interface {
+	// ObserveWait is called once a request acquires a slot to run in among
+	// the concurrent requests of the client.
+	ObserveWait(clientID string, wait time.Duration)
+	// ObserveAttempt is called once an attempt of a request to the provider
+	// is completed. The operation is either "completion" or "embeddings".
+	ObserveAttempt(clientID, model, operation string, latency time.Duration, err error)
+	// ObserveUsage is called once a request is completed with all of its
+	// attempts.
+	ObserveUsage(clientID, model string, usage *Usage)
+}
+
+// acquire waits for a slot to run the request in.
+func (c *Client) acquire(ctx context.Context) error {
+	start := time.Now()
+
+	if err := c.s.Acquire(ctx, 1); err != nil {
+		return err
+	}
+
+	if c.observer != nil {
+		c.observer.ObserveWait(c.name, time.Since(start))
+	}
+
+	return nil
+}
+
+func (c *Client) observeAttempt(model, operation string, start time.Time, err error) {
+	if c.observer != nil {
+		c.observer.ObserveAttempt(c.name, model, operation, time.Since(start), err)
+	}
+}
@@ -0,0 +1,65 @@
+package client
+
+import "log/slog"
+
+type Option func(*options)
+
+type options struct {
+	name     string
+	clients  func(clientID string) (*Client, error)
+	budgets  *Budgets
+	observer Observer
+	logger   *slog.Logger
+	content  bool
+	cache    Cache
+}
+
+// WithName specifies the name the usage of the client is accounted by,
+// which is usually the ID of the client.
+func WithName(name string) Option {
+	return func(o *options) {
+		o.name = name
+	}
+}
+
+// WithClients specifies the function to resolve other clients by their IDs,
+// which is required to create a client that falls back to other clients.
+func WithClients(clients func(clientID string) (*Client, error)) Option {
+	return func(o *options) {
+		o.clients = clients
+	}
+}
+
+// WithBudgets specifies where the spend of the client is kept to check its
+// budget against. Unless specified, the spend is kept only by the client.
+func WithBudgets(budgets *Budgets) Option {
+	return func(o *options) {
+		o.budgets = budgets
+	}
+}
+
+// WithObserver specifies the observer of the requests made by the client.
+func WithObserver(observer Observer) Option {
+	return func(o *options) {
+		o.observer = observer
+	}
+}
+
+// WithLogger specifies the logger to record the requests made by the client
+// with. If content is true, the messages and the completions are recorded
+// as well.
+func WithLogger(logger *slog.Logger, content bool) Option {
+	return func(o *options) {
+		o.logger = logger
+		o.content = content
+	}
+}
+
+// WithCache specifies the cache to keep the responses of the client in, if
+// caching is enabled by its configuration. Unless specified, the responses
+// are kept in memory by the client.
+func WithCache(cache Cache) Option {
+	return func(o *options) {
+		o.cache = cache
+	}
+}
@@ -3,22 +3,38 @@ package client
 type Preset string
 
 const (
-	OpenAI Preset = "openai"
-	Ollama Preset = "ollama"
+	OpenAI    Preset = "openai"
+	Ollama    Preset = "ollama"
+	Anthropic Preset = "anthropic"
+	Gemini    Preset = "gemini"
 )
 
 var presetOpenAI = Config{
-	BaseURL:     "https://api.openai.com/v1/",
-	Concurrency: 5,
+	BaseURL:         "https://api.openai.com/v1/",
+	Concurrency:     5,
+	EmbeddingsBatch: 2048,
 }
 
 var presetOllama = Config{
-	BaseURL:     "http://localhost:11434/v1/",
-	Key:         "ollama",
-	Concurrency: 1,
+	BaseURL:         "http://localhost:11434/",
+	Concurrency:     1,
+	EmbeddingsBatch: 512,
+}
+
+var presetAnthropic = Config{
+	BaseURL:     "https://api.anthropic.com/v1/",
+	Concurrency: 5,
+}
+
+var presetGemini = Config{
+	BaseURL:         "https://generativelanguage.googleapis.com/v1beta/",
+	Concurrency:     5,
+	EmbeddingsBatch: 100,
 }
 
 var presets = map[Preset]Config{
-	OpenAI: presetOpenAI,
-	Ollama: presetOllama,
+	OpenAI:    presetOpenAI,
+	Ollama:    presetOllama,
+	Anthropic: presetAnthropic,
+	Gemini:    presetGemini,
 }
@@ -0,0 +1,168 @@
+package client
+
+import (
+	"context"
+	"math"
+	"sync"
+	"time"
+
+	"github.com/umk/llmservices/pkg/adapter"
+)
+
+type RateLimitConfig struct {
+	// Maximum number of requests per minute.
+	RequestsPerMinute int `json:"requests_per_minute" validate:"omitempty,min=1"`
+	// Maximum number of prompt and completion tokens per minute.
+	TokensPerMinute int `json:"tokens_per_minute" validate:"omitempty,min=1"`
+}
+
+// rateLimiter delays the requests to the provider, so that they don't exceed
+// the number of requests and tokens per minute. Limits that aren't set are
+// not enforced.
+type rateLimiter struct {
+	requests *bucket
+	tokens   *bucket
+}
+
+func newRateLimiter(config *RateLimitConfig) rateLimiter {
+	var l rateLimiter
+	if config == nil {
+		return l
+	}
+
+	if config.RequestsPerMinute > 0 {
+		l.requests = newBucket(config.RequestsPerMinute)
+	}
+	if config.TokensPerMinute > 0 {
+		l.tokens = newBucket(config.TokensPerMinute)
+	}
+
+	return l
+}
+
+// wait blocks until both a request and the estimated number of tokens can
+// be sent to the provider. Returns the number of tokens taken, which is less
+// than the estimated one if it exceeds the capacity of the bucket.
+func (l *rateLimiter) wait(ctx context.Context, toks int64) (int64, error) {
+	if l.requests != nil {
+		if _, err := l.requests.wait(ctx, 1); err != nil {
+			return 0, err
+		}
+	}
+
+	if l.tokens == nil {
+		return toks, nil
+	}
+
+	taken, err := l.tokens.wait(ctx, float64(toks))
+	if err != nil {
+		if l.requests != nil {
+			l.requests.put(1)
+		}
+		return 0, err
+	}
+
+	return int64(taken), nil
+}
+
+// reconcile corrects the number of tokens taken by the request, once the
+// actual number is known.
+func (l *rateLimiter) reconcile(taken, actual int64) {
+	if l.tokens != nil {
+		l.tokens.put(float64(taken - actual))
+	}
+}
+
+// bucket implements the token bucket, which is refilled at a constant rate
+// up to its capacity. The bucket can go into debt, in which case the next
+// callers wait until it's paid off.
+type bucket struct {
+	mu sync.Mutex
+
+	capacity float64
+	rate     float64 // per second
+	tokens   float64
+	last     time.Time
+}
+
+func newBucket(perMinute int) *bucket {
+	return &bucket{
+		capacity: float64(perMinute),
+		rate:     float64(perMinute) / 60,
+		tokens:   float64(perMinute),
+		last:     time.Now(),
+	}
+}
+
+// wait takes n tokens from the bucket, and blocks until the bucket is out of
+// debt. Returns the number of tokens taken.
+func (b *bucket) wait(ctx context.Context, n float64) (float64, error) {
+	// A request larger than the bucket would never fit in, so only wait
+	// until the bucket is full.
+	n = min(n, b.capacity)
+
+	b.mu.Lock()
+	b.refill()
+	b.tokens -= n
+	debt := -b.tokens
+	b.mu.Unlock()
+
+	if debt <= 0 {
+		return n, nil
+	}
+
+	d := time.Duration(math.Ceil(debt / b.rate * float64(time.Second)))
+
+	t := time.NewTimer(d)
+	defer t.Stop()
+
+	select {
+	case <-t.C:
+		return n, nil
+	case <-ctx.Done():
+		b.put(n)
+		return 0, ctx.Err()
+	}
+}
+
+// put returns the tokens to the bucket, or takes them if n is negative.
+func (b *bucket) put(n float64) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	b.refill()
+	b.tokens = min(b.tokens+n, b.capacity)
+}
+
+func (b *bucket) refill() {
+	now := time.Now()
+	b.tokens = min(b.tokens+now.Sub(b.last).Seconds()*b.rate, b.capacity)
+	b.last = now
+}
+
+func (c *Client) getEstimatedComplTokens(messages []adapter.Message, params adapter.CompletionParams) int64 {
+	var size int64
+	for i := range messages {
+		size += adapter.GetEstimatedSize(&messages[i])
+	}
+	for _, t := range params.Tools {
+		size += int64(len(t.Function.Name))
+		if t.Function.Description != nil {
+			size += int64(len(*t.Function.Description))
+		}
+	}
+
+	toks := int64(float32(size) / c.Samples.BytesPerTok())
+
+	// Providers count the maximum number of completion tokens against
+	// the limit up front.
+	if params.MaxTokens != nil {
+		toks += *params.MaxTokens
+	}
+
+	return toks
+}
+
+func (c *Client) getEstimatedEmbeddingTokens(input []string) int64 {
+	return int64(float32(getInputSize(input)) / c.Samples.BytesPerTok())
+}
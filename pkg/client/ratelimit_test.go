@@ -0,0 +1,120 @@
+package client
+
+import (
+	"context"
+	"math"
+	"testing"
+	"time"
+
+	"github.com/umk/llmservices/pkg/adapter"
+)
+
+// getTokens returns the tokens in the bucket, rounded to drop the ones
+// refilled while the test runs.
+func getTokens(b *bucket) float64 {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	b.refill()
+	return math.Floor(b.tokens)
+}
+
+func TestBucketRefill(t *testing.T) {
+	b := newBucket(60)
+
+	if _, err := b.wait(context.Background(), 60); err != nil {
+		t.Fatal(err)
+	}
+	if n := getTokens(b); n != 0 {
+		t.Fatalf("got %g tokens, want 0", n)
+	}
+
+	// A token is refilled every second.
+	b.last = b.last.Add(-30 * time.Second)
+	if n := getTokens(b); n != 30 {
+		t.Errorf("got %g tokens after 30s, want 30", n)
+	}
+
+	// The bucket isn't refilled over its capacity.
+	b.last = b.last.Add(-time.Hour)
+	if n := getTokens(b); n != 60 {
+		t.Errorf("got %g tokens after an hour, want 60", n)
+	}
+}
+
+func TestBucketWait(t *testing.T) {
+	b := newBucket(600)
+
+	// A request larger than the bucket takes all of it.
+	taken, err := b.wait(context.Background(), 1000)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if taken != 600 {
+		t.Errorf("took %g tokens, want 600", taken)
+	}
+
+	// The bucket is refilled by 10 tokens a second.
+	start := time.Now()
+	if _, err := b.wait(context.Background(), 1); err != nil {
+		t.Fatal(err)
+	}
+	if d := time.Since(start); d < 90*time.Millisecond {
+		t.Errorf("waited for %v, want about 100ms", d)
+	}
+
+	// The tokens are returned if the request is canceled.
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+
+	before := getTokens(b)
+	if _, err := b.wait(ctx, 100); err == nil {
+		t.Fatal("wait() succeeded, want an error")
+	}
+	if n := getTokens(b); math.Abs(n-before) > 1 {
+		t.Errorf("got %g tokens, want about %g", n, before)
+	}
+}
+
+func TestRateLimiterReconcile(t *testing.T) {
+	l := newRateLimiter(&RateLimitConfig{TokensPerMinute: 1000})
+
+	taken, err := l.wait(context.Background(), 500)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if taken != 500 {
+		t.Fatalf("took %d tokens, want 500", taken)
+	}
+
+	// Fewer tokens were used than estimated, so the rest is returned.
+	l.reconcile(taken, 100)
+	if n := getTokens(l.tokens); n != 900 {
+		t.Errorf("got %d tokens, want 900", int(n))
+	}
+
+	// More tokens were used than estimated, so the rest is taken.
+	l.reconcile(100, 300)
+	if n := getTokens(l.tokens); n != 700 {
+		t.Errorf("got %d tokens, want 700", int(n))
+	}
+}
+
+func TestCompletionRateLimit(t *testing.T) {
+	a := &testAdapter{resp: adapter.Completion{
+		Message: adapter.AssistantMessage{Content: ptr("ok")},
+		Usage:   &adapter.CompletionUsage{PromptTokens: 30, CompletionTokens: 20},
+	}}
+	c := newTestClient(t, &Config{RateLimit: &RateLimitConfig{TokensPerMinute: 1000}}, a)
+
+	// The maximum number of tokens is taken up front, but only the tokens
+	// actually used are kept.
+	if _, err := c.Completion(context.Background(), getTestMessages("hi"), adapter.CompletionParams{
+		MaxTokens: ptr[int64](500),
+	}); err != nil {
+		t.Fatal(err)
+	}
+	if n := getTokens(c.limiter.tokens); n != 950 {
+		t.Errorf("got %d tokens, want 950", int(n))
+	}
+}
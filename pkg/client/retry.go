@@ -0,0 +1,185 @@
+package client
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"math/rand/v2"
+	"net"
+	"net/http"
+	"regexp"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/umk/llmservices/pkg/adapter"
+)
+
+type RetryConfig struct {
+	// Maximum number of attempts, including the first one.
+	MaxAttempts int `json:"max_attempts" validate:"omitempty,min=1"`
+	// Delay before the first retry, which doubles with every next retry.
+	BaseDelay *Duration `json:"base_delay,omitempty"`
+	// Maximum delay before a retry. If the provider asks to wait longer with
+	// Retry-After, the request fails instead.
+	MaxDelay *Duration `json:"max_delay,omitempty"`
+	// Fraction of the delay to randomize, from 0 to 1.
+	Jitter *float64 `json:"jitter,omitempty" validate:"omitempty,gte=0,lte=1"`
+	// Failures to retry, which are either HTTP statuses like "429" or status
+	// classes like "5xx", or "timeout" and "network" for the failures of
+	// connection to the provider.
+	RetryOn []string `json:"retry_on,omitempty"`
+}
+
+var retryOnRx = regexp.MustCompile(`^([1-5][0-9x]{2}|timeout|network)$`)
+
+var defaultRetry = retryPolicy{
+	maxAttempts: 3,
+	baseDelay:   500 * time.Millisecond,
+	maxDelay:    30 * time.Second,
+	jitter:      0.2,
+	retryOn:     []string{"429", "5xx", "timeout", "network"},
+}
+
+// permanentError wraps an error, which must not be retried.
+type permanentError struct{ error }
+
+type retryPolicy struct {
+	maxAttempts int
+	baseDelay   time.Duration
+	maxDelay    time.Duration
+	jitter      float64
+	retryOn     []string
+}
+
+func getRetryPolicy(config *RetryConfig) (retryPolicy, error) {
+	if config == nil {
+		return retryPolicy{maxAttempts: 1}, nil
+	}
+
+	p := defaultRetry
+
+	if config.MaxAttempts > 0 {
+		p.maxAttempts = config.MaxAttempts
+	}
+	if config.BaseDelay != nil {
+		p.baseDelay = time.Duration(*config.BaseDelay)
+	}
+	if config.MaxDelay != nil {
+		p.maxDelay = time.Duration(*config.MaxDelay)
+	}
+	if config.Jitter != nil {
+		p.jitter = *config.Jitter
+	}
+	if config.RetryOn != nil {
+		for _, v := range config.RetryOn {
+			if !retryOnRx.MatchString(v) {
+				return retryPolicy{}, fmt.Errorf("invalid failure to retry: %s", v)
+			}
+		}
+		p.retryOn = config.RetryOn
+	}
+
+	return p, nil
+}
+
+// do calls the function until it succeeds, or the number of attempts is
+// exhausted, or the error cannot be retried. Returns the number of attempts
+// made.
+func (p *retryPolicy) do(ctx context.Context, fn func() error) (int, error) {
+	for attempt := 1; ; attempt++ {
+		err := fn()
+		if v, ok := err.(permanentError); ok {
+			return attempt, v.error
+		}
+		if err == nil || attempt >= p.maxAttempts || ctx.Err() != nil || !p.retryable(err) {
+			return attempt, err
+		}
+
+		// The request is not retried earlier than the provider asks for, so
+		// if it asks to wait longer than allowed, the request fails.
+		delay := p.delay(attempt)
+		if v, ok := getRetryAfter(err); ok {
+			if v > p.maxDelay {
+				return attempt, err
+			}
+			delay = v
+		}
+
+		t := time.NewTimer(delay)
+		select {
+		case <-t.C:
+		case <-ctx.Done():
+			t.Stop()
+			return attempt, err
+		}
+	}
+}
+
+// delay returns the exponential delay before the retry that follows
+// the attempt.
+func (p *retryPolicy) delay(attempt int) time.Duration {
+	d := p.baseDelay << (attempt - 1)
+	if d > p.maxDelay || d <= 0 {
+		d = p.maxDelay
+	}
+
+	if p.jitter > 0 {
+		j := float64(d) * p.jitter
+		d += time.Duration(j * (2*rand.Float64() - 1))
+	}
+
+	return max(d, 0)
+}
+
+func (p *retryPolicy) retryable(err error) bool {
+	var statusErr *adapter.StatusError
+	if errors.As(err, &statusErr) {
+		s := strconv.Itoa(statusErr.StatusCode)
+		for _, v := range p.retryOn {
+			if v == s || (strings.HasSuffix(v, "xx") && v[0] == s[0]) {
+				return true
+			}
+		}
+		return false
+	}
+
+	var netErr net.Error
+	if errors.As(err, &netErr) && netErr.Timeout() {
+		return slices.Contains(p.retryOn, "timeout")
+	}
+
+	if netErr != nil || errors.Is(err, io.ErrUnexpectedEOF) {
+		return slices.Contains(p.retryOn, "network")
+	}
+
+	return false
+}
+
+// getRetryAfter returns the delay requested by the provider in either
+// Retry-After or retry-after-ms headers.
+func getRetryAfter(err error) (time.Duration, bool) {
+	var statusErr *adapter.StatusError
+	if !errors.As(err, &statusErr) || statusErr.Header == nil {
+		return 0, false
+	}
+
+	if v := statusErr.Header.Get("Retry-After-Ms"); v != "" {
+		if ms, err := strconv.ParseFloat(v, 64); err == nil && ms >= 0 {
+			return time.Duration(ms * float64(time.Millisecond)), true
+		}
+	}
+
+	if v := statusErr.Header.Get("Retry-After"); v != "" {
+		if s, err := strconv.ParseFloat(v, 64); err == nil && s >= 0 {
+			return time.Duration(s * float64(time.Second)), true
+		}
+		if t, err := http.ParseTime(v); err == nil {
+			return max(time.Until(t), 0), true
+		}
+	}
+
+	return 0, false
+}
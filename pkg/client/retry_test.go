@@ -0,0 +1,228 @@
+package client
+
+import (
+	"context"
+	"errors"
+	"io"
+	"net/http"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/umk/llmservices/pkg/adapter"
+)
+
+// testAdapter fails the requests with the errors in order, and then
+// completes them with the response given.
+type testAdapter struct {
+	mu    sync.Mutex
+	errs  []error
+	calls int
+	resp  adapter.Completion
+}
+
+func (a *testAdapter) Completion(ctx context.Context, messages []adapter.Message, params adapter.CompletionParams) (adapter.Completion, error) {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+
+	a.calls++
+	if len(a.errs) > 0 {
+		err := a.errs[0]
+		a.errs = a.errs[1:]
+		return adapter.Completion{}, err
+	}
+
+	return a.resp, nil
+}
+
+func (a *testAdapter) Embeddings(ctx context.Context, input []string, params adapter.EmbeddingsParams) (adapter.Embeddings, error) {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+
+	a.calls++
+	if len(a.errs) > 0 {
+		err := a.errs[0]
+		a.errs = a.errs[1:]
+		return adapter.Embeddings{}, err
+	}
+
+	r := adapter.Embeddings{
+		Data:  make([][]float64, len(input)),
+		Usage: &adapter.EmbeddingsUsage{PromptTokens: int64(len(input))},
+	}
+	for i := range input {
+		r.Data[i] = []float64{float64(len(input[i]))}
+	}
+	return r, nil
+}
+
+func newTestClient(t *testing.T, config *Config, a adapter.Adapter, opts ...Option) *Client {
+	t.Helper()
+
+	c, err := New(config, opts...)
+	if err != nil {
+		t.Fatal(err)
+	}
+	c.adapter = a
+
+	return c
+}
+
+func ptr[T any](v T) *T {
+	return &v
+}
+
+func getTestMessages(content string) []adapter.Message {
+	return []adapter.Message{adapter.CreateUserMessage(content)}
+}
+
+func TestRetry(t *testing.T) {
+	unavailable := &adapter.StatusError{StatusCode: http.StatusServiceUnavailable}
+	badRequest := &adapter.StatusError{StatusCode: http.StatusBadRequest}
+
+	tests := []struct {
+		name      string
+		errs      []error
+		wantErr   error
+		wantCalls int
+	}{
+		{"success", nil, nil, 1},
+		{"retried", []error{unavailable, unavailable}, nil, 3},
+		{"exhausted", []error{unavailable, unavailable, unavailable}, unavailable, 3},
+		{"not retried", []error{badRequest}, badRequest, 1},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			a := &testAdapter{errs: tt.errs, resp: adapter.Completion{
+				Message: adapter.AssistantMessage{Content: ptr("ok")},
+			}}
+			c := newTestClient(t, &Config{Retry: &RetryConfig{
+				BaseDelay: ptr(Duration(time.Millisecond)),
+			}}, a)
+
+			resp, err := c.Completion(context.Background(), getTestMessages("hi"), adapter.CompletionParams{})
+			if !errors.Is(err, tt.wantErr) {
+				t.Fatalf("got error %v, want %v", err, tt.wantErr)
+			}
+			if a.calls != tt.wantCalls {
+				t.Errorf("got %d calls, want %d", a.calls, tt.wantCalls)
+			}
+			if err == nil && resp.Attempts != tt.wantCalls {
+				t.Errorf("got %d attempts, want %d", resp.Attempts, tt.wantCalls)
+			}
+		})
+	}
+}
+
+func TestRetryAfter(t *testing.T) {
+	tooMany := func(v string) error {
+		return &adapter.StatusError{
+			StatusCode: http.StatusTooManyRequests,
+			Header:     http.Header{"Retry-After": []string{v}},
+		}
+	}
+
+	t.Run("waits", func(t *testing.T) {
+		a := &testAdapter{errs: []error{tooMany("0.1")}}
+		c := newTestClient(t, &Config{Retry: &RetryConfig{
+			BaseDelay: ptr(Duration(time.Millisecond)),
+		}}, a)
+
+		start := time.Now()
+		if _, err := c.Completion(context.Background(), getTestMessages("hi"), adapter.CompletionParams{}); err != nil {
+			t.Fatal(err)
+		}
+		if d := time.Since(start); d < 100*time.Millisecond {
+			t.Errorf("retried after %v, want at least 100ms", d)
+		}
+	})
+
+	t.Run("too long", func(t *testing.T) {
+		a := &testAdapter{errs: []error{tooMany("60")}}
+		c := newTestClient(t, &Config{Retry: &RetryConfig{
+			MaxDelay: ptr(Duration(time.Second)),
+		}}, a)
+
+		if _, err := c.Completion(context.Background(), getTestMessages("hi"), adapter.CompletionParams{}); err == nil {
+			t.Fatal("request succeeded, want an error")
+		}
+		if a.calls != 1 {
+			t.Errorf("got %d calls, want 1", a.calls)
+		}
+	})
+}
+
+func TestRetryDelay(t *testing.T) {
+	p := retryPolicy{baseDelay: 100 * time.Millisecond, maxDelay: time.Second}
+
+	want := []time.Duration{
+		100 * time.Millisecond,
+		200 * time.Millisecond,
+		400 * time.Millisecond,
+		800 * time.Millisecond,
+		time.Second,
+		time.Second,
+	}
+	for i, w := range want {
+		if d := p.delay(i + 1); d != w {
+			t.Errorf("delay(%d) = %v, want %v", i+1, d, w)
+		}
+	}
+
+	// The delay is shifted by up to the fraction of it.
+	p.jitter = 0.5
+	for range 100 {
+		if d := p.delay(1); d < 50*time.Millisecond || d > 150*time.Millisecond {
+			t.Fatalf("delay(1) = %v, want between 50ms and 150ms", d)
+		}
+	}
+}
+
+func TestGetRetryAfter(t *testing.T) {
+	tests := []struct {
+		name   string
+		header http.Header
+		want   time.Duration
+		wantOk bool
+	}{
+		{"none", http.Header{}, 0, false},
+		{"seconds", http.Header{"Retry-After": []string{"2"}}, 2 * time.Second, true},
+		{"milliseconds", http.Header{"Retry-After-Ms": []string{"150"}, "Retry-After": []string{"2"}}, 150 * time.Millisecond, true},
+		{"past date", http.Header{"Retry-After": []string{"Mon, 02 Jan 2006 15:04:05 GMT"}}, 0, true},
+		{"invalid", http.Header{"Retry-After": []string{"soon"}}, 0, false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			d, ok := getRetryAfter(&adapter.StatusError{StatusCode: http.StatusTooManyRequests, Header: tt.header})
+			if d != tt.want || ok != tt.wantOk {
+				t.Errorf("got %v, %v, want %v, %v", d, ok, tt.want, tt.wantOk)
+			}
+		})
+	}
+}
+
+func TestRetryable(t *testing.T) {
+	p := defaultRetry
+
+	tests := []struct {
+		name string
+		err  error
+		want bool
+	}{
+		{"429", &adapter.StatusError{StatusCode: 429}, true},
+		{"5xx", &adapter.StatusError{StatusCode: 502}, true},
+		{"4xx", &adapter.StatusError{StatusCode: 401}, false},
+		{"unexpected EOF", io.ErrUnexpectedEOF, true},
+		{"other", errors.New("other"), false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := p.retryable(tt.err); got != tt.want {
+				t.Errorf("retryable() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
@@ -2,6 +2,9 @@ package thread
 
 import (
 	"github.com/umk/llmservices/pkg/client"
+	"go.opentelemetry.io/otel"
 )
 
+var tracer = otel.Tracer("github.com/umk/llmservices/pkg/client/thread")
+
 type Client client.Client
@@ -13,9 +13,25 @@ type Completion struct {
 	// The same thread passed to the request.
 	Thread Thread                   `json:"thread" validate:"required"`
 	Usage  *adapter.CompletionUsage `json:"usage,omitempty"`
+	// Number of attempts made to get the completion.
+	Attempts int `json:"attempts,omitempty"`
+	// ID of the client that provided the completion, if the request could
+	// be sent to one of several clients.
+	Backend string `json:"backend,omitempty"`
 }
 
 func (c *Client) Completion(ctx context.Context, thread Thread, params adapter.CompletionParams) (Completion, error) {
+	return c.CompletionStream(ctx, thread, params, nil)
+}
+
+// CompletionStream is the same as Completion, but also passes the chunks of
+// completion to the handler as they are generated.
+func (c *Client) CompletionStream(
+	ctx context.Context,
+	thread Thread,
+	params adapter.CompletionParams,
+	handler adapter.DeltaHandler,
+) (Completion, error) {
 	if len(thread.Frames) == 0 {
 		return Completion{}, errors.New("thread must have at least one frame")
 	}
@@ -25,7 +41,7 @@ func (c *Client) Completion(ctx context.Context, thread Thread, params adapter.C
 		m = append(m, f.Messages...)
 	}
 
-	resp, err := (*client.Client)(c).Completion(ctx, m, params)
+	resp, err := (*client.Client)(c).CompletionStream(ctx, m, params, handler)
 	if err != nil {
 		return Completion{}, err
 	}
@@ -40,14 +56,18 @@ func (c *Client) Completion(ctx context.Context, thread Thread, params adapter.C
 		Messages: append(f.Messages, adapter.Message{
 			OfAssistantMessage: &message,
 		}),
-		Tokens: resp.Usage.PromptTokens + resp.Usage.CompletionTokens,
+	}
+	if resp.Usage != nil {
+		thread.Frames[n].Tokens = resp.Usage.PromptTokens + resp.Usage.CompletionTokens
 	}
 
 	// Assign the token counts to frames after client stats have been updated.
 	SetFrameTokens(&thread, c.Samples)
 
 	return Completion{
-		Thread: thread,
-		Usage:  resp.Usage,
+		Thread:   thread,
+		Usage:    resp.Usage,
+		Attempts: resp.Attempts,
+		Backend:  resp.Backend,
 	}, nil
 }
@@ -34,52 +34,11 @@ func (f *MessagesFrame) Last() (*adapter.Message, bool) {
 func getEstimatedFrameSize(frame *MessagesFrame) int64 {
 	var size int64
 	for _, m := range frame.Messages {
-		size += getEstimatedMessageSize(&m)
+		size += adapter.GetEstimatedSize(&m)
 	}
 	return size
 }
 
-func getEstimatedMessageSize(message *adapter.Message) int64 {
-	var size int64
-
-	switch {
-	case message.OfSystemMessage != nil:
-		size += int64(len(message.OfSystemMessage.Content))
-
-	case message.OfUserMessage != nil:
-		for _, p := range message.OfUserMessage.Parts {
-			switch {
-			case p.OfContentPartText != nil:
-				size += int64(len(p.OfContentPartText.Text))
-			case p.OfContentPartImageUrl != nil:
-				size += int64(len(p.OfContentPartImageUrl.ImageUrl))
-			}
-		}
-
-	case message.OfToolMessage != nil:
-		size += int64(len(message.OfToolMessage.ToolCallID))
-		for _, p := range message.OfToolMessage.Content {
-			size += int64(len(p.Text))
-		}
-
-	case message.OfAssistantMessage != nil:
-		switch {
-		case message.OfAssistantMessage.Content != nil:
-			size += int64(len(*message.OfAssistantMessage.Content))
-		case message.OfAssistantMessage.Refusal != nil:
-			size += int64(len(*message.OfAssistantMessage.Refusal))
-		}
-
-		for _, c := range message.OfAssistantMessage.ToolCalls {
-			size += int64(len(c.ID))
-			size += int64(len(c.Function.Name))
-			size += int64(len(c.Function.Arguments))
-		}
-	}
-
-	return size
-}
-
 // SetFrameTokens calculates and assigns the number of tokens for each frame in the thread.
 func SetFrameTokens(thread *Thread, samples *client.Samples) {
 	b := samples.BytesPerTok()
@@ -7,84 +7,229 @@ import (
 
 	"github.com/umk/llmservices/internal/msg"
 	"github.com/umk/llmservices/pkg/adapter"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/trace"
+	"golang.org/x/sync/errgroup"
 )
 
 type ResponseParams struct {
 	adapter.CompletionParams
 	Iterations int             `json:"iterations" validate:"required,min=1"`
+	Stream     bool            `json:"stream"`
 	Handler    ResponseHandler `json:"-"`
+	// If specified, up to the number of tool calls are made concurrently,
+	// and the failed calls are reported to the model instead of ending the
+	// response with an error.
+	Parallel int `json:"parallel,omitempty" validate:"omitempty,min=1"`
+	// If specified, the documents relevant to the last user message are
+	// added to the thread before getting the response.
+	Retriever Retriever `json:"-"`
 }
 
 type Response struct {
 	Thread Thread `json:"thread" validate:"required"`
 	Done   bool   `json:"done"`
+	// ID of the client that provided the last completion, if the request
+	// could be sent to one of several clients.
+	Backend string `json:"backend,omitempty"`
+	// Documents added to the thread by the retriever.
+	Citations []Citation `json:"citations,omitempty"`
 }
 
 type ResponseHandler interface {
 	Call(ctx context.Context, fn adapter.ToolCallFunction) (string, error)
 }
 
+// StreamHandler can be implemented by a response handler to receive the
+// chunks of completions, if streaming is requested.
+type StreamHandler interface {
+	Delta(ctx context.Context, delta adapter.CompletionDelta) error
+}
+
 func (c *Client) Response(ctx context.Context, thread Thread, params ResponseParams) (Response, error) {
-	for range params.Iterations {
-		resp, err := c.Completion(ctx, thread, params.CompletionParams)
+	var delta adapter.DeltaHandler
+	if s, ok := params.Handler.(StreamHandler); ok && params.Stream {
+		delta = s.Delta
+	}
+
+	var citations []Citation
+	retrieved := -1
+	if params.Retriever != nil {
+		var err error
+		thread, retrieved, citations, err = retrieve(ctx, thread, params.Retriever)
 		if err != nil {
 			return Response{}, err
 		}
+	}
+
+	var backend string
 
-		r, err := resp.Thread.Response()
+	for i := range params.Iterations {
+		r, err := c.responseIterate(ctx, i, thread, params, delta)
 		if err != nil {
 			return Response{}, err
 		}
 
-		if len(r.ToolCalls) == 0 {
-			return Response{
-				Thread: resp.Thread,
-				Done:   true,
-			}, nil
+		if r.Done {
+			r.Thread = removeFrame(r.Thread, retrieved)
+			r.Citations = citations
+			return r, nil
 		}
 
-		for _, c := range r.ToolCalls {
-			if !slices.ContainsFunc(params.Tools, func(t adapter.Tool) bool {
-				return t.Function.Name == c.Function.Name
-			}) {
-				return Response{}, fmt.Errorf("calling not existing function: %s", c.Function.Name)
-			}
+		thread, backend = r.Thread, r.Backend
+	}
+
+	return Response{
+		Thread:    removeFrame(thread, retrieved),
+		Done:      false,
+		Backend:   backend,
+		Citations: citations,
+	}, nil
+}
+
+// responseIterate gets a completion and calls the tools requested by it. The
+// response is done if no tools are requested.
+func (c *Client) responseIterate(
+	ctx context.Context,
+	iteration int,
+	thread Thread,
+	params ResponseParams,
+	delta adapter.DeltaHandler,
+) (_ Response, err error) {
+	ctx, span := tracer.Start(ctx, "thread iteration", trace.WithAttributes(
+		attribute.Int("llmservices.iteration", iteration),
+	))
+	defer func() {
+		if err != nil {
+			span.RecordError(err)
+			span.SetStatus(codes.Error, err.Error())
+		}
+		span.End()
+	}()
+
+	resp, err := c.CompletionStream(ctx, thread, params.CompletionParams, delta)
+	if err != nil {
+		return Response{}, err
+	}
+
+	r, err := resp.Thread.Response()
+	if err != nil {
+		return Response{}, err
+	}
+
+	span.SetAttributes(attribute.Int("llmservices.tool_calls", len(r.ToolCalls)))
+
+	if len(r.ToolCalls) == 0 {
+		return Response{
+			Thread:  resp.Thread,
+			Done:    true,
+			Backend: resp.Backend,
+		}, nil
+	}
+
+	for _, c := range r.ToolCalls {
+		if !slices.ContainsFunc(params.Tools, func(t adapter.Tool) bool {
+			return t.Function.Name == c.Function.Name
+		}) {
+			return Response{}, fmt.Errorf("calling not existing function: %s", c.Function.Name)
+		}
+	}
+
+	if params.Handler == nil {
+		return Response{}, fmt.Errorf("function caller is not specified")
+	}
+
+	f := &resp.Thread.Frames[len(resp.Thread.Frames)-1]
+
+	if params.Parallel > 0 {
+		m, err := callParallel(ctx, r.ToolCalls, params.Handler, params.Parallel)
+		if err != nil {
+			return Response{}, err
 		}
 
-		if params.Handler == nil {
-			return Response{}, fmt.Errorf("function caller is not specified")
+		f.Messages = append(f.Messages, m...)
+
+		return Response{
+			Thread:  resp.Thread,
+			Done:    false,
+			Backend: resp.Backend,
+		}, nil
+	}
+
+	for i, c := range r.ToolCalls {
+		resp, err := params.Handler.Call(ctx, c.Function)
+		if err != nil {
+			m, renderErr := msg.RenderToolErrorMessage(msg.ToolErrorMessageParams{
+				Error: err.Error(),
+			})
+			if renderErr != nil {
+				m = err.Error()
+			}
+			f.Messages = append(f.Messages, adapter.CreateToolMessage(c.ID, m))
+			for i++; i < len(r.ToolCalls); i++ {
+				c := r.ToolCalls[i]
+				m, err := msg.RenderToolPreviousErrorMessage(msg.ToolPreviousErrorMessageParams{})
+				if err != nil {
+					m = "Ignored because one of the previous calls ended with an error."
+				}
+				f.Messages = append(f.Messages, adapter.CreateToolMessage(c.ID, m))
+			}
+			return Response{}, err
 		}
+		f.Messages = append(f.Messages, adapter.CreateToolMessage(c.ID, resp))
+	}
+
+	return Response{
+		Thread:  resp.Thread,
+		Done:    false,
+		Backend: resp.Backend,
+	}, nil
+}
+
+// callParallel makes up to the limit of the tool calls concurrently, and
+// returns their responses in the order of the calls. A failed call gets its
+// error as the response.
+func callParallel(ctx context.Context, calls []adapter.ToolCall, handler ResponseHandler, limit int) (
+	[]adapter.Message, error,
+) {
+	m := make([]adapter.Message, len(calls))
 
-		f := &resp.Thread.Frames[len(resp.Thread.Frames)-1]
+	g, ctx := errgroup.WithContext(ctx)
+	g.SetLimit(limit)
 
-		for i, c := range r.ToolCalls {
-			resp, err := params.Handler.Call(ctx, c.Function)
+	for i, c := range calls {
+		g.Go(func() error {
+			// The calls that are still queued once the response is canceled
+			// are not made.
+			if err := ctx.Err(); err != nil {
+				return err
+			}
+
+			resp, err := handler.Call(ctx, c.Function)
 			if err != nil {
-				m, renderErr := msg.RenderToolErrorMessage(msg.ToolErrorMessageParams{
+				// The calls fail once the response is canceled, which is not
+				// to be reported to the model.
+				if ctxErr := ctx.Err(); ctxErr != nil {
+					return ctxErr
+				}
+
+				r, renderErr := msg.RenderToolErrorMessage(msg.ToolErrorMessageParams{
 					Error: err.Error(),
 				})
 				if renderErr != nil {
-					m = err.Error()
-				}
-				f.Messages = append(f.Messages, adapter.CreateToolMessage(c.ID, m))
-				for i++; i < len(r.ToolCalls); i++ {
-					c := r.ToolCalls[i]
-					m, err := msg.RenderToolPreviousErrorMessage(msg.ToolPreviousErrorMessageParams{})
-					if err != nil {
-						m = "Ignored because one of the previous calls ended with an error."
-					}
-					f.Messages = append(f.Messages, adapter.CreateToolMessage(c.ID, m))
+					r = err.Error()
 				}
-				return Response{}, err
+				resp = r
 			}
-			f.Messages = append(f.Messages, adapter.CreateToolMessage(c.ID, resp))
-		}
+			m[i] = adapter.CreateToolMessage(c.ID, resp)
+			return nil
+		})
+	}
 
-		thread = resp.Thread
+	if err := g.Wait(); err != nil {
+		return nil, err
 	}
 
-	return Response{
-		Thread: thread,
-		Done:   false,
-	}, nil
+	return m, nil
 }
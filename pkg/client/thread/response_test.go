@@ -0,0 +1,136 @@
+package thread
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/umk/llmservices/pkg/adapter"
+)
+
+// testHandler responds to the calls of the tools with the functions by their
+// names, and tracks the number of the concurrent calls.
+type testHandler struct {
+	fn func(ctx context.Context, f adapter.ToolCallFunction) (string, error)
+
+	mu      sync.Mutex
+	calls   int
+	running int
+	peak    int
+}
+
+func (h *testHandler) Call(ctx context.Context, f adapter.ToolCallFunction) (string, error) {
+	h.mu.Lock()
+	h.calls++
+	h.running++
+	h.peak = max(h.peak, h.running)
+	h.mu.Unlock()
+
+	defer func() {
+		h.mu.Lock()
+		h.running--
+		h.mu.Unlock()
+	}()
+
+	return h.fn(ctx, f)
+}
+
+func getToolCalls(names ...string) []adapter.ToolCall {
+	calls := make([]adapter.ToolCall, len(names))
+	for i, name := range names {
+		calls[i] = adapter.ToolCall{
+			ID:       fmt.Sprintf("call_%d", i),
+			Function: adapter.ToolCallFunction{Name: name, Arguments: "{}"},
+		}
+	}
+	return calls
+}
+
+func getToolResponse(t *testing.T, m adapter.Message) (string, string) {
+	t.Helper()
+
+	if m.OfToolMessage == nil || len(m.OfToolMessage.Content) != 1 {
+		t.Fatalf("message = %+v, want a tool message", m)
+	}
+
+	return m.OfToolMessage.ToolCallID, m.OfToolMessage.Content[0].Text
+}
+
+func TestCallParallelOrder(t *testing.T) {
+	names := []string{"a", "b", "c", "d", "e", "f"}
+
+	h := &testHandler{fn: func(ctx context.Context, f adapter.ToolCallFunction) (string, error) {
+		// The earlier calls take longer to complete.
+		time.Sleep(time.Duration('g'-f.Name[0]) * 5 * time.Millisecond)
+		return "response " + f.Name, nil
+	}}
+
+	m, err := callParallel(context.Background(), getToolCalls(names...), h, 2)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if len(m) != len(names) {
+		t.Fatalf("got %d messages, want %d", len(m), len(names))
+	}
+	for i, name := range names {
+		id, text := getToolResponse(t, m[i])
+		if want := fmt.Sprintf("call_%d", i); id != want || text != "response "+name {
+			t.Errorf("message %d = %s %q, want %s %q", i, id, text, want, "response "+name)
+		}
+	}
+
+	if h.peak > 2 {
+		t.Errorf("%d calls are made concurrently, want at most 2", h.peak)
+	}
+}
+
+func TestCallParallelError(t *testing.T) {
+	h := &testHandler{fn: func(ctx context.Context, f adapter.ToolCallFunction) (string, error) {
+		if f.Name == "fail" {
+			return "", errors.New("something went wrong")
+		}
+		return "ok", nil
+	}}
+
+	m, err := callParallel(context.Background(), getToolCalls("a", "fail", "b"), h, 3)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// The failed call doesn't prevent the other calls.
+	want := []string{"ok", "Error: something went wrong", "ok"}
+	for i := range want {
+		if _, text := getToolResponse(t, m[i]); text != want[i] {
+			t.Errorf("message %d = %q, want %q", i, text, want[i])
+		}
+	}
+}
+
+func TestCallParallelCancel(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	h := &testHandler{fn: func(ctx context.Context, f adapter.ToolCallFunction) (string, error) {
+		// The response is canceled while the first call is made.
+		cancel()
+		<-ctx.Done()
+		return "", ctx.Err()
+	}}
+
+	m, err := callParallel(ctx, getToolCalls("a", "b", "c"), h, 1)
+	if !errors.Is(err, context.Canceled) {
+		t.Errorf("error = %v, want context.Canceled", err)
+	}
+	if m != nil {
+		t.Errorf("messages = %v, want none", m)
+	}
+
+	// The queued calls are not made once the response is canceled.
+	if h.calls != 1 {
+		t.Errorf("%d calls are made, want 1", h.calls)
+	}
+}
@@ -0,0 +1,89 @@
+package thread
+
+import (
+	"context"
+	"slices"
+	"strings"
+
+	"github.com/umk/llmservices/internal/msg"
+	"github.com/umk/llmservices/pkg/adapter"
+)
+
+// Retriever looks up the documents relevant to the query.
+type Retriever interface {
+	Retrieve(ctx context.Context, query string) ([]Citation, error)
+}
+
+// Citation is a document retrieved for the last user message of the thread
+// and passed to the model as a context.
+type Citation struct {
+	ID       string         `json:"id"`
+	Text     string         `json:"text"`
+	Score    float64        `json:"score"`
+	Metadata map[string]any `json:"metadata,omitempty"`
+}
+
+// retrieve gets the documents relevant to the last user message of the
+// thread and inserts them as a frame before the frame with the message.
+// Returns the index of the inserted frame. If there is no user message or
+// no documents found, the thread is returned as is with the index of -1.
+func retrieve(ctx context.Context, thread Thread, retriever Retriever) (Thread, int, []Citation, error) {
+	r, err := thread.Request()
+	if err != nil {
+		return thread, -1, nil, nil
+	}
+
+	var query []string
+	for _, p := range r.Parts {
+		if p.OfContentPartText != nil {
+			query = append(query, p.OfContentPartText.Text)
+		}
+	}
+	if len(query) == 0 {
+		return thread, -1, nil, nil
+	}
+
+	citations, err := retriever.Retrieve(ctx, strings.Join(query, "\n"))
+	if err != nil || len(citations) == 0 {
+		return thread, -1, nil, err
+	}
+
+	p := msg.RetrievalContextMessageParams{
+		Documents: make([]msg.RetrievalContextDocument, len(citations)),
+	}
+	for i, c := range citations {
+		p.Documents[i] = msg.RetrievalContextDocument{
+			ID:   c.ID,
+			Text: c.Text,
+		}
+	}
+
+	m, err := msg.RenderRetrievalContextMessage(p)
+	if err != nil {
+		return thread, -1, nil, err
+	}
+
+	n := len(thread.Frames) - 1
+	for len(thread.Frames[n].Messages) == 0 {
+		n--
+	}
+
+	thread.Frames = slices.Insert(slices.Clone(thread.Frames), n, MessagesFrame{
+		Messages: []adapter.Message{adapter.CreateUserMessage(m)},
+	})
+
+	return thread, n, citations, nil
+}
+
+// removeFrame returns the thread without the frame at the index, like the
+// one inserted by retrieve, so that the documents retrieved for a message
+// aren't passed to the model again along with the next messages.
+func removeFrame(thread Thread, i int) Thread {
+	if i < 0 {
+		return thread
+	}
+
+	thread.Frames = slices.Delete(slices.Clone(thread.Frames), i, i+1)
+
+	return thread
+}
@@ -8,7 +8,7 @@ import (
 )
 
 type Thread struct {
-	Frames []MessagesFrame `json:"frames" validate:"required,dive,min=1"`
+	Frames []MessagesFrame `json:"frames" validate:"required,min=1,dive"`
 }
 
 func (t *Thread) Request() (adapter.UserMessage, error) {
@@ -0,0 +1,94 @@
+package client
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/umk/llmservices/pkg/adapter"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	semconv "go.opentelemetry.io/otel/semconv/v1.30.0"
+	"go.opentelemetry.io/otel/trace"
+)
+
+var tracer = otel.Tracer("github.com/umk/llmservices/pkg/client")
+
+// startSpan starts the span of a request to the provider according to the
+// semantic conventions for generative AI.
+func (c *Client) startSpan(ctx context.Context, operation attribute.KeyValue, model string, attrs ...attribute.KeyValue) (context.Context, trace.Span) {
+	attrs = append(attrs,
+		operation,
+		semconv.GenAIRequestModel(model),
+		attribute.String("llmservices.client.id", c.name),
+	)
+
+	if len(c.config.Fallback) == 0 {
+		system := OpenAI
+		if c.config.Preset != nil {
+			system = *c.config.Preset
+		}
+		attrs = append(attrs, semconv.GenAISystemKey.String(string(system)))
+	}
+
+	name := fmt.Sprintf("%s %s", operation.Value.AsString(), model)
+
+	return tracer.Start(ctx, name, trace.WithSpanKind(trace.SpanKindClient), trace.WithAttributes(attrs...))
+}
+
+func getCompletionAttributes(params *adapter.CompletionParams) []attribute.KeyValue {
+	var attrs []attribute.KeyValue
+
+	if params.MaxTokens != nil {
+		attrs = append(attrs, semconv.GenAIRequestMaxTokens(int(*params.MaxTokens)))
+	}
+	if params.Temperature != nil {
+		attrs = append(attrs, semconv.GenAIRequestTemperature(*params.Temperature))
+	}
+	if params.TopP != nil {
+		attrs = append(attrs, semconv.GenAIRequestTopP(*params.TopP))
+	}
+	if len(params.Stop) > 0 {
+		attrs = append(attrs, semconv.GenAIRequestStopSequences(params.Stop...))
+	}
+
+	return attrs
+}
+
+func endCompletionSpan(span trace.Span, resp *adapter.Completion, err error) {
+	defer span.End()
+
+	if err != nil {
+		setSpanError(span, err)
+		return
+	}
+
+	if resp.Usage != nil {
+		span.SetAttributes(
+			semconv.GenAIUsageInputTokens(int(resp.Usage.PromptTokens)),
+			semconv.GenAIUsageOutputTokens(int(resp.Usage.CompletionTokens)),
+		)
+	}
+	if resp.FinishReason != "" {
+		span.SetAttributes(semconv.GenAIResponseFinishReasons(resp.FinishReason))
+	}
+}
+
+func endEmbeddingsSpan(span trace.Span, resp *adapter.Embeddings, err error) {
+	defer span.End()
+
+	if err != nil {
+		setSpanError(span, err)
+		return
+	}
+
+	if resp.Usage != nil {
+		span.SetAttributes(semconv.GenAIUsageInputTokens(int(resp.Usage.PromptTokens)))
+	}
+}
+
+func setSpanError(span trace.Span, err error) {
+	span.RecordError(err)
+	span.SetStatus(codes.Error, err.Error())
+	span.SetAttributes(semconv.ErrorTypeKey.String(fmt.Sprintf("%T", err)))
+}
@@ -0,0 +1,251 @@
+package client
+
+import (
+	"cmp"
+	"context"
+	"slices"
+	"sync"
+	"time"
+
+	"github.com/umk/llmservices/pkg/adapter"
+)
+
+// Price of a model in dollars per million tokens. Tokens of the embeddings
+// inputs are priced as the prompt tokens.
+type Price struct {
+	Prompt     float64 `json:"prompt" validate:"min=0"`
+	Completion float64 `json:"completion" validate:"min=0"`
+}
+
+// Usage is the cumulative usage of a client with a model.
+type Usage struct {
+	Requests         int64 `json:"requests"`
+	Errors           int64 `json:"errors"`
+	Retries          int64 `json:"retries"`
+	PromptTokens     int64 `json:"prompt_tokens"`
+	CompletionTokens int64 `json:"completion_tokens"`
+	EmbeddingTokens  int64 `json:"embedding_tokens"`
+	// Requests responded from the cache, which don't use any tokens.
+	CacheHits int64 `json:"cache_hits"`
+	// Spend in dollars according to the pricing of the client.
+	Cost float64 `json:"cost"`
+	// Time taken by the requests, including the retries.
+	Latency Histogram `json:"latency"`
+}
+
+// Upper bounds of the latency buckets in seconds.
+var latencyBounds = []float64{0.1, 0.25, 0.5, 1, 2.5, 5, 10, 30, 60, 120}
+
+type Histogram struct {
+	// Upper bounds of the buckets. The last bucket, which is not listed
+	// here, is unbounded.
+	Bounds []float64 `json:"bounds"`
+	Counts []int64   `json:"counts"`
+	Sum    float64   `json:"sum"`
+}
+
+func newHistogram(bounds []float64) Histogram {
+	return Histogram{
+		Bounds: bounds,
+		Counts: make([]int64, len(bounds)+1),
+	}
+}
+
+func (h *Histogram) observe(v float64) {
+	i, _ := slices.BinarySearch(h.Bounds, v)
+	h.Counts[i]++
+	h.Sum += v
+}
+
+func (u *Usage) add(v *Usage) {
+	u.Requests += v.Requests
+	u.Errors += v.Errors
+	u.Retries += v.Retries
+	u.CacheHits += v.CacheHits
+	u.PromptTokens += v.PromptTokens
+	u.CompletionTokens += v.CompletionTokens
+	u.EmbeddingTokens += v.EmbeddingTokens
+	u.Cost += v.Cost
+}
+
+type ModelUsage struct {
+	ClientID string `json:"client_id"`
+	Model    string `json:"model"`
+	Usage
+}
+
+// Statistics accumulates the usage of the clients by client ID and model.
+type Statistics struct {
+	mu    sync.Mutex
+	usage map[usageKey]*Usage
+	// Tokens used by client ID, which are not discarded on reset.
+	tokens map[string]int64
+	// Tokens used by all of the clients except the ones that fall back to
+	// other clients, since the tokens are also used by the latter.
+	total int64
+	// If positive, the requests fail once the tokens accumulated in the
+	// statistics reach the limit.
+	MaxTokens int64
+}
+
+type usageKey struct {
+	clientID string
+	model    string
+}
+
+func NewStatistics() *Statistics {
+	return &Statistics{
+		usage:  make(map[usageKey]*Usage),
+		tokens: make(map[string]int64),
+	}
+}
+
+// Get returns the usage of the client by model.
+func (s *Statistics) Get(clientID string) []ModelUsage {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	r := []ModelUsage{}
+	for k, u := range s.usage {
+		if k.clientID == clientID {
+			v := *u
+			v.Latency.Counts = slices.Clone(u.Latency.Counts)
+			r = append(r, ModelUsage{ClientID: k.clientID, Model: k.model, Usage: v})
+		}
+	}
+
+	slices.SortFunc(r, func(a, b ModelUsage) int {
+		return cmp.Compare(a.Model, b.Model)
+	})
+
+	return r
+}
+
+// Reset discards the usage of the client. The tokens used before the reset
+// still count towards the limit.
+func (s *Statistics) Reset(clientID string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	for k := range s.usage {
+		if k.clientID == clientID {
+			delete(s.usage, k)
+		}
+	}
+}
+
+// getTokens returns the tokens used by either the client or all the clients.
+func (s *Statistics) getTokens(clientID *string) int64 {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if clientID != nil {
+		return s.tokens[*clientID]
+	}
+
+	return s.total
+}
+
+// add accumulates the usage of the client. The tokens of the client that
+// falls back to other clients don't count towards the total.
+func (s *Statistics) add(clientID, model string, v *Usage, latency time.Duration, fallback bool) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	k := usageKey{clientID: clientID, model: model}
+
+	u, ok := s.usage[k]
+	if !ok {
+		u = &Usage{Latency: newHistogram(latencyBounds)}
+		s.usage[k] = u
+	}
+
+	u.add(v)
+	u.Latency.observe(latency.Seconds())
+
+	tokens := v.PromptTokens + v.CompletionTokens + v.EmbeddingTokens
+
+	s.tokens[clientID] += tokens
+	if !fallback {
+		s.total += tokens
+	}
+}
+
+type ContextKey string
+
+const (
+	CtxStatistics ContextKey = "statistics"
+)
+
+// ContextWithStatistics returns the context, in which the usage of any client
+// is also accumulated in the statistics, like the ones of a session.
+func ContextWithStatistics(ctx context.Context, s *Statistics) context.Context {
+	return context.WithValue(ctx, CtxStatistics, s)
+}
+
+// ContextStatistics returns the statistics of the context, or nil if the
+// context has no statistics.
+func ContextStatistics(ctx context.Context) *Statistics {
+	s, _ := ctx.Value(CtxStatistics).(*Statistics)
+	return s
+}
+
+func (c *Client) recordCompletion(ctx context.Context, model string, start time.Time, resp *adapter.Completion, attempts int, err error) {
+	u := Usage{Requests: 1, Retries: int64(attempts - 1)}
+	if err != nil {
+		u.Errors = 1
+	} else if resp.Usage != nil {
+		u.PromptTokens = resp.Usage.PromptTokens
+		u.CompletionTokens = resp.Usage.CompletionTokens
+	}
+
+	c.record(ctx, model, &u, time.Since(start))
+}
+
+func (c *Client) recordEmbeddings(ctx context.Context, model string, start time.Time, resp *adapter.Embeddings, attempts int, err error) {
+	u := Usage{Requests: 1, Retries: int64(attempts - 1)}
+	if err != nil {
+		u.Errors = 1
+	} else if resp.Usage != nil {
+		u.EmbeddingTokens = resp.Usage.PromptTokens
+	}
+
+	c.record(ctx, model, &u, time.Since(start))
+}
+
+func (c *Client) recordCacheHit(ctx context.Context, model string, start time.Time) {
+	c.record(ctx, model, &Usage{Requests: 1, CacheHits: 1}, time.Since(start))
+}
+
+func (c *Client) record(ctx context.Context, model string, u *Usage, latency time.Duration) {
+	if p, ok := c.getPrice(model); ok {
+		u.Cost = (float64(u.PromptTokens+u.EmbeddingTokens)*p.Prompt + float64(u.CompletionTokens)*p.Completion) / 1e6
+	}
+
+	_, fallback := c.adapter.(*fallbackAdapter)
+
+	c.Stats.add(c.name, model, u, latency, fallback)
+
+	if c.observer != nil {
+		c.observer.ObserveUsage(c.name, model, u)
+	}
+
+	if c.config.Budget != nil {
+		c.budgets.add(c.name, u.PromptTokens+u.CompletionTokens+u.EmbeddingTokens, u.Cost, time.Now())
+	}
+
+	if s := ContextStatistics(ctx); s != nil {
+		s.add(c.name, model, u, latency, fallback)
+	}
+}
+
+// getPrice returns the price of the model, or the one of the key * if the
+// model is not listed.
+func (c *Client) getPrice(model string) (Price, bool) {
+	if p, ok := c.config.Pricing[model]; ok {
+		return p, true
+	}
+
+	p, ok := c.config.Pricing["*"]
+	return p, ok
+}
@@ -0,0 +1,218 @@
+// Package mcp implements a client of the Model Context Protocol, which makes
+// the tools of the MCP servers available to the models.
+package mcp
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"os"
+	"os/exec"
+	"runtime/debug"
+	"sync"
+	"time"
+
+	"github.com/umk/jsonrpc2"
+)
+
+// ErrClosed is returned by the calls made after the connection to the server
+// is closed.
+var ErrClosed = errors.New("connection to MCP server is closed")
+
+// Time to wait for the server started by the client to exit before it's
+// killed.
+const closeTimeout = 5 * time.Second
+
+// Config specifies a server, which is either started by the client to talk
+// over its stdio, or is listening on a unix domain socket.
+type Config struct {
+	// Command to start the server with.
+	Command string   `json:"command" validate:"required_without=Socket,excluded_with=Socket"`
+	Args    []string `json:"args,omitempty"`
+	// Variables like KEY=VALUE added to the environment of the service to
+	// start the server with.
+	Env []string `json:"env,omitempty"`
+	// Working directory of the server. Defaults to that of the service.
+	Dir string `json:"dir,omitempty"`
+	// Path to the unix domain socket to connect to the server at.
+	Socket string `json:"socket,omitempty" validate:"required_without=Command"`
+}
+
+// Client is a connection to a server. It's safe for concurrent use.
+type Client struct {
+	client jsonrpc2.Client
+	close  func() error
+
+	// Canceled once the connection is closed by either side.
+	done context.Context
+
+	// Response of the server to the initialization.
+	Server InitializeResponse
+
+	mu    sync.Mutex
+	tools []Tool
+}
+
+// Connect starts or connects to the server, and initializes the session with
+// it. The client must be closed once it's not needed.
+func Connect(ctx context.Context, config *Config) (*Client, error) {
+	var (
+		in    io.Reader
+		out   io.Writer
+		close func() error
+		err   error
+	)
+	if config.Socket != "" {
+		in, out, close, err = dial(ctx, config)
+	} else {
+		in, out, close, err = start(config)
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	return connect(ctx, in, out, close)
+}
+
+// connect initializes the session with the server over the input and the
+// output, which are closed by the function given.
+func connect(ctx context.Context, in io.Reader, out io.Writer, close func() error) (*Client, error) {
+	c := Client{close: close}
+
+	h := jsonrpc2.NewHandler(map[string]jsonrpc2.HandlerFunc{
+		"ping": func(ctx context.Context, rpc jsonrpc2.RPCContext) (any, error) {
+			return rpc.Response(PingResponse{})
+		},
+		"notifications/tools/list_changed": func(ctx context.Context, rpc jsonrpc2.RPCContext) (any, error) {
+			c.mu.Lock()
+			defer c.mu.Unlock()
+
+			c.tools = nil
+
+			return nil, nil
+		},
+	})
+
+	done, cancel := context.WithCancel(context.Background())
+	c.done = done
+
+	host := jsonrpc2.NewHost(in, out, jsonrpc2.WithClient(&c.client), jsonrpc2.WithServer(h))
+	go func() {
+		defer cancel()
+		host.Run(done)
+	}()
+
+	if err := c.initialize(ctx); err != nil {
+		c.Close()
+		return nil, fmt.Errorf("failed to initialize MCP server: %w", err)
+	}
+
+	return &c, nil
+}
+
+// Close ends the session with the server, and stops the server if it was
+// started by the client.
+func (c *Client) Close() error {
+	return c.close()
+}
+
+func (c *Client) initialize(ctx context.Context) error {
+	if err := c.call(ctx, "initialize", InitializeRequest{
+		ProtocolVersion: ProtocolVersion,
+		Capabilities:    map[string]any{},
+		ClientInfo:      GetImplementation(),
+	}, &c.Server); err != nil {
+		return err
+	}
+
+	return c.client.Notify(ctx, "notifications/initialized", struct{}{})
+}
+
+// call makes the request to the server, which fails once the connection is
+// closed instead of waiting for the response that will never come.
+func (c *Client) call(ctx context.Context, method string, req any, resp any) error {
+	ctx, cancel := context.WithCancelCause(ctx)
+	defer cancel(nil)
+
+	stop := context.AfterFunc(c.done, func() { cancel(ErrClosed) })
+	defer stop()
+
+	return c.client.Call(ctx, method, req, resp)
+}
+
+func start(config *Config) (io.Reader, io.Writer, func() error, error) {
+	cmd := exec.Command(config.Command, config.Args...)
+	cmd.Env = append(os.Environ(), config.Env...)
+	cmd.Dir = config.Dir
+	// Stdout of the service may be taken by its own protocol.
+	cmd.Stderr = os.Stderr
+
+	stdin, err := cmd.StdinPipe()
+	if err != nil {
+		return nil, nil, nil, err
+	}
+
+	// Unlike the pipe made by the command, this one isn't closed by Wait,
+	// so the output of the server is read to the end after it exits.
+	stdout, w, err := os.Pipe()
+	if err != nil {
+		return nil, nil, nil, err
+	}
+	cmd.Stdout = w
+
+	err = cmd.Start()
+	w.Close()
+	if err != nil {
+		stdout.Close()
+		return nil, nil, nil, fmt.Errorf("failed to start MCP server: %w", err)
+	}
+
+	exited := make(chan error, 1)
+	go func() {
+		exited <- cmd.Wait()
+	}()
+
+	var once sync.Once
+
+	stop := func() error {
+		once.Do(func() {
+			// The server is expected to exit once its input is closed.
+			stdin.Close()
+
+			select {
+			case <-exited:
+			case <-time.After(closeTimeout):
+				cmd.Process.Kill()
+				<-exited
+			}
+
+			stdout.Close()
+		})
+		return nil
+	}
+
+	return stdout, stdin, stop, nil
+}
+
+func dial(ctx context.Context, config *Config) (io.Reader, io.Writer, func() error, error) {
+	var d net.Dialer
+
+	conn, err := d.DialContext(ctx, "unix", config.Socket)
+	if err != nil {
+		return nil, nil, nil, fmt.Errorf("failed to connect to MCP server: %w", err)
+	}
+
+	return conn, conn, conn.Close, nil
+}
+
+// GetImplementation describes the service to the peers.
+func GetImplementation() Implementation {
+	version := "(devel)"
+	if info, ok := debug.ReadBuildInfo(); ok && info.Main.Version != "" {
+		version = info.Main.Version
+	}
+
+	return Implementation{Name: "llmservices", Version: version}
+}
@@ -0,0 +1,361 @@
+package mcp
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"io"
+	"slices"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/umk/jsonrpc2"
+)
+
+// fakeServer is an in-process server, which the client talks to over pipes.
+type fakeServer struct {
+	client jsonrpc2.Client
+	// Closes the output of the server as if it exited.
+	exit func() error
+	// Receives the calls of the tool, which never responds.
+	hung chan struct{}
+
+	mu          sync.Mutex
+	initialized bool
+	// Pages of the tools returned by tools/list.
+	pages     [][]Tool
+	listCalls int
+}
+
+func (s *fakeServer) initialize(ctx context.Context, c jsonrpc2.RPCContext) (any, error) {
+	var req InitializeRequest
+	if err := c.Request(&req); err != nil {
+		return nil, err
+	}
+
+	return c.Response(InitializeResponse{
+		ProtocolVersion: req.ProtocolVersion,
+		Capabilities:    map[string]any{"tools": map[string]any{"listChanged": true}},
+		ServerInfo:      Implementation{Name: "fake", Version: "1.0.0"},
+	})
+}
+
+func (s *fakeServer) notified(ctx context.Context, c jsonrpc2.RPCContext) (any, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.initialized = true
+
+	return nil, nil
+}
+
+// listTools returns the pages of the tools, which cursors are the indexes
+// of the pages.
+func (s *fakeServer) listTools(ctx context.Context, c jsonrpc2.RPCContext) (any, error) {
+	var req ListToolsRequest
+	if err := c.Request(&req); err != nil {
+		return nil, err
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.listCalls++
+
+	i := 0
+	if req.Cursor != "" {
+		i = int(req.Cursor[0] - '0')
+	}
+
+	resp := ListToolsResponse{Tools: s.pages[i]}
+	if i+1 < len(s.pages) {
+		resp.NextCursor = string(rune('0' + i + 1))
+	}
+
+	return c.Response(resp)
+}
+
+func (s *fakeServer) callTool(ctx context.Context, c jsonrpc2.RPCContext) (any, error) {
+	var req CallToolRequest
+	if err := c.Request(&req); err != nil {
+		return nil, err
+	}
+
+	switch req.Name {
+	case "echo":
+		return c.Response(CallToolResponse{
+			Content: []Content{{Type: "text", Text: string(req.Arguments)}},
+		})
+	case "hang":
+		s.hung <- struct{}{}
+		<-ctx.Done()
+		return nil, ctx.Err()
+	case "fail":
+		return c.Response(CallToolResponse{
+			Content: []Content{{Type: "text", Text: "something went wrong"}},
+			IsError: true,
+		})
+	default:
+		return nil, jsonrpc2.Error{Code: -32602, Message: "Unknown tool: " + req.Name}
+	}
+}
+
+// setTools changes the tools, and notifies the client about it.
+func (s *fakeServer) setTools(ctx context.Context, pages ...[]Tool) error {
+	s.mu.Lock()
+	s.pages = pages
+	s.mu.Unlock()
+
+	return s.client.Notify(ctx, "notifications/tools/list_changed", struct{}{})
+}
+
+func (s *fakeServer) getListCalls() int {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	return s.listCalls
+}
+
+func newTestClient(t *testing.T, pages ...[]Tool) (*Client, *fakeServer) {
+	t.Helper()
+
+	s := &fakeServer{pages: pages, hung: make(chan struct{}, 1)}
+
+	h := jsonrpc2.NewHandler(map[string]jsonrpc2.HandlerFunc{
+		"initialize":                s.initialize,
+		"notifications/initialized": s.notified,
+		"tools/list":                s.listTools,
+		"tools/call":                s.callTool,
+	})
+
+	serverIn, clientOut := io.Pipe()
+	clientIn, serverOut := io.Pipe()
+
+	ctx, cancel := context.WithCancel(context.Background())
+	s.exit = serverOut.Close
+
+	host := jsonrpc2.NewHost(serverIn, serverOut, jsonrpc2.WithClient(&s.client), jsonrpc2.WithServer(h))
+	go host.Run(ctx)
+
+	c, err := connect(ctx, clientIn, clientOut, func() error {
+		cancel()
+		return errors.Join(clientOut.Close(), serverOut.Close())
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { c.Close() })
+
+	return c, s
+}
+
+func getNames(tools []Tool) []string {
+	names := make([]string, len(tools))
+	for i, t := range tools {
+		names[i] = t.Name
+	}
+	return names
+}
+
+func TestConnect(t *testing.T) {
+	c, s := newTestClient(t)
+
+	if c.Server.ProtocolVersion != ProtocolVersion || c.Server.ServerInfo.Name != "fake" {
+		t.Errorf("Server = %+v, want the response of the fake server", c.Server)
+	}
+
+	// The notification is handled by the server after the client returns.
+	deadline := time.Now().Add(time.Second)
+	for {
+		s.mu.Lock()
+		initialized := s.initialized
+		s.mu.Unlock()
+
+		if initialized {
+			break
+		}
+		if time.Now().After(deadline) {
+			t.Fatal("server isn't notified of the initialization")
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+}
+
+func TestListToolsPages(t *testing.T) {
+	c, s := newTestClient(t,
+		[]Tool{{Name: "a"}, {Name: "b"}},
+		[]Tool{{Name: "c"}},
+		[]Tool{{Name: "d"}},
+	)
+
+	ctx := context.Background()
+
+	tools, err := c.ListTools(ctx)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if names := getNames(tools); !slices.Equal(names, []string{"a", "b", "c", "d"}) {
+		t.Errorf("tools = %v, want the tools of all of the pages", names)
+	}
+
+	// The tools are kept until the server notifies that they're changed.
+	if _, err := c.ListTools(ctx); err != nil {
+		t.Fatal(err)
+	}
+	if n := s.getListCalls(); n != 3 {
+		t.Errorf("tools/list is called %d times, want 3", n)
+	}
+}
+
+func TestListToolsChanged(t *testing.T) {
+	c, s := newTestClient(t, []Tool{{Name: "a"}})
+
+	ctx := context.Background()
+
+	if _, err := c.ListTools(ctx); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := s.setTools(ctx, []Tool{{Name: "b"}}); err != nil {
+		t.Fatal(err)
+	}
+
+	// The notification is handled by the client asynchronously.
+	deadline := time.Now().Add(time.Second)
+	for {
+		tools, err := c.ListTools(ctx)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if slices.Equal(getNames(tools), []string{"b"}) {
+			break
+		}
+		if time.Now().After(deadline) {
+			t.Fatalf("tools = %v after the notification, want [b]", getNames(tools))
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+}
+
+func TestToolCall(t *testing.T) {
+	c, _ := newTestClient(t, []Tool{
+		{Name: "echo", Description: "Echoes the arguments.", InputSchema: map[string]any{"type": "object"}},
+		{Name: "fail"},
+	})
+
+	ctx := context.Background()
+
+	tools, err := c.Tools(ctx)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(tools) != 2 {
+		t.Fatalf("got %d tools, want 2", len(tools))
+	}
+
+	echo, fail := tools[0], tools[1]
+
+	f := echo.Function()
+	if f.Name != "echo" || f.Description == nil || *f.Description != "Echoes the arguments." {
+		t.Errorf("function = %+v, want the description of the tool", f)
+	}
+	if f := fail.Function(); f.Parameters["type"] != "object" {
+		t.Errorf("parameters = %v, want an object by default", f.Parameters)
+	}
+
+	resp, err := echo.Call(ctx, `{"a":1}`)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var args map[string]any
+	if err := json.Unmarshal([]byte(resp), &args); err != nil || args["a"] != float64(1) {
+		t.Errorf("response = %s, want the arguments", resp)
+	}
+
+	// The failure of the tool is reported to the model.
+	resp, err = fail.Call(ctx, "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !strings.Contains(resp, "something went wrong") {
+		t.Errorf("response = %s, want the error of the tool", resp)
+	}
+
+	if _, err := echo.Call(ctx, "{"); err == nil {
+		t.Error("Call() with invalid arguments succeeded")
+	}
+}
+
+func TestServerExit(t *testing.T) {
+	c, s := newTestClient(t, []Tool{{Name: "hang"}})
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	done := make(chan error, 1)
+	go func() {
+		_, err := c.CallTool(ctx, "hang", nil)
+		done <- err
+	}()
+
+	<-s.hung
+	if err := s.exit(); err != nil {
+		t.Fatal(err)
+	}
+
+	// The call doesn't wait for the response that will never come.
+	if err := <-done; !errors.Is(err, ErrClosed) {
+		t.Errorf("error = %v, want ErrClosed", err)
+	}
+
+	if _, err := c.CallTool(ctx, "hang", nil); err == nil {
+		t.Error("CallTool() succeeded after the server exited")
+	}
+}
+
+func TestGetContentText(t *testing.T) {
+	tests := []struct {
+		name string
+		resp CallToolResponse
+		want string
+	}{
+		{
+			name: "text",
+			resp: CallToolResponse{Content: []Content{{Type: "text", Text: "a"}, {Type: "text", Text: "b"}}},
+			want: "a\nb",
+		},
+		{
+			name: "structured",
+			resp: CallToolResponse{StructuredContent: map[string]any{"a": 1}},
+			want: `{"a":1}`,
+		},
+		{
+			name: "resources",
+			resp: CallToolResponse{Content: []Content{
+				{Type: "resource", Resource: &ResourceContents{URI: "file:///a.txt", Text: "a"}},
+				{Type: "resource", Resource: &ResourceContents{URI: "file:///b.png", Blob: "AAAA"}},
+				{Type: "resource_link", URI: "file:///c.txt"},
+			}},
+			want: "a\n[resource: file:///b.png]\n[resource: file:///c.txt]",
+		},
+		{
+			name: "image",
+			resp: CallToolResponse{Content: []Content{{Type: "image", Data: "AAAA", MimeType: "image/png"}}},
+			want: "[image: image/png]",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := getContentText(tt.resp)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if got != tt.want {
+				t.Errorf("getContentText() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
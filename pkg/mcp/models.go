@@ -0,0 +1,114 @@
+package mcp
+
+import "encoding/json"
+
+// ProtocolVersion is the revision of the Model Context Protocol the client
+// and the server are implemented against.
+const ProtocolVersion = "2025-06-18"
+
+type Implementation struct {
+	Name    string `json:"name"`
+	Version string `json:"version"`
+}
+
+/*** Initialize ***/
+
+type InitializeRequest struct {
+	ProtocolVersion string         `json:"protocolVersion"`
+	Capabilities    map[string]any `json:"capabilities"`
+	ClientInfo      Implementation `json:"clientInfo"`
+}
+
+type InitializeResponse struct {
+	ProtocolVersion string         `json:"protocolVersion"`
+	Capabilities    map[string]any `json:"capabilities"`
+	ServerInfo      Implementation `json:"serverInfo"`
+	Instructions    string         `json:"instructions,omitempty"`
+}
+
+/*** List tools ***/
+
+type ListToolsRequest struct {
+	Cursor string `json:"cursor,omitempty"`
+}
+
+type ListToolsResponse struct {
+	Tools      []Tool `json:"tools"`
+	NextCursor string `json:"nextCursor,omitempty"`
+}
+
+type Tool struct {
+	Name        string         `json:"name"`
+	Title       string         `json:"title,omitempty"`
+	Description string         `json:"description,omitempty"`
+	InputSchema map[string]any `json:"inputSchema"`
+}
+
+/*** Call tool ***/
+
+type CallToolRequest struct {
+	Name      string          `json:"name" validate:"required"`
+	Arguments json.RawMessage `json:"arguments,omitempty"`
+}
+
+type CallToolResponse struct {
+	Content           []Content `json:"content"`
+	StructuredContent any       `json:"structuredContent,omitempty"`
+	// Whether the call failed, in which case the content describes the
+	// error to the model.
+	IsError bool `json:"isError,omitempty"`
+}
+
+// Content is an item of the response of a tool, which is text, an image,
+// an audio, an embedded resource or a link to a resource.
+type Content struct {
+	Type     string            `json:"type"`
+	Text     string            `json:"text,omitempty"`
+	Data     string            `json:"data,omitempty"`
+	MimeType string            `json:"mimeType,omitempty"`
+	URI      string            `json:"uri,omitempty"`
+	Name     string            `json:"name,omitempty"`
+	Resource *ResourceContents `json:"resource,omitempty"`
+}
+
+type ResourceContents struct {
+	URI      string `json:"uri"`
+	MimeType string `json:"mimeType,omitempty"`
+	Text     string `json:"text,omitempty"`
+	Blob     string `json:"blob,omitempty"`
+}
+
+/*** List resources ***/
+
+type ListResourcesRequest struct {
+	Cursor string `json:"cursor,omitempty"`
+}
+
+type ListResourcesResponse struct {
+	Resources  []Resource `json:"resources"`
+	NextCursor string     `json:"nextCursor,omitempty"`
+}
+
+type Resource struct {
+	URI         string `json:"uri"`
+	Name        string `json:"name"`
+	Title       string `json:"title,omitempty"`
+	Description string `json:"description,omitempty"`
+	MimeType    string `json:"mimeType,omitempty"`
+}
+
+/*** Read resource ***/
+
+type ReadResourceRequest struct {
+	URI string `json:"uri" validate:"required"`
+}
+
+type ReadResourceResponse struct {
+	Contents []ResourceContents `json:"contents"`
+}
+
+/*** Ping ***/
+
+type PingRequest struct{}
+
+type PingResponse struct{}
@@ -0,0 +1,167 @@
+package mcp
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"strings"
+
+	"github.com/umk/llmservices/internal/msg"
+	"github.com/umk/llmservices/pkg/adapter"
+	"github.com/umk/llmservices/pkg/tools"
+)
+
+// ListTools returns the tools of the server. The tools are kept until the
+// server notifies that they're changed.
+func (c *Client) ListTools(ctx context.Context) ([]Tool, error) {
+	c.mu.Lock()
+	t := c.tools
+	c.mu.Unlock()
+
+	if t != nil {
+		return t, nil
+	}
+
+	t = []Tool{}
+
+	var req ListToolsRequest
+	for {
+		var resp ListToolsResponse
+		if err := c.call(ctx, "tools/list", req, &resp); err != nil {
+			return nil, err
+		}
+
+		t = append(t, resp.Tools...)
+
+		if resp.NextCursor == "" {
+			break
+		}
+		req.Cursor = resp.NextCursor
+	}
+
+	c.mu.Lock()
+	c.tools = t
+	c.mu.Unlock()
+
+	return t, nil
+}
+
+// CallTool calls the tool of the server with the arguments in JSON.
+func (c *Client) CallTool(ctx context.Context, name string, arguments json.RawMessage) (CallToolResponse, error) {
+	var resp CallToolResponse
+	if err := c.call(ctx, "tools/call", CallToolRequest{
+		Name:      name,
+		Arguments: arguments,
+	}, &resp); err != nil {
+		return CallToolResponse{}, err
+	}
+
+	return resp, nil
+}
+
+// Tools returns the tools of the server to be executed by the service along
+// with its own tools.
+func (c *Client) Tools(ctx context.Context) ([]tools.Tool, error) {
+	t, err := c.ListTools(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	r := make([]tools.Tool, len(t))
+	for i, t := range t {
+		r[i] = &tool{client: c, tool: t}
+	}
+
+	return r, nil
+}
+
+type tool struct {
+	client *Client
+	tool   Tool
+}
+
+func (t *tool) Function() adapter.ToolFunction {
+	var description *string
+	if t.tool.Description != "" {
+		description = &t.tool.Description
+	}
+
+	parameters := t.tool.InputSchema
+	if parameters == nil {
+		parameters = map[string]any{"type": "object"}
+	}
+
+	return adapter.ToolFunction{
+		Name:        t.tool.Name,
+		Description: description,
+		Parameters:  parameters,
+	}
+}
+
+func (t *tool) Call(ctx context.Context, arguments string) (string, error) {
+	if arguments == "" {
+		arguments = "{}"
+	}
+	if !json.Valid([]byte(arguments)) {
+		return "", fmt.Errorf("invalid arguments: %s", arguments)
+	}
+
+	resp, err := t.client.CallTool(ctx, t.tool.Name, json.RawMessage(arguments))
+	if err != nil {
+		return "", err
+	}
+
+	text, err := getContentText(resp)
+	if err != nil {
+		return "", err
+	}
+
+	// The failure of the tool is reported to the model rather than ending
+	// the response.
+	if resp.IsError {
+		m, err := msg.RenderToolErrorMessage(msg.ToolErrorMessageParams{
+			Error: text,
+		})
+		if err != nil {
+			return text, nil
+		}
+		return m, nil
+	}
+
+	return text, nil
+}
+
+// getContentText renders the content of the response as text. The content
+// the model cannot read as text is replaced with a short description.
+func getContentText(resp CallToolResponse) (string, error) {
+	if len(resp.Content) == 0 && resp.StructuredContent != nil {
+		b, err := json.Marshal(resp.StructuredContent)
+		if err != nil {
+			return "", err
+		}
+		return string(b), nil
+	}
+
+	parts := make([]string, 0, len(resp.Content))
+	for _, c := range resp.Content {
+		switch c.Type {
+		case "text":
+			parts = append(parts, c.Text)
+		case "resource":
+			if c.Resource == nil {
+				continue
+			}
+			if c.Resource.Blob == "" {
+				parts = append(parts, c.Resource.Text)
+			} else {
+				parts = append(parts, fmt.Sprintf("[resource: %s]", c.Resource.URI))
+			}
+		case "resource_link":
+			parts = append(parts, fmt.Sprintf("[resource: %s]", c.URI))
+		default:
+			parts = append(parts, fmt.Sprintf("[%s: %s]", c.Type, c.MimeType))
+		}
+	}
+
+	return strings.Join(parts, "\n"), nil
+}
@@ -0,0 +1,328 @@
+package tools
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"math"
+	"strconv"
+	"strings"
+	"unicode"
+
+	"github.com/umk/llmservices/pkg/adapter"
+)
+
+type calculator struct {
+	function adapter.ToolFunction
+}
+
+// NewCalculator creates the tool that evaluates arithmetic expressions. If
+// the description is empty, the default one is used.
+func NewCalculator(name, description string) Tool {
+	return &calculator{
+		function: getFunction(name, description,
+			"Evaluates an arithmetic expression with the operators + - * / % ^, parentheses, "+
+				"the constants pi and e, and the functions abs, sqrt, exp, ln, log10, sin, cos, tan, "+
+				"floor, ceil, round, min and max.",
+			map[string]any{
+				"type": "object",
+				"properties": map[string]any{
+					"expression": map[string]any{
+						"type":        "string",
+						"description": "Expression like (2 + 3) * sqrt(16).",
+					},
+				},
+				"required": []string{"expression"},
+			}),
+	}
+}
+
+func (c *calculator) Function() adapter.ToolFunction {
+	return c.function
+}
+
+func (c *calculator) Call(ctx context.Context, arguments string) (string, error) {
+	var args struct {
+		Expression string `json:"expression"`
+	}
+	if err := getArguments(arguments, &args); err != nil {
+		return "", err
+	}
+
+	v, err := evaluate(args.Expression)
+	if err != nil {
+		return "", err
+	}
+
+	return strconv.FormatFloat(v, 'g', -1, 64), nil
+}
+
+var constants = map[string]float64{
+	"pi": math.Pi,
+	"e":  math.E,
+}
+
+var functions = map[string]func(args ...float64) (float64, error){
+	"abs":   unary(math.Abs),
+	"sqrt":  unary(math.Sqrt),
+	"exp":   unary(math.Exp),
+	"ln":    unary(math.Log),
+	"log10": unary(math.Log10),
+	"sin":   unary(math.Sin),
+	"cos":   unary(math.Cos),
+	"tan":   unary(math.Tan),
+	"floor": unary(math.Floor),
+	"ceil":  unary(math.Ceil),
+	"round": unary(math.Round),
+	"min":   variadic(math.Min),
+	"max":   variadic(math.Max),
+}
+
+func unary(fn func(float64) float64) func(args ...float64) (float64, error) {
+	return func(args ...float64) (float64, error) {
+		if len(args) != 1 {
+			return 0, errors.New("expected 1 argument")
+		}
+		return fn(args[0]), nil
+	}
+}
+
+func variadic(fn func(a, b float64) float64) func(args ...float64) (float64, error) {
+	return func(args ...float64) (float64, error) {
+		if len(args) == 0 {
+			return 0, errors.New("expected at least 1 argument")
+		}
+		r := args[0]
+		for _, v := range args[1:] {
+			r = fn(r, v)
+		}
+		return r, nil
+	}
+}
+
+// evaluate parses and evaluates the expression by recursive descent:
+//
+//	expr   = term { ("+" | "-") term }
+//	term   = unary { ("*" | "/" | "%") unary }
+//	unary  = ("+" | "-") unary | power
+//	power  = atom [ "^" unary ]
+//	atom   = number | name | name "(" expr { "," expr } ")" | "(" expr ")"
+func evaluate(expression string) (float64, error) {
+	p := &parser{s: expression}
+
+	v, err := p.expr()
+	if err != nil {
+		return 0, err
+	}
+
+	if p.skip(); p.pos < len(p.s) {
+		return 0, fmt.Errorf("unexpected %q at %d", p.s[p.pos], p.pos)
+	}
+
+	if math.IsNaN(v) || math.IsInf(v, 0) {
+		return 0, errors.New("result is not a finite number")
+	}
+
+	return v, nil
+}
+
+type parser struct {
+	s   string
+	pos int
+}
+
+func (p *parser) skip() {
+	for p.pos < len(p.s) && unicode.IsSpace(rune(p.s[p.pos])) {
+		p.pos++
+	}
+}
+
+// accept consumes the character, if it's next.
+func (p *parser) accept(c byte) bool {
+	p.skip()
+	if p.pos < len(p.s) && p.s[p.pos] == c {
+		p.pos++
+		return true
+	}
+	return false
+}
+
+func (p *parser) expr() (float64, error) {
+	v, err := p.term()
+	if err != nil {
+		return 0, err
+	}
+
+	for {
+		switch {
+		case p.accept('+'):
+			r, err := p.term()
+			if err != nil {
+				return 0, err
+			}
+			v += r
+		case p.accept('-'):
+			r, err := p.term()
+			if err != nil {
+				return 0, err
+			}
+			v -= r
+		default:
+			return v, nil
+		}
+	}
+}
+
+func (p *parser) term() (float64, error) {
+	v, err := p.unary()
+	if err != nil {
+		return 0, err
+	}
+
+	for {
+		var op byte
+		switch {
+		case p.accept('*'):
+			op = '*'
+		case p.accept('/'):
+			op = '/'
+		case p.accept('%'):
+			op = '%'
+		default:
+			return v, nil
+		}
+
+		r, err := p.unary()
+		if err != nil {
+			return 0, err
+		}
+
+		switch op {
+		case '*':
+			v *= r
+		case '/', '%':
+			if r == 0 {
+				return 0, errors.New("division by zero")
+			}
+			if op == '/' {
+				v /= r
+			} else {
+				v = math.Mod(v, r)
+			}
+		}
+	}
+}
+
+func (p *parser) unary() (float64, error) {
+	switch {
+	case p.accept('-'):
+		v, err := p.unary()
+		return -v, err
+	case p.accept('+'):
+		return p.unary()
+	default:
+		return p.power()
+	}
+}
+
+func (p *parser) power() (float64, error) {
+	v, err := p.atom()
+	if err != nil {
+		return 0, err
+	}
+
+	if p.accept('^') {
+		r, err := p.unary()
+		if err != nil {
+			return 0, err
+		}
+		v = math.Pow(v, r)
+	}
+
+	return v, nil
+}
+
+func (p *parser) atom() (float64, error) {
+	if p.accept('(') {
+		v, err := p.expr()
+		if err != nil {
+			return 0, err
+		}
+		if !p.accept(')') {
+			return 0, errors.New("expected )")
+		}
+		return v, nil
+	}
+
+	p.skip()
+	start := p.pos
+
+	if p.pos < len(p.s) && unicode.IsLetter(rune(p.s[p.pos])) {
+		for p.pos < len(p.s) && (unicode.IsLetter(rune(p.s[p.pos])) || unicode.IsDigit(rune(p.s[p.pos]))) {
+			p.pos++
+		}
+		return p.name(strings.ToLower(p.s[start:p.pos]))
+	}
+
+	for p.pos < len(p.s) && (unicode.IsDigit(rune(p.s[p.pos])) || p.s[p.pos] == '.') {
+		p.pos++
+	}
+	// Exponent like 1e-3
+	if p.pos > start && p.pos < len(p.s) && (p.s[p.pos] == 'e' || p.s[p.pos] == 'E') {
+		p.pos++
+		if p.pos < len(p.s) && (p.s[p.pos] == '+' || p.s[p.pos] == '-') {
+			p.pos++
+		}
+		for p.pos < len(p.s) && unicode.IsDigit(rune(p.s[p.pos])) {
+			p.pos++
+		}
+	}
+
+	if p.pos == start {
+		if p.pos == len(p.s) {
+			return 0, errors.New("unexpected end of expression")
+		}
+		return 0, fmt.Errorf("unexpected %q at %d", p.s[p.pos], p.pos)
+	}
+
+	return strconv.ParseFloat(p.s[start:p.pos], 64)
+}
+
+func (p *parser) name(name string) (float64, error) {
+	if !p.accept('(') {
+		if v, ok := constants[name]; ok {
+			return v, nil
+		}
+		return 0, fmt.Errorf("unknown constant: %s", name)
+	}
+
+	fn, ok := functions[name]
+	if !ok {
+		return 0, fmt.Errorf("unknown function: %s", name)
+	}
+
+	var args []float64
+	if !p.accept(')') {
+		for {
+			v, err := p.expr()
+			if err != nil {
+				return 0, err
+			}
+			args = append(args, v)
+
+			if p.accept(')') {
+				break
+			}
+			if !p.accept(',') {
+				return 0, errors.New("expected , or )")
+			}
+		}
+	}
+
+	v, err := fn(args...)
+	if err != nil {
+		return 0, fmt.Errorf("%s: %w", name, err)
+	}
+
+	return v, nil
+}
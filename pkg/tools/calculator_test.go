@@ -0,0 +1,92 @@
+package tools
+
+import (
+	"context"
+	"math"
+	"testing"
+)
+
+func TestEvaluate(t *testing.T) {
+	tests := []struct {
+		expression string
+		want       float64
+	}{
+		{"1 + 2 * 3", 7},
+		{"(1 + 2) * 3", 9},
+		{"10 - 4 - 3", 3},
+		{"24 / 4 / 3", 2},
+		{"7 % 4 * 2", 6},
+		{"2 ^ 3 ^ 2", 512},
+		{"2 * 3 ^ 2", 18},
+		{"-2 ^ 2", -4},
+		{"(-2) ^ 2", 4},
+		{"2 ^ -1", 0.5},
+		{"--3", 3},
+		{"+3 - -3", 6},
+		{"1.5e3 + 1E-1", 1500.1},
+		{"abs(-3) + sqrt(16)", 7},
+		{"min(3, 1, 2) + max(3, 1, 2)", 4},
+		{"ROUND(2.5)", 3},
+		{"cos(pi) + ln(e)", 0},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.expression, func(t *testing.T) {
+			got, err := evaluate(tt.expression)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if math.Abs(got-tt.want) > 1e-9 {
+				t.Errorf("evaluate() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestEvaluateError(t *testing.T) {
+	tests := []struct {
+		name       string
+		expression string
+	}{
+		{"division by zero", "1 / 0"},
+		{"modulo by zero", "1 % (2 - 2)"},
+		{"too many arguments", "sqrt(4, 9)"},
+		{"no arguments", "sqrt()"},
+		{"no arguments of variadic", "max()"},
+		{"unknown function", "foo(1)"},
+		{"unknown constant", "foo"},
+		{"missing parenthesis", "(1 + 2"},
+		{"missing argument", "min(1,)"},
+		{"missing operand", "1 +"},
+		{"trailing input", "1 2"},
+		{"unexpected character", "1 + $"},
+		{"empty", ""},
+		{"not finite", "sqrt(-1)"},
+		{"overflow", "10 ^ 400"},
+		{"bad number", "1.2.3"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if v, err := evaluate(tt.expression); err == nil {
+				t.Errorf("evaluate(%q) = %v, want an error", tt.expression, v)
+			}
+		})
+	}
+}
+
+func TestCalculatorCall(t *testing.T) {
+	c := NewCalculator("calc", "")
+
+	resp, err := c.Call(context.Background(), `{"expression":"(2 + 3) * sqrt(16)"}`)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if resp != "20" {
+		t.Errorf("response = %s, want 20", resp)
+	}
+
+	if _, err := c.Call(context.Background(), `{"expression":`); err == nil {
+		t.Error("Call() with invalid arguments succeeded")
+	}
+}
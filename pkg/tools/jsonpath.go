@@ -0,0 +1,205 @@
+package tools
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+
+	"github.com/umk/llmservices/pkg/adapter"
+)
+
+type jsonPath struct {
+	function adapter.ToolFunction
+}
+
+// NewJSONPath creates the tool that queries a JSON document with a path
+// like $.items[0].name. If the description is empty, the default one is
+// used.
+func NewJSONPath(name, description string) Tool {
+	return &jsonPath{
+		function: getFunction(name, description,
+			"Queries a JSON document with a path like $.items[0].name, which supports "+
+				"the fields like .name or ['name'], the indexes like [0] or [-1] and the wildcards like [*] or .*.",
+			map[string]any{
+				"type": "object",
+				"properties": map[string]any{
+					"document": map[string]any{
+						"type":        "string",
+						"description": "JSON document to query.",
+					},
+					"path": map[string]any{
+						"type":        "string",
+						"description": "Path to the values in the document.",
+					},
+				},
+				"required": []string{"document", "path"},
+			}),
+	}
+}
+
+func (j *jsonPath) Function() adapter.ToolFunction {
+	return j.function
+}
+
+func (j *jsonPath) Call(ctx context.Context, arguments string) (string, error) {
+	var args struct {
+		Document string `json:"document"`
+		Path     string `json:"path"`
+	}
+	if err := getArguments(arguments, &args); err != nil {
+		return "", err
+	}
+
+	var doc any
+	if err := json.Unmarshal([]byte(args.Document), &doc); err != nil {
+		return "", fmt.Errorf("invalid document: %w", err)
+	}
+
+	v, err := queryJSON(doc, args.Path)
+	if err != nil {
+		return "", err
+	}
+
+	b, err := json.Marshal(v)
+	if err != nil {
+		return "", err
+	}
+
+	return string(b), nil
+}
+
+// queryJSON returns the value at the path. If the path has wildcards, the
+// list of the values matching the path is returned.
+func queryJSON(doc any, path string) (any, error) {
+	steps, err := parsePath(path)
+	if err != nil {
+		return nil, err
+	}
+
+	values := []any{doc}
+	wildcard := false
+
+	for _, s := range steps {
+		var next []any
+		for _, v := range values {
+			switch {
+			case s.wildcard:
+				next = append(next, getChildren(v)...)
+			case s.index != nil:
+				if a, ok := v.([]any); ok {
+					i := *s.index
+					if i < 0 {
+						i += len(a)
+					}
+					if i >= 0 && i < len(a) {
+						next = append(next, a[i])
+					}
+				}
+			default:
+				if o, ok := v.(map[string]any); ok {
+					if c, ok := o[s.field]; ok {
+						next = append(next, c)
+					}
+				}
+			}
+		}
+
+		values = next
+		wildcard = wildcard || s.wildcard
+	}
+
+	if wildcard {
+		if values == nil {
+			values = []any{}
+		}
+		return values, nil
+	}
+
+	if len(values) == 0 {
+		return nil, fmt.Errorf("no value at path: %s", path)
+	}
+
+	return values[0], nil
+}
+
+func getChildren(v any) []any {
+	switch v := v.(type) {
+	case []any:
+		return v
+	case map[string]any:
+		r := make([]any, 0, len(v))
+		for _, c := range v {
+			r = append(r, c)
+		}
+		return r
+	default:
+		return nil
+	}
+}
+
+type pathStep struct {
+	field    string
+	index    *int
+	wildcard bool
+}
+
+func parsePath(path string) ([]pathStep, error) {
+	s := strings.TrimSpace(path)
+	s = strings.TrimPrefix(s, "$")
+
+	var steps []pathStep
+	for s != "" {
+		switch s[0] {
+		case '.':
+			s = s[1:]
+			n := strings.IndexAny(s, ".[")
+			if n < 0 {
+				n = len(s)
+			}
+			if n == 0 {
+				return nil, fmt.Errorf("invalid path: %s", path)
+			}
+			if s[:n] == "*" {
+				steps = append(steps, pathStep{wildcard: true})
+			} else {
+				steps = append(steps, pathStep{field: s[:n]})
+			}
+			s = s[n:]
+		case '[':
+			n := strings.IndexByte(s, ']')
+			if n < 0 {
+				return nil, fmt.Errorf("invalid path: %s", path)
+			}
+			step, err := parseBracket(strings.TrimSpace(s[1:n]))
+			if err != nil {
+				return nil, fmt.Errorf("invalid path: %s: %w", path, err)
+			}
+			steps = append(steps, step)
+			s = s[n+1:]
+		default:
+			return nil, fmt.Errorf("invalid path: %s", path)
+		}
+	}
+
+	return steps, nil
+}
+
+func parseBracket(s string) (pathStep, error) {
+	if s == "*" {
+		return pathStep{wildcard: true}, nil
+	}
+
+	if len(s) >= 2 && (s[0] == '\'' || s[0] == '"') && s[len(s)-1] == s[0] {
+		return pathStep{field: s[1 : len(s)-1]}, nil
+	}
+
+	i, err := strconv.Atoi(s)
+	if err != nil {
+		return pathStep{}, errors.New("expected index, quoted field or *")
+	}
+
+	return pathStep{index: &i}, nil
+}
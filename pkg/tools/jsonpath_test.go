@@ -0,0 +1,108 @@
+package tools
+
+import (
+	"context"
+	"encoding/json"
+	"reflect"
+	"testing"
+)
+
+const testDocument = `{
+	"store": {
+		"name": "books",
+		"odd key": true,
+		"items": [
+			{"title": "a", "price": 1, "tags": ["x", "y"]},
+			{"title": "b", "price": 2, "tags": []},
+			{"title": "c", "price": 3, "tags": ["z"]}
+		]
+	}
+}`
+
+func TestQueryJSON(t *testing.T) {
+	var doc any
+	if err := json.Unmarshal([]byte(testDocument), &doc); err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		path string
+		want any
+	}{
+		{"$.store.name", "books"},
+		{"$['store']['odd key']", true},
+		{`$["store"].items[0].title`, "a"},
+		{"$.store.items[-1].title", "c"},
+		{"$.store.items[-3].price", float64(1)},
+		{"$.store.items[*].title", []any{"a", "b", "c"}},
+		{"$.store.items.*.price", []any{float64(1), float64(2), float64(3)}},
+		{"$.store.items[*].tags[*]", []any{"x", "y", "z"}},
+		{"$.store.items[*].tags[0]", []any{"x", "z"}},
+		{"$.store.items[*].missing", []any{}},
+		{"$.store.name[*]", []any{}},
+		{"$", doc},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.path, func(t *testing.T) {
+			got, err := queryJSON(doc, tt.path)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("queryJSON() = %#v, want %#v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestQueryJSONError(t *testing.T) {
+	var doc any
+	if err := json.Unmarshal([]byte(testDocument), &doc); err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		name string
+		path string
+	}{
+		{"missing field", "$.store.missing"},
+		{"index out of range", "$.store.items[3]"},
+		{"negative index out of range", "$.store.items[-4]"},
+		{"index of object", "$.store[0]"},
+		{"field of array", "$.store.items.title"},
+		{"empty field", "$.store..name"},
+		{"unclosed bracket", "$.store[0"},
+		{"bad index", "$.store.items[one]"},
+		{"bad start", "$store"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if v, err := queryJSON(doc, tt.path); err == nil {
+				t.Errorf("queryJSON(%q) = %#v, want an error", tt.path, v)
+			}
+		})
+	}
+}
+
+func TestJSONPathCall(t *testing.T) {
+	j := NewJSONPath("jsonpath", "")
+
+	args, err := json.Marshal(map[string]string{"document": testDocument, "path": "$.store.items[*].price"})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	resp, err := j.Call(context.Background(), string(args))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if resp != "[1,2,3]" {
+		t.Errorf("response = %s, want [1,2,3]", resp)
+	}
+
+	if _, err := j.Call(context.Background(), `{"document":"{","path":"$"}`); err == nil {
+		t.Error("Call() with invalid document succeeded")
+	}
+}
@@ -0,0 +1,67 @@
+package tools
+
+import (
+	"context"
+	"encoding/json"
+	"time"
+
+	// The time zones are available even if the system doesn't have them.
+	_ "time/tzdata"
+
+	"github.com/umk/llmservices/pkg/adapter"
+)
+
+type timeTool struct {
+	function adapter.ToolFunction
+}
+
+// NewTime creates the tool that tells the current time in a time zone. If
+// the description is empty, the default one is used.
+func NewTime(name, description string) Tool {
+	return &timeTool{
+		function: getFunction(name, description, "Returns the current date and time.", map[string]any{
+			"type": "object",
+			"properties": map[string]any{
+				"timezone": map[string]any{
+					"type":        "string",
+					"description": "IANA time zone like Europe/Berlin. Defaults to UTC.",
+				},
+			},
+		}),
+	}
+}
+
+func (t *timeTool) Function() adapter.ToolFunction {
+	return t.function
+}
+
+func (t *timeTool) Call(ctx context.Context, arguments string) (string, error) {
+	var args struct {
+		Timezone string `json:"timezone"`
+	}
+	if err := getArguments(arguments, &args); err != nil {
+		return "", err
+	}
+
+	loc := time.UTC
+	if args.Timezone != "" {
+		l, err := time.LoadLocation(args.Timezone)
+		if err != nil {
+			return "", err
+		}
+		loc = l
+	}
+
+	now := time.Now().In(loc)
+
+	b, err := json.Marshal(map[string]any{
+		"time":     now.Format(time.RFC3339),
+		"timezone": loc.String(),
+		"weekday":  now.Weekday().String(),
+	})
+	if err != nil {
+		return "", err
+	}
+
+	return string(b), nil
+}
@@ -0,0 +1,121 @@
+// Package tools contains the tools that are executed by the service itself
+// rather than by the peer that requests the response.
+package tools
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"slices"
+	"sync"
+
+	"github.com/umk/llmservices/pkg/adapter"
+)
+
+// Tool is a tool the model can call, which is executed in-process.
+type Tool interface {
+	// Function describes the tool to the model.
+	Function() adapter.ToolFunction
+	// Call executes the tool with the arguments in JSON, and returns the
+	// response to pass to the model.
+	Call(ctx context.Context, arguments string) (string, error)
+}
+
+// Registry keeps the tools by their names. It's safe for concurrent use.
+type Registry struct {
+	mu    sync.RWMutex
+	tools map[string]Tool
+}
+
+func NewRegistry() *Registry {
+	return &Registry{tools: make(map[string]Tool)}
+}
+
+// Register adds the tool to the registry. The name of the tool must not be
+// taken by another tool.
+func (r *Registry) Register(t Tool) error {
+	name := t.Function().Name
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if _, ok := r.tools[name]; ok {
+		return fmt.Errorf("tool is already registered: %s", name)
+	}
+
+	r.tools[name] = t
+
+	return nil
+}
+
+// Get returns the tool with the name, if it's registered. A nil registry
+// has no tools.
+func (r *Registry) Get(name string) (Tool, bool) {
+	if r == nil {
+		return nil, false
+	}
+
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	t, ok := r.tools[name]
+	return t, ok
+}
+
+// Names returns the names of the registered tools in sorted order.
+func (r *Registry) Names() []string {
+	if r == nil {
+		return nil
+	}
+
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	names := make([]string, 0, len(r.tools))
+	for name := range r.tools {
+		names = append(names, name)
+	}
+	slices.Sort(names)
+
+	return names
+}
+
+// Tools returns the descriptions of the tools with the names to pass to the
+// model along with the request. If no names are specified, all of the tools
+// are returned.
+func (r *Registry) Tools(names ...string) ([]adapter.Tool, error) {
+	if len(names) == 0 {
+		names = r.Names()
+	}
+
+	tools := make([]adapter.Tool, 0, len(names))
+	for _, name := range names {
+		t, ok := r.Get(name)
+		if !ok {
+			return nil, fmt.Errorf("tool not found: %s", name)
+		}
+		tools = append(tools, adapter.Tool{Function: t.Function()})
+	}
+
+	return tools, nil
+}
+
+// getArguments reads the arguments of the call in JSON.
+func getArguments(arguments string, v any) error {
+	if err := json.Unmarshal([]byte(arguments), v); err != nil {
+		return fmt.Errorf("invalid arguments: %w", err)
+	}
+	return nil
+}
+
+func getFunction(name, description, defaultDescription string, parameters map[string]any) adapter.ToolFunction {
+	if description == "" {
+		description = defaultDescription
+	}
+
+	return adapter.ToolFunction{
+		Name:        name,
+		Description: &description,
+		Parameters:  parameters,
+	}
+}
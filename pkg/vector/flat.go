@@ -0,0 +1,100 @@
+package vector
+
+// Flat is an index, which compares the query to every document. It gives
+// exact results, but the search time grows linearly with the number of
+// documents.
+type Flat struct {
+	docs []flatDocument
+	ids  map[string]int
+	dims dimensions
+}
+
+type flatDocument struct {
+	Document
+	norm []float64
+}
+
+func NewFlat() *Flat {
+	return &Flat{
+		ids: make(map[string]int),
+	}
+}
+
+func (f *Flat) Upsert(docs ...Document) error {
+	norms, err := f.dims.prepare(docs)
+	if err != nil {
+		return err
+	}
+
+	for i, d := range docs {
+		if j, ok := f.ids[d.ID]; ok {
+			f.docs[j] = flatDocument{Document: d, norm: norms[i]}
+		} else {
+			f.ids[d.ID] = len(f.docs)
+			f.docs = append(f.docs, flatDocument{Document: d, norm: norms[i]})
+		}
+	}
+
+	return nil
+}
+
+func (f *Flat) Delete(ids ...string) int {
+	var n int
+	for _, id := range ids {
+		i, ok := f.ids[id]
+		if !ok {
+			continue
+		}
+
+		// Move the last document in place of the deleted one.
+		last := len(f.docs) - 1
+		if i != last {
+			f.docs[i] = f.docs[last]
+			f.ids[f.docs[i].ID] = i
+		}
+		f.docs[last] = flatDocument{}
+		f.docs = f.docs[:last]
+
+		delete(f.ids, id)
+		n++
+	}
+
+	return n
+}
+
+func (f *Flat) Search(vector []float64, k int, filter Filter) ([]Hit, error) {
+	if len(f.docs) == 0 {
+		return nil, nil
+	}
+	if err := f.dims.check(vector); err != nil {
+		return nil, err
+	}
+
+	q, err := normalize(vector)
+	if err != nil {
+		return nil, err
+	}
+
+	var hits []Hit
+	for _, d := range f.docs {
+		if filter.Match(d.Metadata) {
+			hits = append(hits, Hit{Document: d.Document, Score: dot(q, d.norm)})
+		}
+	}
+
+	sortHits(hits)
+
+	return hits[:min(k, len(hits))], nil
+}
+
+func (f *Flat) Documents() []Document {
+	docs := make([]Document, len(f.docs))
+	for i, d := range f.docs {
+		docs[i] = d.Document
+	}
+	return docs
+}
+
+func (f *Flat) Len() int {
+	return len(f.docs)
+}
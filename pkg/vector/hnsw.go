@@ -0,0 +1,344 @@
+package vector
+
+import (
+	"cmp"
+	"container/heap"
+	"math"
+	"math/rand/v2"
+	"slices"
+)
+
+const (
+	defaultM              = 16
+	defaultEfConstruction = 200
+	defaultEfSearch       = 64
+)
+
+type HNSWConfig struct {
+	// Maximum number of links of a node per layer. The bottom layer has
+	// twice as many.
+	M int `json:"m" validate:"omitempty,min=2"`
+	// Number of candidates considered when adding a document.
+	EfConstruction int `json:"ef_construction" validate:"omitempty,min=1"`
+	// Number of candidates considered when searching.
+	EfSearch int `json:"ef_search" validate:"omitempty,min=1"`
+}
+
+// HNSW is an index based on the hierarchical navigable small world graph.
+// It gives approximate results, but the search time grows logarithmically
+// with the number of documents.
+//
+// Deleted documents stay in the graph to keep it connected, until they
+// make up half of the nodes and the graph is rebuilt.
+type HNSW struct {
+	m              int
+	efConstruction int
+	efSearch       int
+	ml             float64
+
+	nodes    []*hnswNode
+	ids      map[string]int
+	entry    int
+	maxLevel int
+	deleted  int
+	dims     dimensions
+	rnd      *rand.Rand
+}
+
+type hnswNode struct {
+	doc     Document
+	norm    []float64
+	links   [][]int
+	deleted bool
+}
+
+func NewHNSW(config HNSWConfig) *HNSW {
+	h := &HNSW{
+		m:              defaultM,
+		efConstruction: defaultEfConstruction,
+		efSearch:       defaultEfSearch,
+		rnd:            rand.New(rand.NewPCG(rand.Uint64(), rand.Uint64())),
+	}
+
+	if config.M > 0 {
+		h.m = config.M
+	}
+	if config.EfConstruction > 0 {
+		h.efConstruction = config.EfConstruction
+	}
+	if config.EfSearch > 0 {
+		h.efSearch = config.EfSearch
+	}
+
+	h.ml = 1 / math.Log(float64(h.m))
+	h.reset()
+
+	return h
+}
+
+func (h *HNSW) reset() {
+	h.nodes = nil
+	h.ids = make(map[string]int)
+	h.entry = -1
+	h.maxLevel = 0
+	h.deleted = 0
+}
+
+func (h *HNSW) Upsert(docs ...Document) error {
+	norms, err := h.dims.prepare(docs)
+	if err != nil {
+		return err
+	}
+
+	for i, d := range docs {
+		h.Delete(d.ID)
+		h.insert(d, norms[i])
+	}
+
+	return nil
+}
+
+func (h *HNSW) Delete(ids ...string) int {
+	var n int
+	for _, id := range ids {
+		i, ok := h.ids[id]
+		if !ok {
+			continue
+		}
+
+		h.nodes[i].deleted = true
+		h.deleted++
+		delete(h.ids, id)
+		n++
+	}
+
+	if n > 0 && h.deleted*2 >= len(h.nodes) {
+		h.rebuild()
+	}
+
+	return n
+}
+
+func (h *HNSW) rebuild() {
+	nodes := h.nodes
+	h.reset()
+
+	for _, n := range nodes {
+		if !n.deleted {
+			h.insert(n.doc, n.norm)
+		}
+	}
+}
+
+func (h *HNSW) Search(vector []float64, k int, filter Filter) ([]Hit, error) {
+	if len(h.ids) == 0 {
+		return nil, nil
+	}
+	if err := h.dims.check(vector); err != nil {
+		return nil, err
+	}
+
+	q, err := normalize(vector)
+	if err != nil {
+		return nil, err
+	}
+
+	ep := h.entry
+	for l := h.maxLevel; l > 0; l-- {
+		ep = h.searchLayer(q, []int{ep}, 1, l)[0].node
+	}
+
+	var hits []Hit
+	for _, c := range h.searchLayer(q, []int{ep}, max(h.efSearch, k), 0) {
+		n := h.nodes[c.node]
+		if !n.deleted && filter.Match(n.doc.Metadata) {
+			hits = append(hits, Hit{Document: n.doc, Score: 1 - c.dist})
+		}
+	}
+
+	// Deleted documents and the filter may leave fewer documents than asked
+	// for, so fall back to comparing the query to every document.
+	if len(hits) < k && len(hits) < len(h.ids) {
+		hits = hits[:0]
+		for _, n := range h.nodes {
+			if !n.deleted && filter.Match(n.doc.Metadata) {
+				hits = append(hits, Hit{Document: n.doc, Score: dot(q, n.norm)})
+			}
+		}
+	}
+
+	sortHits(hits)
+
+	return hits[:min(k, len(hits))], nil
+}
+
+func (h *HNSW) Documents() []Document {
+	docs := make([]Document, 0, len(h.ids))
+	for _, n := range h.nodes {
+		if !n.deleted {
+			docs = append(docs, n.doc)
+		}
+	}
+	return docs
+}
+
+func (h *HNSW) Len() int {
+	return len(h.ids)
+}
+
+func (h *HNSW) insert(doc Document, norm []float64) {
+	level := int(math.Floor(-math.Log(1-h.rnd.Float64()) * h.ml))
+
+	i := len(h.nodes)
+	h.nodes = append(h.nodes, &hnswNode{
+		doc:   doc,
+		norm:  norm,
+		links: make([][]int, level+1),
+	})
+	h.ids[doc.ID] = i
+
+	if h.entry < 0 {
+		h.entry = i
+		h.maxLevel = level
+		return
+	}
+
+	ep := []int{h.entry}
+	for l := h.maxLevel; l > level; l-- {
+		ep = []int{h.searchLayer(norm, ep, 1, l)[0].node}
+	}
+
+	for l := min(level, h.maxLevel); l >= 0; l-- {
+		candidates := h.searchLayer(norm, ep, h.efConstruction, l)
+
+		maxLinks := h.maxLinks(l)
+
+		neighbors := candidates[:min(maxLinks, len(candidates))]
+		for _, c := range neighbors {
+			h.nodes[i].links[l] = append(h.nodes[i].links[l], c.node)
+			h.link(c.node, i, l)
+		}
+
+		ep = ep[:0]
+		for _, c := range candidates {
+			ep = append(ep, c.node)
+		}
+	}
+
+	if level > h.maxLevel {
+		h.entry = i
+		h.maxLevel = level
+	}
+}
+
+// link adds a link from one node to another, dropping the most distant
+// link of the node if it has too many links.
+func (h *HNSW) link(from, to int, level int) {
+	n := h.nodes[from]
+	n.links[level] = append(n.links[level], to)
+
+	maxLinks := h.maxLinks(level)
+	if len(n.links[level]) <= maxLinks {
+		return
+	}
+
+	candidates := make([]candidate, len(n.links[level]))
+	for j, v := range n.links[level] {
+		candidates[j] = candidate{node: v, dist: 1 - dot(n.norm, h.nodes[v].norm)}
+	}
+
+	sortCandidates(candidates)
+
+	n.links[level] = n.links[level][:0]
+	for _, c := range candidates[:maxLinks] {
+		n.links[level] = append(n.links[level], c.node)
+	}
+}
+
+func (h *HNSW) maxLinks(level int) int {
+	if level == 0 {
+		return h.m * 2
+	}
+	return h.m
+}
+
+// searchLayer returns up to ef nodes of the layer closest to the query,
+// in ascending order of distance.
+func (h *HNSW) searchLayer(q []float64, ep []int, ef int, level int) []candidate {
+	visited := make(map[int]bool, ef*4)
+
+	var cands, results candidateHeap
+	results.max = true
+
+	for _, e := range ep {
+		visited[e] = true
+		c := candidate{node: e, dist: 1 - dot(q, h.nodes[e].norm)}
+		heap.Push(&cands, c)
+		heap.Push(&results, c)
+	}
+
+	for cands.Len() > 0 {
+		c := heap.Pop(&cands).(candidate)
+		if results.Len() >= ef && c.dist > results.items[0].dist {
+			break
+		}
+
+		for _, v := range h.nodes[c.node].links[level] {
+			if visited[v] {
+				continue
+			}
+			visited[v] = true
+
+			d := 1 - dot(q, h.nodes[v].norm)
+			if results.Len() < ef || d < results.items[0].dist {
+				heap.Push(&cands, candidate{node: v, dist: d})
+				heap.Push(&results, candidate{node: v, dist: d})
+				if results.Len() > ef {
+					heap.Pop(&results)
+				}
+			}
+		}
+	}
+
+	sortCandidates(results.items)
+
+	return results.items
+}
+
+type candidate struct {
+	node int
+	dist float64
+}
+
+func sortCandidates(candidates []candidate) {
+	slices.SortFunc(candidates, func(a, b candidate) int {
+		return cmp.Compare(a.dist, b.dist)
+	})
+}
+
+// candidateHeap keeps the closest candidate on top, or the most distant one
+// if max is set.
+type candidateHeap struct {
+	items []candidate
+	max   bool
+}
+
+func (h *candidateHeap) Len() int { return len(h.items) }
+
+func (h *candidateHeap) Less(i, j int) bool {
+	if h.max {
+		return h.items[i].dist > h.items[j].dist
+	}
+	return h.items[i].dist < h.items[j].dist
+}
+
+func (h *candidateHeap) Swap(i, j int) { h.items[i], h.items[j] = h.items[j], h.items[i] }
+
+func (h *candidateHeap) Push(x any) { h.items = append(h.items, x.(candidate)) }
+
+func (h *candidateHeap) Pop() any {
+	n := len(h.items) - 1
+	x := h.items[n]
+	h.items = h.items[:n]
+	return x
+}
@@ -0,0 +1,81 @@
+package vector
+
+import (
+	"fmt"
+	"slices"
+	"testing"
+)
+
+func TestHNSWRecall(t *testing.T) {
+	const (
+		n       = 2000
+		dims    = 32
+		k       = 10
+		queries = 50
+	)
+
+	flat := NewFlat()
+	hnsw := NewHNSW(HNSWConfig{})
+
+	for i, v := range getVectors(1, n, dims) {
+		d := Document{ID: fmt.Sprint(i), Vector: v}
+		if err := flat.Upsert(d); err != nil {
+			t.Fatal(err)
+		}
+		if err := hnsw.Upsert(d); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	var found int
+	for _, q := range getVectors(2, queries, dims) {
+		want, err := flat.Search(q, k, nil)
+		if err != nil {
+			t.Fatal(err)
+		}
+		got, err := hnsw.Search(q, k, nil)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		ids := getIDs(got)
+		for _, h := range want {
+			if slices.Contains(ids, h.ID) {
+				found++
+			}
+		}
+	}
+
+	if recall := float64(found) / (queries * k); recall < 0.95 {
+		t.Errorf("recall = %.2f, want at least 0.95", recall)
+	}
+}
+
+func TestHNSWRebuild(t *testing.T) {
+	h := NewHNSW(HNSWConfig{})
+
+	vectors := getVectors(1, 100, 8)
+	for i, v := range vectors {
+		if err := h.Upsert(Document{ID: fmt.Sprint(i), Vector: v}); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	// Deleting half of the documents rebuilds the graph without them.
+	for i := range 50 {
+		h.Delete(fmt.Sprint(i))
+	}
+	if len(h.nodes) != 50 || h.deleted != 0 {
+		t.Fatalf("got %d nodes with %d deleted, want 50 nodes with none deleted", len(h.nodes), h.deleted)
+	}
+
+	for i := 50; i < 100; i++ {
+		hits, err := h.Search(vectors[i], 1, nil)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if len(hits) != 1 || hits[0].ID != fmt.Sprint(i) {
+			t.Errorf("document %d isn't found by its own vector: %v", i, getIDs(hits))
+		}
+	}
+}
@@ -0,0 +1,169 @@
+package vector
+
+import (
+	"encoding/gob"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sync"
+)
+
+type IndexType string
+
+const (
+	TypeFlat IndexType = "flat"
+	TypeHNSW IndexType = "hnsw"
+)
+
+type Config struct {
+	// Type of the index. Defaults to the flat index.
+	Type IndexType `json:"type" validate:"omitempty,oneof=flat hnsw"`
+	// Path to the file to keep the documents in. If not specified, the
+	// documents are kept in memory only.
+	Path string `json:"path"`
+
+	HNSW *HNSWConfig `json:"hnsw,omitempty"`
+}
+
+// Store is an index, which is safe for concurrent use and saves the documents
+// to a file after every change.
+type Store struct {
+	mu sync.RWMutex
+
+	index Index
+	path  string
+}
+
+// storeFile is the content of the file. The index itself is rebuilt from the
+// documents when the file is loaded.
+type storeFile struct {
+	Documents []Document
+}
+
+func init() {
+	// Types the metadata may contain after being decoded from JSON.
+	gob.Register([]any{})
+	gob.Register(map[string]any{})
+}
+
+func NewIndex(config Config) (Index, error) {
+	switch config.Type {
+	case "", TypeFlat:
+		return NewFlat(), nil
+	case TypeHNSW:
+		var c HNSWConfig
+		if config.HNSW != nil {
+			c = *config.HNSW
+		}
+		return NewHNSW(c), nil
+	default:
+		return nil, fmt.Errorf("index type is not supported: %s", config.Type)
+	}
+}
+
+// Open creates the index and loads the documents from the file, if it
+// exists.
+func Open(config Config) (*Store, error) {
+	index, err := NewIndex(config)
+	if err != nil {
+		return nil, err
+	}
+
+	s := &Store{
+		index: index,
+		path:  config.Path,
+	}
+
+	if err := s.load(); err != nil {
+		return nil, err
+	}
+
+	return s, nil
+}
+
+func (s *Store) Upsert(docs ...Document) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if err := s.index.Upsert(docs...); err != nil {
+		return err
+	}
+
+	return s.save()
+}
+
+func (s *Store) Delete(ids ...string) (int, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	n := s.index.Delete(ids...)
+	if n == 0 {
+		return 0, nil
+	}
+
+	return n, s.save()
+}
+
+func (s *Store) Search(vector []float64, k int, filter Filter) ([]Hit, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	return s.index.Search(vector, k, filter)
+}
+
+func (s *Store) Len() int {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	return s.index.Len()
+}
+
+func (s *Store) load() error {
+	if s.path == "" {
+		return nil
+	}
+
+	f, err := os.Open(s.path)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil
+		}
+		return err
+	}
+	defer f.Close()
+
+	var content storeFile
+	if err := gob.NewDecoder(f).Decode(&content); err != nil {
+		return fmt.Errorf("failed to read index file: %w", err)
+	}
+
+	return s.index.Upsert(content.Documents...)
+}
+
+// save writes the documents to a temporary file and replaces the original
+// one with it, so the file is never left partially written.
+func (s *Store) save() error {
+	if s.path == "" {
+		return nil
+	}
+
+	f, err := os.CreateTemp(filepath.Dir(s.path), filepath.Base(s.path)+".*")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(f.Name())
+
+	content := storeFile{
+		Documents: s.index.Documents(),
+	}
+
+	if err := gob.NewEncoder(f).Encode(&content); err != nil {
+		f.Close()
+		return err
+	}
+	if err := f.Close(); err != nil {
+		return err
+	}
+
+	return os.Rename(f.Name(), s.path)
+}
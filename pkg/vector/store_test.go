@@ -0,0 +1,67 @@
+package vector
+
+import (
+	"path/filepath"
+	"testing"
+)
+
+func TestStoreReload(t *testing.T) {
+	for _, typ := range []IndexType{TypeFlat, TypeHNSW} {
+		t.Run(string(typ), func(t *testing.T) {
+			config := Config{
+				Type: typ,
+				Path: filepath.Join(t.TempDir(), "index.gob"),
+			}
+
+			s, err := Open(config)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			if err := s.Upsert(
+				Document{ID: "a", Vector: []float64{1, 0}, Text: "a", Metadata: map[string]any{"tags": []any{"x"}}},
+				Document{ID: "b", Vector: []float64{0, 1}, Text: "b"},
+				Document{ID: "c", Vector: []float64{-1, 0}, Text: "c"},
+			); err != nil {
+				t.Fatal(err)
+			}
+			if _, err := s.Delete("c"); err != nil {
+				t.Fatal(err)
+			}
+
+			s, err = Open(config)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			if s.Len() != 2 {
+				t.Fatalf("Len() = %d, want 2", s.Len())
+			}
+
+			hits, err := s.Search([]float64{1, 0.1}, 1, nil)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if len(hits) != 1 || hits[0].ID != "a" || hits[0].Text != "a" {
+				t.Fatalf("got %v, want the document a", hits)
+			}
+			if tags, ok := hits[0].Metadata["tags"].([]any); !ok || len(tags) != 1 || tags[0] != "x" {
+				t.Errorf("metadata = %v, want the tags to be kept", hits[0].Metadata)
+			}
+		})
+	}
+}
+
+func TestStoreMemory(t *testing.T) {
+	s, err := Open(Config{})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if err := s.Upsert(Document{ID: "a", Vector: []float64{1}}); err != nil {
+		t.Fatal(err)
+	}
+	if s.Len() != 1 {
+		t.Errorf("Len() = %d, want 1", s.Len())
+	}
+}
@@ -0,0 +1,140 @@
+package vector
+
+import (
+	"cmp"
+	"errors"
+	"fmt"
+	"math"
+	"reflect"
+	"slices"
+)
+
+var ErrEmptyVector = errors.New("vector is empty or zero")
+
+type Document struct {
+	ID       string
+	Vector   []float64
+	Text     string
+	Metadata map[string]any
+}
+
+type Hit struct {
+	Document
+	// Cosine similarity of the document to the query, from -1 to 1.
+	Score float64
+}
+
+// Index stores the documents and looks up the ones, which vectors are the
+// most similar to the query. Implementations are not safe for concurrent use.
+type Index interface {
+	// Upsert adds the documents to the index, replacing the documents with
+	// the same IDs.
+	Upsert(docs ...Document) error
+	// Delete removes the documents from the index and returns the number of
+	// documents removed.
+	Delete(ids ...string) int
+	// Search returns up to k documents matching the filter, which are the
+	// most similar to the vector, in descending order of similarity.
+	Search(vector []float64, k int, filter Filter) ([]Hit, error)
+	// Documents returns all documents in the index.
+	Documents() []Document
+	Len() int
+}
+
+// Filter matches the metadata of documents. A document matches the filter
+// if its metadata contains every key of the filter with the same value. If
+// the value of the filter is a list, the metadata value must be equal to any
+// of its items.
+type Filter map[string]any
+
+func (f Filter) Match(metadata map[string]any) bool {
+	for k, want := range f {
+		v, ok := metadata[k]
+		if !ok {
+			return false
+		}
+
+		if items, ok := want.([]any); ok {
+			if !slices.ContainsFunc(items, func(item any) bool {
+				return reflect.DeepEqual(item, v)
+			}) {
+				return false
+			}
+		} else if !reflect.DeepEqual(want, v) {
+			return false
+		}
+	}
+
+	return true
+}
+
+// dimensions checks that all vectors added to the index have the same
+// number of dimensions as the first one.
+type dimensions int
+
+func (d *dimensions) check(vector []float64) error {
+	if *d == 0 {
+		*d = dimensions(len(vector))
+	} else if len(vector) != int(*d) {
+		return fmt.Errorf("vector has %d dimensions, but %d expected", len(vector), *d)
+	}
+
+	return nil
+}
+
+// prepare checks and normalizes the vectors of all documents before any of
+// them is added, so that a failed upsert leaves the index unchanged.
+func (d *dimensions) prepare(docs []Document) ([][]float64, error) {
+	dims := *d
+
+	norms := make([][]float64, len(docs))
+	for i, doc := range docs {
+		if err := dims.check(doc.Vector); err != nil {
+			return nil, err
+		}
+
+		norm, err := normalize(doc.Vector)
+		if err != nil {
+			return nil, err
+		}
+		norms[i] = norm
+	}
+
+	*d = dims
+	return norms, nil
+}
+
+// normalize returns a copy of the vector of unit length, so that cosine
+// similarity of two vectors is their dot product.
+func normalize(vector []float64) ([]float64, error) {
+	var norm float64
+	for _, v := range vector {
+		norm += v * v
+	}
+	if norm == 0 {
+		return nil, ErrEmptyVector
+	}
+
+	norm = math.Sqrt(norm)
+
+	r := make([]float64, len(vector))
+	for i, v := range vector {
+		r[i] = v / norm
+	}
+
+	return r, nil
+}
+
+func dot(a, b []float64) float64 {
+	var r float64
+	for i := range a {
+		r += a[i] * b[i]
+	}
+	return r
+}
+
+func sortHits(hits []Hit) {
+	slices.SortFunc(hits, func(a, b Hit) int {
+		return cmp.Compare(b.Score, a.Score)
+	})
+}
@@ -0,0 +1,183 @@
+package vector
+
+import (
+	"fmt"
+	"math/rand/v2"
+	"testing"
+)
+
+// getVectors returns n random vectors of the dimensions, which are the same
+// for the same seed.
+func getVectors(seed uint64, n, dims int) [][]float64 {
+	rnd := rand.New(rand.NewPCG(seed, seed))
+
+	r := make([][]float64, n)
+	for i := range r {
+		r[i] = make([]float64, dims)
+		for j := range r[i] {
+			r[i][j] = rnd.NormFloat64()
+		}
+	}
+
+	return r
+}
+
+func getIndexes() map[string]Index {
+	return map[string]Index{
+		"flat": NewFlat(),
+		"hnsw": NewHNSW(HNSWConfig{}),
+	}
+}
+
+func getIDs(hits []Hit) []string {
+	ids := make([]string, len(hits))
+	for i, h := range hits {
+		ids[i] = h.ID
+	}
+	return ids
+}
+
+func TestFilterMatch(t *testing.T) {
+	metadata := map[string]any{"lang": "en", "year": float64(2024)}
+
+	tests := []struct {
+		name   string
+		filter Filter
+		want   bool
+	}{
+		{"empty", nil, true},
+		{"equal", Filter{"lang": "en"}, true},
+		{"not equal", Filter{"lang": "de"}, false},
+		{"missing key", Filter{"author": "x"}, false},
+		{"any of list", Filter{"lang": []any{"de", "en"}}, true},
+		{"none of list", Filter{"lang": []any{"de", "fr"}}, false},
+		{"all keys", Filter{"lang": "en", "year": float64(2024)}, true},
+		{"one key differs", Filter{"lang": "en", "year": float64(2023)}, false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := tt.filter.Match(metadata); got != tt.want {
+				t.Errorf("Match() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestIndexSearchFilter(t *testing.T) {
+	for name, index := range getIndexes() {
+		t.Run(name, func(t *testing.T) {
+			for i, v := range getVectors(1, 200, 8) {
+				lang := "en"
+				if i%10 == 0 {
+					lang = "de"
+				}
+				if err := index.Upsert(Document{
+					ID:       fmt.Sprint(i),
+					Vector:   v,
+					Metadata: map[string]any{"lang": lang},
+				}); err != nil {
+					t.Fatal(err)
+				}
+			}
+
+			hits, err := index.Search(getVectors(2, 1, 8)[0], 50, Filter{"lang": "de"})
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			// Only 20 of the documents match, which is fewer than asked for.
+			if len(hits) != 20 {
+				t.Errorf("got %d hits, want 20", len(hits))
+			}
+			for _, h := range hits {
+				if h.Metadata["lang"] != "de" {
+					t.Errorf("hit %s doesn't match the filter", h.ID)
+				}
+			}
+		})
+	}
+}
+
+func TestIndexUpsertDelete(t *testing.T) {
+	for name, index := range getIndexes() {
+		t.Run(name, func(t *testing.T) {
+			if err := index.Upsert(
+				Document{ID: "a", Vector: []float64{1, 0}, Text: "a1"},
+				Document{ID: "b", Vector: []float64{0, 1}},
+				Document{ID: "c", Vector: []float64{-1, 0}},
+			); err != nil {
+				t.Fatal(err)
+			}
+
+			// The document with the same ID is replaced.
+			if err := index.Upsert(Document{ID: "a", Vector: []float64{0, -1}, Text: "a2"}); err != nil {
+				t.Fatal(err)
+			}
+			if index.Len() != 3 {
+				t.Fatalf("Len() = %d, want 3", index.Len())
+			}
+
+			hits, err := index.Search([]float64{0, -1}, 1, nil)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if len(hits) != 1 || hits[0].ID != "a" || hits[0].Text != "a2" {
+				t.Fatalf("got %v, want the replaced document a", hits)
+			}
+
+			if n := index.Delete("a", "x"); n != 1 {
+				t.Errorf("Delete() = %d, want 1", n)
+			}
+
+			hits, err = index.Search([]float64{0, -1}, 3, nil)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if ids := getIDs(hits); len(ids) != 2 || ids[0] == "a" || ids[1] == "a" {
+				t.Errorf("got %v, want the documents except a", ids)
+			}
+
+			if err := index.Upsert(Document{ID: "d", Vector: []float64{1, 2, 3}}); err == nil {
+				t.Error("Upsert() of a vector with other dimensions succeeded")
+			}
+			if err := index.Upsert(Document{ID: "d", Vector: []float64{0, 0}}); err == nil {
+				t.Error("Upsert() of a zero vector succeeded")
+			}
+		})
+	}
+}
+
+func TestIndexUpsertInvalid(t *testing.T) {
+	tests := []struct {
+		name string
+		docs []Document
+	}{
+		{"dimensions", []Document{
+			{ID: "a", Vector: []float64{1, 0}},
+			{ID: "b", Vector: []float64{1, 0, 0}},
+		}},
+		{"empty vector", []Document{
+			{ID: "a", Vector: []float64{1, 0}},
+			{ID: "b", Vector: []float64{0, 0}},
+		}},
+	}
+
+	for _, tt := range tests {
+		for name, index := range getIndexes() {
+			t.Run(tt.name+"/"+name, func(t *testing.T) {
+				if err := index.Upsert(tt.docs...); err == nil {
+					t.Fatal("Upsert() succeeded, want an error")
+				}
+				if index.Len() != 0 {
+					t.Errorf("Len() = %d, want 0", index.Len())
+				}
+
+				// The failed batch doesn't fix the dimensions of the index.
+				if err := index.Upsert(Document{ID: "c", Vector: []float64{1, 0, 0}}); err != nil {
+					t.Errorf("Upsert() = %v, want no error", err)
+				}
+			})
+		}
+	}
+}
@@ -3,35 +3,82 @@ package main
 import (
 	"context"
 	"io"
+	"log"
 	"os"
 	"os/signal"
 	"syscall"
 
 	"github.com/umk/jsonrpc2"
+	"github.com/umk/llmservices/internal/auth"
 	"github.com/umk/llmservices/internal/config"
+	"github.com/umk/llmservices/internal/mcpserver"
+	"github.com/umk/llmservices/internal/metrics"
 	"github.com/umk/llmservices/internal/service"
 	"github.com/umk/llmservices/internal/service/callbacks"
 	"github.com/umk/llmservices/internal/service/handlers"
+	"github.com/umk/llmservices/internal/service/handlers/documents"
 )
 
-type Runner struct{}
+type Runner struct {
+	// Whether the transport cannot send requests back to the caller.
+	NoCallbacks bool
+	// Principal of the session if authenticated by the transport. If not
+	// specified, the session must call the authenticate method.
+	Principal *auth.Principal
+	// Whether to speak the Model Context Protocol with the caller instead
+	// of serving the methods directly.
+	MCP bool
+}
 
 func (r Runner) Run(ctx context.Context, in io.Reader, out io.Writer) error {
-	h := service.Handler()
-
 	ctx = handlers.Context(ctx)
+	ctx = documents.Context(ctx)
 	ctx = callbacks.Context(ctx)
+	ctx = auth.Context(ctx, r.Principal)
+
+	defer metrics.StartSession()()
+
+	if r.MCP {
+		// The host of the protocol doesn't answer the callbacks.
+		*callbacks.Client(ctx) = callbacks.Disabled{}
+		return mcpserver.Run(ctx, in, out, service.Methods())
+	}
+
+	opts := []jsonrpc2.HostOption{jsonrpc2.WithServer(service.Handler())}
+	if r.NoCallbacks {
+		*callbacks.Client(ctx) = callbacks.Disabled{}
+	} else {
+		opts = append(opts, jsonrpc2.WithClient(callbacks.Client(ctx)))
+	}
 
-	return jsonrpc2.NewHost(in, out, jsonrpc2.WithServer(h)).Run(ctx)
+	return jsonrpc2.NewHost(in, out, opts...).Run(ctx)
 }
 
 func Serve(ctx context.Context) error {
-	s := jsonrpc2.NewServer(Runner{})
-
 	ch := make(chan os.Signal, 1)
 	signal.Notify(ch, os.Interrupt, syscall.SIGTERM)
 	defer signal.Stop(ch)
 
+	if config.Cur.Metrics != "" {
+		go func() {
+			if err := metrics.ListenAndServe(ctx, config.Cur.Metrics); err != nil {
+				log.Println("Error serving metrics:", err)
+			}
+		}()
+	}
+
+	if config.Cur.Listen != "" {
+		return ServeHTTP(ctx, config.Cur.Listen, ch)
+	}
+
+	// Unlike the socket, stdio is available only to the parent process.
+	r := Runner{MCP: config.Cur.MCP}
+	if config.Cur.Socket == "" {
+		r.Principal = auth.Unrestricted
+	}
+
+	s := jsonrpc2.NewServer(r)
+
 	go func() {
 		<-ch
 		s.Close()